@@ -0,0 +1,111 @@
+package chord
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrAmbiguous is the error matched by errors.Is when a key of a path is an
+// ambiguous abbreviation.
+var ErrAmbiguous = errors.New("chord: ambiguous key")
+
+// AmbiguousError is returned in place of the execution of a path holding a key
+// which abbreviates several keys, when abbreviations are enabled.
+type AmbiguousError struct {
+	Key        string   // Key of the path.
+	Candidates []string // Keys it abbreviates, in lexical order.
+}
+
+// Error implements the error interface.
+func (e *AmbiguousError) Error() string {
+	return "chord: ambiguous key " + strconv.Quote(e.Key) + ", candidates: " + strings.Join(e.Candidates, ", ")
+}
+
+// Unwrap returns ErrAmbiguous, so that errors.Is(err, ErrAmbiguous) reports true.
+func (e *AmbiguousError) Unwrap() error {
+	return ErrAmbiguous
+}
+
+// ExitCode returns ExitUsage.
+func (e *AmbiguousError) ExitCode() int {
+	return ExitUsage
+}
+
+// SetAbbreviations enables or disables abbreviations on the chord and its
+// nested chords: a key of a path which isn't registered matches the static
+// key, or alias, of which it is the only prefix, such as "st" for "status".
+// Hidden threads and chords must be spelled out. When no parameter or
+// wildcard key matches instead, a key abbreviating several keys is matched as
+// a thread failing with an *AmbiguousError listing them.
+func (c *Chord) SetAbbreviations(enabled bool) {
+	c.abbrev.Store(enabled)
+}
+
+// expand returns the key abbreviated by the prefix, among the thread keys of
+// the chord when leaf, or else its chord keys, or the prefix itself when it is
+// registered or isn't the abbreviation of a single key.
+func (c *Chord) expand(prefix string, leaf bool) string {
+	if _, ok := c.fetchEntry(prefix); ok {
+		return prefix
+	}
+	if _, ok := c.fetchMount(prefix); ok {
+		return prefix
+	}
+	if keys := c.abbreviated(prefix, leaf); len(keys) == 1 {
+		return keys[0]
+	}
+	return prefix
+}
+
+// ambiguity returns an *AmbiguousError if the prefix abbreviates several
+// keys, as by expand, and nil otherwise.
+func (c *Chord) ambiguity(prefix string, leaf bool) error {
+	if keys := c.abbreviated(prefix, leaf); len(keys) > 1 {
+		return &AmbiguousError{Key: prefix, Candidates: keys}
+	}
+	return nil
+}
+
+// abbreviated returns the visible static keys of the chord starting with the
+// prefix, or targeted by an alias starting with it, in lexical order.
+func (c *Chord) abbreviated(prefix string, leaf bool) []string {
+	visible := func(key string) bool {
+		if isParam(key) || isWildcard(key) {
+			return false
+		}
+		if leaf {
+			e, ok := c.fetchEntry(key)
+			return ok && !e.meta.Hidden && !e.meta.Internal
+		}
+		m, ok := c.fetchMount(key)
+		return ok && !m.meta.Hidden && !m.meta.Internal
+	}
+	c.ensureLoaded()
+	keys := make(map[string]bool)
+	m := &c.chords
+	if leaf {
+		m = &c.threads
+	}
+	for _, key := range sortedKeys(m) {
+		if strings.HasPrefix(key, prefix) && visible(key) {
+			keys[key] = true
+		}
+	}
+	c.mu.RLock()
+	for alias, target := range c.aliases {
+		if strings.HasPrefix(alias, prefix) {
+			keys[target] = true
+		}
+	}
+	c.mu.RUnlock()
+	res := make([]string, 0, len(keys))
+	for key := range keys {
+		if visible(key) {
+			res = append(res, key)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,91 @@
+package chord
+
+import (
+	"maps"
+	"sort"
+)
+
+// Alias makes the alias key of the chord resolve to the thread or the chord
+// registered under target during matching, such as "rm" for "remove", without
+// registering it twice. The route of the executions matched through the alias
+// holds the target. Keys registered directly on the chord take precedence
+// over aliases. The aliases of a target are removed once no thread or chord
+// is registered under it anymore, such as after Unregister. The alias is
+// validated as the keys registered by Register, and an alias targeting
+// itself, once both keys are normalized as set by SetKeyFold, fails with a
+// *KeyError.
+func (c *Chord) Alias(alias, target string) error {
+	alias, target = c.foldKey(alias), c.foldKey(target)
+	if alias == target {
+		return &KeyError{Key: alias, Reason: "alias targets itself"}
+	}
+	if err := c.validateKey(alias); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.aliases == nil {
+		c.aliases = make(map[string]string)
+	}
+	c.aliases[alias] = target
+	return nil
+}
+
+// Unalias removes the alias, and reports whether it was defined on the chord.
+func (c *Chord) Unalias(alias string) bool {
+	alias = c.foldKey(alias)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.aliases[alias]
+	delete(c.aliases, alias)
+	return ok
+}
+
+// FetchAliases returns the aliases of the chord targeting the key, in lexical
+// order.
+func (c *Chord) FetchAliases(target string) []string {
+	target = c.foldKey(target)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var aliases []string
+	for alias, t := range c.aliases {
+		if t == target {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// unalias returns the key targeted by the alias key, or key itself when a
+// thread or a chord is registered under it or it isn't an alias, normalized
+// as set by SetKeyFold.
+func (c *Chord) unalias(key string) string {
+	key = c.foldKey(key)
+	if _, ok := c.fetchEntry(key); ok {
+		return key
+	}
+	if _, ok := c.fetchMount(key); ok {
+		return key
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if target, ok := c.aliases[key]; ok {
+		return target
+	}
+	return key
+}
+
+// dropAliases removes the aliases targeting the key once neither a thread nor
+// a chord is registered under it.
+func (c *Chord) dropAliases(key string) {
+	if _, ok := c.threads.Load(key); ok {
+		return
+	}
+	if _, ok := c.chords.Load(key); ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	maps.DeleteFunc(c.aliases, func(_, target string) bool { return target == key })
+}
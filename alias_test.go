@@ -0,0 +1,34 @@
+package chord_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+func TestAlias(t *testing.T) {
+	c := chord.NewChord()
+	c.Register("remove", echo("removed"))
+	if err := c.Alias("rm", "remove"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := execute(t, c, []string{"rm"}, nil); err != nil || got != "removed" {
+		t.Fatalf("output = %q, %v, want %q", got, err, "removed")
+	}
+	c.Unregister("remove", nil)
+	if _, err := execute(t, c, []string{"rm"}, nil); !errors.Is(err, chord.ErrNotFound) {
+		t.Fatalf("error after Unregister = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAliasSelf(t *testing.T) {
+	c := chord.NewChord()
+	c.SetKeyFold(chord.FoldCase)
+	for _, alias := range []string{"rm", "RM"} {
+		var ke *chord.KeyError
+		if err := c.Alias(alias, "rm"); !errors.As(err, &ke) {
+			t.Errorf("Alias(%q, %q) = %v, want a *KeyError", alias, "rm", err)
+		}
+	}
+}
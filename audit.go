@@ -0,0 +1,238 @@
+package chord
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrAuditTampered is the error matched by errors.Is when an audit trail
+// doesn't verify, because one of its records was modified, removed or
+// inserted.
+var ErrAuditTampered = errors.New("chord: audit trail tampered")
+
+// AuditRecord is an entry of an audit trail, recording an execution of a
+// thread. Records are chained: the hash of a record covers its fields and the
+// hash of the previous record, so that any change to the trail is detected by
+// VerifyAudit.
+type AuditRecord struct {
+	Seq         uint64            `json:"seq"`                    // Position in the trail, from 1.
+	Time        time.Time         `json:"time"`                   // Time the execution returned, in UTC.
+	Caller      string            `json:"caller,omitempty"`       // Identity of the caller, if known.
+	Route       []string          `json:"route"`                  // Registered keys of the path executed.
+	Args        []string          `json:"args,omitempty"`         // Arguments of the input.
+	Flags       map[string]string `json:"flags,omitempty"`        // Flags of the input, redacted.
+	ExecutionID string            `json:"execution_id,omitempty"` // ID assigned by ExecutionID.
+	ExitCode    int               `json:"exit_code"`              // Status of the execution, as reported by ExitCode.
+	Error       string            `json:"error,omitempty"`        // Message of the error of the execution.
+	PrevHash    string            `json:"prev_hash"`              // Hash of the previous record, empty for the first one.
+	Hash        string            `json:"hash"`                   // Hash of this record.
+}
+
+// ComputeHash returns the hex-encoded SHA-256 hash of the record, covering all
+// its fields but Hash.
+func (r *AuditRecord) ComputeHash() string {
+	rec := *r
+	rec.Hash = ""
+	b, _ := json.Marshal(&rec)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditSink stores the records of an audit trail. Records are written one at a
+// time, in order.
+type AuditSink interface {
+	WriteAudit(rec *AuditRecord) error
+}
+
+// AuditSinkFunc adapts a function to an AuditSink, such as one inserting the
+// records into a database.
+type AuditSinkFunc func(rec *AuditRecord) error
+
+// WriteAudit implements AuditSink.
+func (f AuditSinkFunc) WriteAudit(rec *AuditRecord) error {
+	return f(rec)
+}
+
+// NewAuditWriter returns an AuditSink writing the records to w as JSON lines,
+// as read by VerifyAudit.
+func NewAuditWriter(w io.Writer) AuditSink {
+	enc := json.NewEncoder(w)
+	return AuditSinkFunc(func(rec *AuditRecord) error {
+		return enc.Encode(rec)
+	})
+}
+
+// AuditFile is an AuditSink appending the records to a file as JSON lines.
+type AuditFile struct {
+	f    *os.File
+	sink AuditSink
+	last *AuditRecord
+}
+
+// OpenAuditFile opens the audit trail stored in the file at path, creating it
+// if needed. The records already in the file are verified first, so that a
+// tampered trail isn't extended, and the last one is returned by Last.
+func OpenAuditFile(path string) (*AuditFile, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	last, err := VerifyAudit(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("chord: audit file %s: %w", path, err)
+	}
+	return &AuditFile{f: f, sink: NewAuditWriter(f), last: last}, nil
+}
+
+// WriteAudit implements AuditSink.
+func (a *AuditFile) WriteAudit(rec *AuditRecord) error {
+	return a.sink.WriteAudit(rec)
+}
+
+// Last returns the last record of the file when it was opened, or nil if it
+// was empty.
+func (a *AuditFile) Last() *AuditRecord {
+	return a.last
+}
+
+// Close closes the file.
+func (a *AuditFile) Close() error {
+	return a.f.Close()
+}
+
+// VerifyAudit reads an audit trail of JSON lines from r, as written by
+// NewAuditWriter, and checks the sequence and the hash chain of its records.
+// It returns the last record, or nil if the trail is empty, and an error
+// wrapping ErrAuditTampered when the trail doesn't verify.
+func VerifyAudit(r io.Reader) (*AuditRecord, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var last *AuditRecord
+	for {
+		rec := new(AuditRecord)
+		if err := dec.Decode(rec); err == io.EOF {
+			return last, nil
+		} else if err != nil {
+			return nil, err
+		}
+		switch {
+		case last == nil && (rec.Seq != 1 || rec.PrevHash != ""),
+			last != nil && (rec.Seq != last.Seq+1 || rec.PrevHash != last.Hash):
+			return nil, fmt.Errorf("%w: record %d is out of sequence", ErrAuditTampered, rec.Seq)
+		case rec.Hash != rec.ComputeHash():
+			return nil, fmt.Errorf("%w: record %d doesn't match its hash", ErrAuditTampered, rec.Seq)
+		}
+		last = rec
+	}
+}
+
+// Auditor records the executions of the threads it wraps into an append-only,
+// tamper-evident audit trail, usually as middleware of the root chord:
+//
+//	auditor := chord.NewAuditor(file)
+//	root.Use(auditor.Audit)
+type Auditor struct {
+	// Sink stores the records.
+	Sink AuditSink
+	// CallerFlag is the flag holding the identity of the caller, recorded as
+	// AuditRecord.Caller.
+	CallerFlag string
+	// Redact lists the names of the flags whose values are recorded as
+	// "[REDACTED]".
+	Redact []string
+	// OnError, when set, is called with the errors of the sink. Otherwise,
+	// they are returned by the execution along with its own error.
+	OnError func(err error, rec *AuditRecord)
+
+	mu   sync.Mutex
+	seq  uint64
+	prev string
+}
+
+// NewAuditor returns an Auditor writing to the sink. When the sink is an
+// *AuditFile, the trail resumes from its last record.
+func NewAuditor(sink AuditSink) *Auditor {
+	a := &Auditor{Sink: sink}
+	if f, ok := sink.(*AuditFile); ok {
+		a.Resume(f.Last())
+	}
+	return a
+}
+
+// Resume chains the next records after last, the last record of the trail
+// stored by the sink. A nil record restarts the trail.
+func (a *Auditor) Resume(last *AuditRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.seq, a.prev = 0, ""
+	if last != nil {
+		a.seq, a.prev = last.Seq, last.Hash
+	}
+}
+
+// Audit is a ThreadWrapper recording every execution of the thread once it
+// returned.
+func (a *Auditor) Audit(next Thread) Thread {
+	return func(in *Input, out *Output) error {
+		err := next(in, out)
+		rec := &AuditRecord{
+			Time:        time.Now().UTC().Round(0),
+			Route:       Route(in),
+			Args:        in.Args,
+			Flags:       a.flags(in.Flags),
+			ExecutionID: ExecutionIDOf(in),
+			ExitCode:    ExitCode(err),
+		}
+		if a.CallerFlag != "" {
+			rec.Caller = in.Flags[a.CallerFlag]
+		}
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		if werr := a.write(rec); werr != nil {
+			if a.OnError == nil {
+				return errors.Join(err, werr)
+			}
+			a.OnError(werr, rec)
+		}
+		return err
+	}
+}
+
+// write chains the record to the trail and writes it to the sink. The trail
+// isn't advanced when the sink fails.
+func (a *Auditor) write(rec *AuditRecord) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	rec.Seq, rec.PrevHash = a.seq+1, a.prev
+	rec.Hash = rec.ComputeHash()
+	if err := a.Sink.WriteAudit(rec); err != nil {
+		return err
+	}
+	a.seq, a.prev = rec.Seq, rec.Hash
+	return nil
+}
+
+// flags returns the flags to record, with the sensitive values redacted.
+func (a *Auditor) flags(flags map[string]string) map[string]string {
+	if len(flags) == 0 {
+		return nil
+	}
+	recorded := make(map[string]string, len(flags))
+	for name, value := range flags {
+		if slices.Contains(a.Redact, name) {
+			value = "[REDACTED]"
+		}
+		recorded[name] = value
+	}
+	return recorded
+}
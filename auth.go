@@ -0,0 +1,55 @@
+package chord
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrUnauthenticated is the error matched by errors.Is when the caller of a
+// request couldn't be authenticated.
+var ErrUnauthenticated = errors.New("chord: unauthenticated")
+
+// Credentials are presented by the caller of a request received by an
+// adapter. The adapters fill the fields their protocol carries.
+type Credentials struct {
+	RemoteAddr net.Addr             // Address of the caller.
+	User       string               // Name of the user, such as of HTTP basic or SSH authentication.
+	Password   string               // Password of the user.
+	Token      string               // Bearer token.
+	PublicKey  []byte               // Public key of the caller, in SSH wire format.
+	TLS        *tls.ConnectionState // State of the TLS connection, holding the client certificates.
+}
+
+// Authenticator authenticates the callers of the requests received by the
+// server adapters, before their threads are matched. The principal returned
+// is placed in the context of the input, as read by PrincipalOf.
+type Authenticator interface {
+	Authenticate(ctx context.Context, cred *Credentials) (*Principal, error)
+}
+
+// AuthenticatorFunc adapts a function to an Authenticator.
+type AuthenticatorFunc func(ctx context.Context, cred *Credentials) (*Principal, error)
+
+// Authenticate implements Authenticator.
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, cred *Credentials) (*Principal, error) {
+	return f(ctx, cred)
+}
+
+// Authenticate authenticates the credentials with a, as the adapters do. Errors
+// not matching ErrUnauthenticated or ErrForbidden are wrapped so that they
+// match ErrUnauthenticated, which is also returned for a nil principal.
+func Authenticate(ctx context.Context, a Authenticator, cred *Credentials) (*Principal, error) {
+	p, err := a.Authenticate(ctx, cred)
+	switch {
+	case err != nil && !errors.Is(err, ErrUnauthenticated) && !errors.Is(err, ErrForbidden):
+		return nil, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	case err != nil:
+		return nil, err
+	case p == nil:
+		return nil, ErrUnauthenticated
+	}
+	return p, nil
+}
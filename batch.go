@@ -0,0 +1,97 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrBatchStopped is the error of the requests of a batch which weren't
+// executed because a previous request failed and BatchOptions.StopOnError is
+// set.
+var ErrBatchStopped = errors.New("chord: batch stopped")
+
+// Request is the execution of the thread of a path with an input, as
+// submitted to ExecuteAll.
+type Request struct {
+	Path  []string
+	Input *Input
+}
+
+// Result is the result of a Request executed by ExecuteAll.
+type Result struct {
+	Output []byte // Output written by the thread.
+	Err    error  // Error returned by the thread, or by its matching.
+}
+
+// BatchOptions are the options of ExecuteAll.
+type BatchOptions struct {
+	// Concurrency is the maximum number of requests executed simultaneously.
+	// It defaults to GOMAXPROCS.
+	Concurrency int
+	// StopOnError stops starting requests once one fails, the requests not
+	// started failing with ErrBatchStopped.
+	StopOnError bool
+}
+
+// ExecuteAll executes the requests, as Execute does, with bounded concurrency,
+// such as for bulk operations driven by files or queues, and returns their
+// results in the order of the requests. The output of every thread is
+// captured into its result, the reader side of its Output reads nothing, and
+// its panics are recovered as by Recover. The returned error joins the errors
+// of the failed requests, prefixed by their index and path.
+func (c *Chord) ExecuteAll(reqs []Request, opts BatchOptions) ([]Result, error) {
+	results := make([]Result, len(reqs))
+	n := opts.Concurrency
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	var next atomic.Int64
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for range min(n, len(reqs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(reqs) {
+					return
+				}
+				if opts.StopOnError && failed.Load() {
+					results[i].Err = ErrBatchStopped
+					continue
+				}
+				results[i].Output, results[i].Err = c.executeRequest(reqs[i])
+				if results[i].Err != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for i, res := range results {
+		if res.Err != nil && res.Err != ErrBatchStopped {
+			errs = append(errs, fmt.Errorf("request %d (%s): %w", i, strings.Join(reqs[i].Path, " "), res.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// executeRequest executes the request and returns the output of its thread.
+func (c *Chord) executeRequest(req Request) ([]byte, error) {
+	thread, ok := Match(c, req.Path)
+	if !ok {
+		return nil, c.notFoundError(req.Path)
+	}
+	in := req.Input
+	if in == nil {
+		in = &Input{}
+	}
+	return captureOutput(executed(recoverThread(thread, nil)), in, nil)
+}
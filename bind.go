@@ -0,0 +1,193 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMissingArg is the error matched by errors.Is when a required argument is missing.
+var ErrMissingArg = errors.New("missing required argument")
+
+// ArgError is returned when the arguments of an input can't be bound to a struct.
+type ArgError struct {
+	Index int    // Index of the argument in Input.Args.
+	Name  string // Name of the argument, from its struct tag.
+	Value string // Value of the argument, empty when missing.
+	Err   error  // Parse error, or ErrMissingArg.
+}
+
+// Error implements the error interface.
+func (e *ArgError) Error() string {
+	if errors.Is(e.Err, ErrMissingArg) {
+		return fmt.Sprintf("chord: missing required argument %d (%s)", e.Index, e.Name)
+	}
+	return fmt.Sprintf("chord: invalid value %q for argument %d (%s): %v", e.Value, e.Index, e.Name, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ArgError) Unwrap() error {
+	return e.Err
+}
+
+// BindFlags unmarshals the flags and arguments of the input into the struct
+// pointed to by v, according to the `chord` tags of its fields:
+//
+//	Count   int           `chord:"count,default=3"`    // flag --count, defaults to 3
+//	Name    string        `chord:"name,required"`      // flag --name, must be set
+//	Timeout time.Duration `chord:"timeout"`            // flag --timeout, such as "2s"
+//	Tags    []string      `chord:"tags,default=a,b"`   // comma-separated flag --tags
+//	File    string        `chord:"file,arg=0,required"` // first positional argument
+//	Rest    []string      `chord:",args"`              // all positional arguments
+//
+// The default option must come last, as its value extends to the end of the
+// tag. Supported field types are strings, booleans, integers, floats,
+// time.Duration and string slices. Fields without a tag, or tagged "-", are
+// left untouched. A *FlagError or *ArgError is returned on the first failure.
+func BindFlags(in *Input, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("chord: BindFlags requires a non-nil pointer to a struct")
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup("chord")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		b := parseBindTag(tag)
+		if err := b.bind(in, rv.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// binding is the parsed form of a `chord` struct tag.
+type binding struct {
+	name       string
+	required   bool
+	def        string
+	hasDefault bool
+	arg        int // Index of the positional argument, or -1 for a flag.
+	args       bool
+}
+
+// parseBindTag parses a `chord` struct tag.
+func parseBindTag(tag string) binding {
+	name, opts, _ := strings.Cut(tag, ",")
+	b := binding{name: name, arg: -1}
+	for opts != "" {
+		if def, ok := strings.CutPrefix(opts, "default="); ok {
+			b.def, b.hasDefault = def, true
+			break
+		}
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		switch {
+		case opt == "required":
+			b.required = true
+		case opt == "args":
+			b.args = true
+		case strings.HasPrefix(opt, "arg="):
+			if n, err := strconv.Atoi(opt[len("arg="):]); err == nil && n >= 0 {
+				b.arg = n
+			}
+		}
+	}
+	return b
+}
+
+// bind sets the field from the input according to the binding.
+func (b binding) bind(in *Input, fv reflect.Value) error {
+	if b.args {
+		if fv.Type() != reflect.TypeOf([]string(nil)) {
+			return fmt.Errorf("chord: args field %s must be a []string", b.name)
+		}
+		fv.Set(reflect.ValueOf(append([]string(nil), in.Args...)))
+		return nil
+	}
+
+	var raw string
+	var ok bool
+	if b.arg >= 0 {
+		if b.arg < len(in.Args) {
+			raw, ok = in.Args[b.arg], true
+		}
+	} else {
+		raw, ok = in.Flags[b.name]
+	}
+	if !ok {
+		if b.required {
+			if b.arg >= 0 {
+				return &ArgError{Index: b.arg, Name: b.name, Err: ErrMissingArg}
+			}
+			return &FlagError{Name: b.name, Err: ErrMissingFlag}
+		}
+		if !b.hasDefault {
+			return nil
+		}
+		raw = b.def
+	}
+	if err := setField(fv, raw); err != nil {
+		if b.arg >= 0 {
+			return &ArgError{Index: b.arg, Name: b.name, Value: raw, Err: err}
+		}
+		return &FlagError{Name: b.name, Value: raw, Err: err}
+	}
+	return nil
+}
+
+// setField parses the raw value into the field according to its type.
+func setField(fv reflect.Value, raw string) error {
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Bool:
+		b, err := parseFlag(BoolFlag, raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b.(bool))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s", fv.Type())
+		}
+		s, _ := parseFlag(StringSliceFlag, raw)
+		fv.Set(reflect.ValueOf(s).Convert(fv.Type()))
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+Package botadapter dispatches chat messages through a chord tree.
+
+Messages starting with the command prefix of a Bot, such as
+"!deploy app --env=prod", are tokenized with chord.ParseLine and dispatched
+through the root chord, and the output of the thread is posted back to the
+channel of the message. Chat platforms plug in by implementing the Message
+interface; reference implementations are provided for Slack, Discord and Telegram.
+*/
+package botadapter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/graphitects/chord"
+)
+
+// Flags set on the input of the threads, overriding those of the message.
+const (
+	AuthorFlag  = "author"  // Identifier of the author of the message.
+	ChannelFlag = "channel" // Identifier of the channel of the message.
+)
+
+// Message is a chat message received by a bot.
+type Message interface {
+	// Text returns the text of the message.
+	Text() string
+	// Channel returns the identifier of the channel of the message.
+	Channel() string
+	// Author returns the identifier of the author of the message.
+	Author() string
+	// Reply posts a reply to the message in its channel.
+	Reply(ctx context.Context, text string) error
+}
+
+// Bot dispatches the commands of chat messages through a root chord.
+type Bot struct {
+	// Prefix marks the messages which are commands. It defaults to "!".
+	Prefix string
+
+	root *chord.Chord
+
+	// channels maps channel identifiers to the middleware applied to the
+	// threads dispatched from their messages.
+	channels map[string][]chord.ThreadWrapper
+	mu       sync.RWMutex
+}
+
+// New returns a Bot dispatching commands through root.
+func New(root *chord.Chord) *Bot {
+	return &Bot{Prefix: "!", root: root, channels: make(map[string][]chord.ThreadWrapper)}
+}
+
+// UseChannel registers thread wrappers (middleware) applied, in FIFO order, to
+// the threads dispatched from the messages of the channel, such as to restrict
+// the commands available in a public channel.
+func (b *Bot) UseChannel(channel string, tw ...chord.ThreadWrapper) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.channels[channel] = append(b.channels[channel], tw...)
+}
+
+// Handle dispatches the command of the message, if it starts with the prefix,
+// and replies with the output of the thread, or with its error. The author and
+// channel of the message are passed as the AuthorFlag and ChannelFlag flags.
+// It returns the error of the reply; messages which aren't commands are
+// ignored.
+func (b *Bot) Handle(ctx context.Context, msg Message) error {
+	line, ok := strings.CutPrefix(strings.TrimSpace(msg.Text()), b.Prefix)
+	if !ok || strings.TrimSpace(line) == "" {
+		return nil
+	}
+	thread, in, err := b.root.MatchLine(line)
+	if err != nil {
+		return msg.Reply(ctx, err.Error())
+	}
+	in.Flags[AuthorFlag] = msg.Author()
+	in.Flags[ChannelFlag] = msg.Channel()
+
+	b.mu.RLock()
+	thread = chord.WrapThreads(thread, b.channels[msg.Channel()]...)
+	b.mu.RUnlock()
+
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bw)}
+	err = thread(in.WithContext(chord.WithExternal(ctx)), out)
+	bw.Flush()
+	reply := strings.TrimSpace(buf.String())
+	if err != nil {
+		reply = strings.TrimSpace(reply + "\n" + err.Error())
+	}
+	if reply == "" {
+		return nil
+	}
+	return msg.Reply(ctx, reply)
+}
@@ -0,0 +1,117 @@
+package botadapter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graphitects/chord"
+)
+
+// message is a Message recording its replies.
+type message struct {
+	text, channel string
+	replies       []string
+}
+
+func (m *message) Text() string    { return m.text }
+func (m *message) Channel() string { return m.channel }
+func (m *message) Author() string  { return "bob" }
+
+func (m *message) Reply(ctx context.Context, text string) error {
+	m.replies = append(m.replies, text)
+	return nil
+}
+
+func newBot() *Bot {
+	root := chord.NewChord()
+	root.Register("greet", func(in *chord.Input, out *chord.Output) error {
+		fmt.Fprintf(out, "hello %s from %s in %s", strings.Join(in.Args, " "), in.Flags[AuthorFlag], in.Flags[ChannelFlag])
+		return out.Flush()
+	})
+	root.Register("fail", func(in *chord.Input, out *chord.Output) error {
+		return errors.New("failed")
+	})
+	return New(root)
+}
+
+func TestHandle(t *testing.T) {
+	b := newBot()
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"!greet alice", []string{"hello alice from bob in general"}},
+		{"!fail", []string{"failed"}},
+		{"!missing", []string{`chord: no thread found for path "missing"`}},
+		{"greet alice", nil},
+		{"!", nil},
+	}
+	for _, tt := range tests {
+		msg := &message{text: tt.text, channel: "general"}
+		if err := b.Handle(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(msg.replies) != fmt.Sprint(tt.want) {
+			t.Errorf("replies to %q = %q, want %q", tt.text, msg.replies, tt.want)
+		}
+	}
+}
+
+func TestUseChannel(t *testing.T) {
+	b := newBot()
+	b.UseChannel("public", func(next chord.Thread) chord.Thread {
+		return func(in *chord.Input, out *chord.Output) error {
+			return errors.New("not here")
+		}
+	})
+	public := &message{text: "!greet", channel: "public"}
+	b.Handle(context.Background(), public)
+	if len(public.replies) != 1 || public.replies[0] != "not here" {
+		t.Fatalf("replies in the public channel = %q, want [not here]", public.replies)
+	}
+	private := &message{text: "!greet", channel: "private"}
+	b.Handle(context.Background(), private)
+	if len(private.replies) != 1 || !strings.HasPrefix(private.replies[0], "hello") {
+		t.Fatalf("replies in the private channel = %q, want a greeting", private.replies)
+	}
+}
+
+func TestSlackEventsVerification(t *testing.T) {
+	h := NewSlackEvents(newBot(), "secret", &SlackClient{})
+	body := `{"type":"url_verification","challenge":"abc"}`
+	request := func(secret string, at time.Time) *http.Request {
+		ts := strconv.FormatInt(at.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("X-Slack-Request-Timestamp", ts)
+		r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+		return r
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, request("secret", time.Now()))
+	if w.Code != http.StatusOK || w.Body.String() != "abc" {
+		t.Fatalf("response = %d %q, want 200 %q", w.Code, w.Body.String(), "abc")
+	}
+	for name, r := range map[string]*http.Request{
+		"wrong secret": request("other", time.Now()),
+		"replay":       request("secret", time.Now().Add(-time.Hour)),
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("status with a %s = %d, want 401", name, w.Code)
+		}
+	}
+}
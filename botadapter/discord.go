@@ -0,0 +1,122 @@
+package botadapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// discordMaxLength is the maximum length of the content of a Discord message.
+const discordMaxLength = 2000
+
+// DiscordClient posts messages through the Discord HTTP API. Messages are
+// received through the Discord gateway, with any gateway client, and decoded
+// with Message.
+type DiscordClient struct {
+	// Token is the bot token.
+	Token string
+	// BaseURL is the base URL of the HTTP API. It defaults to "https://discord.com/api/v10".
+	BaseURL string
+	// HTTPClient is the client used for the requests. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Message decodes the data of a MESSAGE_CREATE gateway event into a message
+// replied to through the client. Messages posted by bots are reported with a
+// nil message, so that bots don't answer each other.
+func (c *DiscordClient) Message(data []byte) (*DiscordMessage, error) {
+	var ev struct {
+		DiscordMessage
+		Author struct {
+			ID  string `json:"id"`
+			Bot bool   `json:"bot"`
+		} `json:"author"`
+	}
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return nil, err
+	}
+	if ev.Author.Bot {
+		return nil, nil
+	}
+	msg := ev.DiscordMessage
+	msg.AuthorID = ev.Author.ID
+	msg.client = c
+	return &msg, nil
+}
+
+// CreateMessage posts a message to the channel, as a reply to the message
+// with the identifier if not empty. Content longer than the limit of Discord
+// is split into several messages.
+func (c *DiscordClient) CreateMessage(ctx context.Context, channelID, replyTo, content string) error {
+	for content != "" {
+		chunk := content
+		if len(chunk) > discordMaxLength {
+			chunk = chunk[:discordMaxLength]
+		}
+		content = content[len(chunk):]
+		body := map[string]any{"content": chunk}
+		if replyTo != "" {
+			body["message_reference"] = map[string]string{"message_id": replyTo}
+		}
+		if err := c.post(ctx, "/channels/"+url.PathEscape(channelID)+"/messages", body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// post sends a JSON request to the HTTP API.
+func (c *DiscordClient) post(ctx context.Context, path string, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	base := c.BaseURL
+	if base == "" {
+		base = "https://discord.com/api/v10"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bot "+c.Token)
+	res, err := httpClient(c.HTTPClient).Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("botadapter: discord: %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// DiscordMessage is a message received from Discord, implementing Message.
+type DiscordMessage struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	Content   string `json:"content"`
+	AuthorID  string `json:"-"`
+
+	client *DiscordClient
+}
+
+// Text implements Message.
+func (m *DiscordMessage) Text() string { return m.Content }
+
+// Channel implements Message.
+func (m *DiscordMessage) Channel() string { return m.ChannelID }
+
+// Author implements Message.
+func (m *DiscordMessage) Author() string { return m.AuthorID }
+
+// Reply implements Message, posting a reply referencing the message.
+func (m *DiscordMessage) Reply(ctx context.Context, text string) error {
+	return m.client.CreateMessage(ctx, m.ChannelID, m.ID, text)
+}
@@ -0,0 +1,179 @@
+package botadapter
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SlackClient posts messages through the Slack Web API.
+type SlackClient struct {
+	// Token is the bot token, starting with "xoxb-".
+	Token string
+	// BaseURL is the base URL of the Web API. It defaults to "https://slack.com/api".
+	BaseURL string
+	// HTTPClient is the client used for the requests. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// PostMessage posts a message to the channel, in the thread of the message
+// with the thread timestamp if not empty.
+func (c *SlackClient) PostMessage(ctx context.Context, channel, threadTS, text string) error {
+	body, err := json.Marshal(map[string]string{"channel": channel, "thread_ts": threadTS, "text": text})
+	if err != nil {
+		return err
+	}
+	base := c.BaseURL
+	if base == "" {
+		base = "https://slack.com/api"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/chat.postMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	res, err := httpClient(c.HTTPClient).Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return fmt.Errorf("botadapter: slack: %s: %w", res.Status, err)
+	}
+	if !result.OK {
+		return errors.New("botadapter: slack: " + result.Error)
+	}
+	return nil
+}
+
+// SlackMessage is a message received from Slack, implementing Message.
+type SlackMessage struct {
+	ChannelID string `json:"channel"`
+	User      string `json:"user"`
+	Content   string `json:"text"`
+	TS        string `json:"ts"`
+	ThreadTS  string `json:"thread_ts"`
+
+	client *SlackClient
+}
+
+// Text implements Message.
+func (m *SlackMessage) Text() string { return m.Content }
+
+// Channel implements Message.
+func (m *SlackMessage) Channel() string { return m.ChannelID }
+
+// Author implements Message.
+func (m *SlackMessage) Author() string { return m.User }
+
+// Reply implements Message, replying in the thread of the message.
+func (m *SlackMessage) Reply(ctx context.Context, text string) error {
+	ts := m.ThreadTS
+	if ts == "" {
+		ts = m.TS
+	}
+	return m.client.PostMessage(ctx, m.ChannelID, ts, text)
+}
+
+// SlackEvents is an http.Handler receiving the Slack Events API callbacks and
+// handling their message events with a Bot. Requests are authenticated with
+// the signing secret of the Slack app.
+type SlackEvents struct {
+	// SigningSecret is the signing secret of the Slack app.
+	SigningSecret string
+	// Client posts the replies of the bot.
+	Client *SlackClient
+	// OnError, when set, is called with the errors of the replies.
+	OnError func(error)
+
+	bot *Bot
+}
+
+// NewSlackEvents returns a SlackEvents handler dispatching messages through the bot.
+func NewSlackEvents(bot *Bot, signingSecret string, client *SlackClient) *SlackEvents {
+	return &SlackEvents{SigningSecret: signingSecret, Client: client, bot: bot}
+}
+
+// ServeHTTP implements http.Handler. Messages are handled asynchronously, as
+// Slack expects callbacks to be acknowledged within seconds; messages posted by
+// bots and edited or deleted messages are ignored.
+func (h *SlackEvents) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.verify(r.Header, body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	var payload struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+		Event     struct {
+			Type    string `json:"type"`
+			Subtype string `json:"subtype"`
+			BotID   string `json:"bot_id"`
+			SlackMessage
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch payload.Type {
+	case "url_verification":
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, payload.Challenge)
+		return
+	case "event_callback":
+		ev := payload.Event
+		if ev.Type == "message" && ev.Subtype == "" && ev.BotID == "" {
+			msg := ev.SlackMessage
+			msg.client = h.Client
+			ctx := context.WithoutCancel(r.Context())
+			go func() {
+				if err := h.bot.Handle(ctx, &msg); err != nil && h.OnError != nil {
+					h.OnError(err)
+				}
+			}()
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// verify checks the signature of a Slack request, rejecting requests older
+// than five minutes to prevent replays.
+func (h *SlackEvents) verify(header http.Header, body []byte) bool {
+	ts := header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || time.Since(time.Unix(sec, 0)).Abs() > 5*time.Minute {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(h.SigningSecret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature")))
+}
+
+// httpClient returns the client, or http.DefaultClient if nil.
+func httpClient(c *http.Client) *http.Client {
+	if c == nil {
+		return http.DefaultClient
+	}
+	return c
+}
@@ -0,0 +1,325 @@
+package botadapter
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graphitects/chord"
+)
+
+// telegramMaxLength is the maximum length of the text of a Telegram message.
+const telegramMaxLength = 4096
+
+// TelegramClient connects a Bot to the Telegram Bot API. Commands such as
+// "/deploy app --env=prod" or "/deploy@mybot app" in group chats are
+// dispatched through a Bot whose Prefix is "/", the command being the first
+// key of the chord path.
+type TelegramClient struct {
+	// Token is the bot token.
+	Token string
+	// Username is the username of the bot, without "@". When set, commands
+	// addressed to other bots, such as "/start@otherbot", are ignored.
+	Username string
+	// BaseURL is the base URL of the Bot API. It defaults to "https://api.telegram.org".
+	BaseURL string
+	// HTTPClient is the client used for the requests. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// TelegramCommand is a command of the menu of a Telegram bot.
+type TelegramCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+// TelegramCommands returns the menu of commands of the root chord: the keys of
+// its threads and chords, described by the description of their metadata.
+// Hidden and internal entries, and keys which aren't valid Telegram commands,
+// such as parameter keys, are left out.
+func TelegramCommands(root *chord.Chord) []TelegramCommand {
+	tree := root.Tree()
+	var cmds []TelegramCommand
+	add := func(key string, meta chord.Meta) {
+		if meta.Hidden || meta.Internal || !validTelegramCommand(key) {
+			return
+		}
+		desc := meta.Description
+		if desc == "" {
+			desc = key
+		}
+		if len(desc) > 256 {
+			desc = desc[:256]
+		}
+		cmds = append(cmds, TelegramCommand{Command: key, Description: desc})
+	}
+	for _, t := range tree.Threads {
+		add(t.Key, t.Meta)
+	}
+	for _, c := range tree.Chords {
+		add(c.Key, c.Meta)
+	}
+	return cmds
+}
+
+// validTelegramCommand reports whether the key is a valid Telegram command:
+// 1 to 32 lowercase letters, digits and underscores.
+func validTelegramCommand(key string) bool {
+	if key == "" || len(key) > 32 {
+		return false
+	}
+	for _, r := range key {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// SetCommands registers the menu of commands of the bot from the metadata of
+// the root chord, as returned by TelegramCommands.
+func (c *TelegramClient) SetCommands(ctx context.Context, root *chord.Chord) error {
+	cmds := TelegramCommands(root)
+	if cmds == nil {
+		cmds = []TelegramCommand{}
+	}
+	return c.call(ctx, "setMyCommands", map[string]any{"commands": cmds}, nil)
+}
+
+// SendMessage sends a message to the chat, as a reply to the message with the
+// identifier if not zero. Text longer than the limit of Telegram is split into
+// several messages.
+func (c *TelegramClient) SendMessage(ctx context.Context, chatID, replyTo int64, text string) error {
+	for text != "" {
+		chunk := text
+		if len(chunk) > telegramMaxLength {
+			chunk = chunk[:telegramMaxLength]
+		}
+		text = text[len(chunk):]
+		body := map[string]any{"chat_id": chatID, "text": chunk}
+		if replyTo != 0 {
+			body["reply_parameters"] = map[string]any{"message_id": replyTo, "allow_sending_without_reply": true}
+		}
+		if err := c.call(ctx, "sendMessage", body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Message decodes an update of the Bot API into a message replied to through
+// the client. Updates without a text message, messages posted by bots and
+// commands addressed to other bots are reported with a nil message. The
+// "@username" suffix of the command is removed from the text.
+func (c *TelegramClient) Message(update []byte) (*TelegramMessage, error) {
+	var u telegramUpdate
+	if err := json.Unmarshal(update, &u); err != nil {
+		return nil, err
+	}
+	return c.message(u), nil
+}
+
+// message returns the message of the update, as documented by Message.
+func (c *TelegramClient) message(u telegramUpdate) *TelegramMessage {
+	m := u.Message
+	if m == nil || m.Text == "" || m.From.IsBot {
+		return nil
+	}
+	text := m.Text
+	if strings.HasPrefix(text, "/") {
+		cmd, rest, _ := strings.Cut(text, " ")
+		if name, bot, ok := strings.Cut(cmd, "@"); ok {
+			if c.Username != "" && !strings.EqualFold(bot, c.Username) {
+				return nil
+			}
+			text = name
+			if rest != "" {
+				text += " " + rest
+			}
+		}
+	}
+	return &TelegramMessage{
+		ID:      m.MessageID,
+		ChatID:  m.Chat.ID,
+		UserID:  m.From.ID,
+		Content: text,
+		client:  c,
+	}
+}
+
+// Poll receives the updates of the bot through long polling and dispatches
+// their messages through the bot until the context is done. Messages are
+// handled concurrently; errors of the replies are reported to onError, when
+// not nil. Poll must not be used with a webhook set.
+func (c *TelegramClient) Poll(ctx context.Context, bot *Bot, onError func(error)) error {
+	var offset int64
+	for {
+		var updates []telegramUpdate
+		err := c.call(ctx, "getUpdates", map[string]any{
+			"offset":          offset,
+			"timeout":         30,
+			"allowed_updates": []string{"message"},
+		}, &updates)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if onError != nil {
+				onError(err)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if msg := c.message(u); msg != nil {
+				go func() {
+					if err := bot.Handle(ctx, msg); err != nil && onError != nil {
+						onError(err)
+					}
+				}()
+			}
+		}
+	}
+}
+
+// call invokes a method of the Bot API, decoding its result into result when not nil.
+func (c *TelegramClient) call(ctx context.Context, method string, params, result any) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	base := c.BaseURL
+	if base == "" {
+		base = "https://api.telegram.org"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/bot"+c.Token+"/"+method, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := httpClient(c.HTTPClient).Do(req)
+	if err != nil {
+		// The error of the client includes the URL, which holds the token.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return errors.New("botadapter: telegram: " + method + ": " + err.Error())
+	}
+	defer res.Body.Close()
+	var reply struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&reply); err != nil {
+		return errors.New("botadapter: telegram: " + method + ": " + res.Status)
+	}
+	if !reply.OK {
+		return errors.New("botadapter: telegram: " + method + ": " + reply.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(reply.Result, result)
+	}
+	return nil
+}
+
+// telegramUpdate is an update of the Bot API.
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		MessageID int64  `json:"message_id"`
+		Text      string `json:"text"`
+		From      struct {
+			ID    int64 `json:"id"`
+			IsBot bool  `json:"is_bot"`
+		} `json:"from"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+// TelegramMessage is a message received from Telegram, implementing Message.
+type TelegramMessage struct {
+	ID      int64
+	ChatID  int64
+	UserID  int64
+	Content string
+
+	client *TelegramClient
+}
+
+// Text implements Message.
+func (m *TelegramMessage) Text() string { return m.Content }
+
+// Channel implements Message, returning the identifier of the chat.
+func (m *TelegramMessage) Channel() string { return strconv.FormatInt(m.ChatID, 10) }
+
+// Author implements Message, returning the identifier of the user.
+func (m *TelegramMessage) Author() string { return strconv.FormatInt(m.UserID, 10) }
+
+// Reply implements Message, replying to the message in its chat.
+func (m *TelegramMessage) Reply(ctx context.Context, text string) error {
+	return m.client.SendMessage(ctx, m.ChatID, m.ID, text)
+}
+
+// TelegramWebhook is an http.Handler receiving the updates of a Telegram bot
+// set up with a webhook, and dispatching their messages through a Bot.
+type TelegramWebhook struct {
+	// SecretToken, when set, must match the secret token of the webhook.
+	SecretToken string
+	// Client decodes the updates and posts the replies of the bot.
+	Client *TelegramClient
+	// OnError, when set, is called with the errors of the replies.
+	OnError func(error)
+
+	bot *Bot
+}
+
+// NewTelegramWebhook returns a TelegramWebhook handler dispatching messages through the bot.
+func NewTelegramWebhook(bot *Bot, client *TelegramClient) *TelegramWebhook {
+	return &TelegramWebhook{Client: client, bot: bot}
+}
+
+// ServeHTTP implements http.Handler. Messages are handled asynchronously, so
+// that slow threads don't hold back the delivery of updates.
+func (h *TelegramWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	if h.SecretToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.SecretToken)) != 1 {
+		http.Error(w, "invalid secret token", http.StatusUnauthorized)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	msg, err := h.Client.Message(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg != nil {
+		ctx := context.WithoutCancel(r.Context())
+		go func() {
+			if err := h.bot.Handle(ctx, msg); err != nil && h.OnError != nil {
+				h.OnError(err)
+			}
+		}()
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,27 @@
+package botadapter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+func TestTelegramCommands(t *testing.T) {
+	root := chord.NewChord()
+	root.RegisterWithMeta("status", func(*chord.Input, *chord.Output) error { return nil }, chord.Meta{Description: "Show the status"})
+	root.Register("deploy_app", func(*chord.Input, *chord.Output) error { return nil })
+	root.Register("Invalid-Key", func(*chord.Input, *chord.Output) error { return nil })
+	root.RegisterWithMeta("secret", func(*chord.Input, *chord.Output) error { return nil }, chord.Meta{Hidden: true})
+	root.Mount("admin", chord.NewChord())
+
+	got := fmt.Sprint(TelegramCommands(root))
+	want := fmt.Sprint([]TelegramCommand{
+		{Command: "deploy_app", Description: "deploy_app"},
+		{Command: "status", Description: "Show the status"},
+		{Command: "admin", Description: "admin"},
+	})
+	if got != want {
+		t.Fatalf("TelegramCommands = %s, want %s", got, want)
+	}
+}
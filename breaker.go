@@ -0,0 +1,204 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is the error returned by the threads guarded by a
+// CircuitBreaker while their circuit is open.
+var ErrCircuitOpen = errors.New("chord: circuit open")
+
+// CircuitState is the state of a circuit of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets the executions through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects the executions with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets one trial execution through, closing the circuit
+	// if it succeeds, and rejects the others.
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreaker stops invoking the threads it guards after consecutive
+// failures, such as threads proxying an unavailable remote service: the circuit
+// opens after Threshold consecutive failures, short-circuiting the executions
+// for Cooldown, then half-opens to let a trial execution through. Executions
+// are accounted in one circuit per route, so that a failing thread doesn't
+// open the circuit of the other threads of a guarded chord, or in one circuit
+// per key computed by KeyFunc.
+//
+// The fields are meant to be set before the breaker is used.
+type CircuitBreaker struct {
+	// Threshold is the number of consecutive failures opening the circuit.
+	// It defaults to 5.
+	Threshold int
+	// Cooldown is the duration a circuit stays open. It defaults to 30s.
+	Cooldown time.Duration
+	// KeyFunc, when set, selects the circuit of an execution, such as ByKey.
+	// It defaults to ByRoute.
+	KeyFunc func(*Input) string
+	// IsFailure, when set, reports whether an error counts as a failure.
+	// Otherwise all the errors do.
+	IsFailure func(error) bool
+	// OnStateChange, when set, is called when the circuit of a key changes of
+	// state.
+	OnStateChange func(key string, from, to CircuitState)
+
+	circuits map[string]*circuit
+	mu       sync.Mutex
+}
+
+// circuit is a circuit of a CircuitBreaker.
+type circuit struct {
+	state    CircuitState
+	failures int       // Consecutive failures while closed.
+	openedAt time.Time // Time the circuit last opened.
+	trial    bool      // Whether a trial execution is running while half-open.
+}
+
+// NewCircuitBreaker returns a breaker opening the circuit of a route after
+// threshold consecutive failures, for the cooldown duration.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+// Guard is a ThreadWrapper invoking the thread through the circuit of the
+// execution. While the circuit is open, the thread isn't invoked:
+// ErrCircuitOpen is written to the Output and returned. A panic of the thread
+// counts as a failure.
+func (b *CircuitBreaker) Guard(next Thread) Thread {
+	return func(in *Input, out *Output) error {
+		keyFunc := b.KeyFunc
+		if keyFunc == nil {
+			keyFunc = ByRoute
+		}
+		key := keyFunc(in)
+		if !b.allow(key) {
+			if out != nil && out.Writer != nil {
+				fmt.Fprintln(out, ErrCircuitOpen)
+				out.Flush()
+			}
+			return ErrCircuitOpen
+		}
+		failed := true
+		defer func() { b.record(key, failed) }()
+		err := next(in, out)
+		failed = err != nil && (b.IsFailure == nil || b.IsFailure(err))
+		return err
+	}
+}
+
+// State returns the state of the circuit of the key.
+func (b *CircuitBreaker) State(key string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[key]
+	if !ok {
+		return CircuitClosed
+	}
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= b.cooldown() {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// allow reports whether an execution may go through the circuit of the key.
+func (b *CircuitBreaker) allow(key string) bool {
+	b.mu.Lock()
+	c := b.circuit(key)
+	from := c.state
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= b.cooldown() {
+		c.state = CircuitHalfOpen
+	}
+	allowed := true
+	if c.state == CircuitOpen || (c.state == CircuitHalfOpen && c.trial) {
+		allowed = false
+	} else if c.state == CircuitHalfOpen {
+		c.trial = true
+	}
+	to := c.state
+	b.mu.Unlock()
+	b.changed(key, from, to)
+	return allowed
+}
+
+// record records the outcome of an execution through the circuit of the key.
+func (b *CircuitBreaker) record(key string, failed bool) {
+	b.mu.Lock()
+	c := b.circuit(key)
+	from := c.state
+	switch {
+	case !failed:
+		c.state = CircuitClosed
+		c.failures = 0
+	case c.state == CircuitOpen:
+		// A failure of an execution started before the circuit opened.
+	case c.state == CircuitHalfOpen:
+		c.state = CircuitOpen
+		c.openedAt = time.Now()
+	default:
+		c.failures++
+		threshold := b.Threshold
+		if threshold <= 0 {
+			threshold = 5
+		}
+		if c.failures >= threshold {
+			c.state = CircuitOpen
+			c.openedAt = time.Now()
+			c.failures = 0
+		}
+	}
+	c.trial = false
+	to := c.state
+	if to == CircuitClosed && c.failures == 0 {
+		delete(b.circuits, key)
+	}
+	b.mu.Unlock()
+	b.changed(key, from, to)
+}
+
+// circuit returns the circuit of the key, creating it closed if missing.
+func (b *CircuitBreaker) circuit(key string) *circuit {
+	if b.circuits == nil {
+		b.circuits = make(map[string]*circuit)
+	}
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+// cooldown returns the duration a circuit stays open.
+func (b *CircuitBreaker) cooldown() time.Duration {
+	if b.Cooldown <= 0 {
+		return 30 * time.Second
+	}
+	return b.Cooldown
+}
+
+// changed calls OnStateChange if the state of the circuit changed.
+func (b *CircuitBreaker) changed(key string, from, to CircuitState) {
+	if from != to && b.OnStateChange != nil {
+		b.OnStateChange(key, from, to)
+	}
+}
@@ -0,0 +1,65 @@
+package chord_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/graphitects/chord"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	c := chord.NewChord()
+	b := chord.NewCircuitBreaker(2, time.Hour)
+	c.Use(b.Guard)
+	calls := 0
+	c.Register("down", func(*chord.Input, *chord.Output) error {
+		calls++
+		return errors.New("down")
+	})
+	c.Register("up", nop)
+
+	for range 2 {
+		execute(t, c, []string{"down"}, nil)
+	}
+	if _, err := execute(t, c, []string{"down"}, nil); !errors.Is(err, chord.ErrCircuitOpen) {
+		t.Fatalf("execution after the threshold = %v, want ErrCircuitOpen", err)
+	}
+	if calls != 2 {
+		t.Fatalf("thread called %d times, want 2", calls)
+	}
+	if _, err := execute(t, c, []string{"up"}, nil); err != nil {
+		t.Fatalf("other route = %v, want its circuit closed", err)
+	}
+	if s := b.State("down"); s != chord.CircuitOpen {
+		t.Fatalf("State = %v, want open", s)
+	}
+}
+
+func TestCircuitBreakerPanicTrial(t *testing.T) {
+	c := chord.NewChord()
+	b := chord.NewCircuitBreaker(1, 10*time.Millisecond)
+	c.Use(chord.Recover, b.Guard)
+	fail := true
+	c.Register("x", func(*chord.Input, *chord.Output) error {
+		if fail {
+			panic("boom")
+		}
+		return nil
+	})
+
+	execute(t, c, []string{"x"}, nil)
+	time.Sleep(20 * time.Millisecond)
+	execute(t, c, []string{"x"}, nil) // Panicking trial, reopening the circuit.
+	if s := b.State("x"); s != chord.CircuitOpen {
+		t.Fatalf("State after a panicking trial = %v, want open", s)
+	}
+	fail = false
+	time.Sleep(20 * time.Millisecond)
+	if _, err := execute(t, c, []string{"x"}, nil); err != nil {
+		t.Fatalf("trial after the cooldown = %v, want it let through", err)
+	}
+	if s := b.State("x"); s != chord.CircuitClosed {
+		t.Fatalf("State = %v, want closed", s)
+	}
+}
@@ -0,0 +1,81 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// BroadcastResult is the result of a thread invoked by Broadcast.
+type BroadcastResult struct {
+	Path   []string // Path of the thread from the chord Broadcast was called on.
+	Output []byte   // Output written by the thread.
+	Err    error    // Error returned by the thread.
+}
+
+// BroadcastReport aggregates the results of the threads invoked by Broadcast,
+// in the order in which Walk visits them.
+type BroadcastReport struct {
+	Results []BroadcastResult
+}
+
+// Err returns the errors of the threads which failed, joined and prefixed by
+// their path, or nil if all of them succeeded.
+func (r *BroadcastReport) Err() error {
+	var errs []error
+	for _, res := range r.Results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", strings.Join(res.Path, " "), res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Broadcast invokes concurrently every thread of the chord mounted under path,
+// including the threads of its nested chords, such as to "reload" all the
+// mounted modules, and returns their results. Every thread is matched from
+// this chord, wrapped with the middleware of the chords traversed, and
+// receives its own copy of the input, as Execute does; its output is captured
+// into its result and its panics are recovered as by Recover. Threads under
+// parameter or wildcard keys are skipped, as no path selects them without
+// values. A *NotFoundError is returned when no chord is mounted under path; an
+// empty path broadcasts to the whole tree.
+func (c *Chord) Broadcast(path []string, in *Input) (*BroadcastReport, error) {
+	node := c
+	for _, key := range path {
+		next, ok := node.FetchChord(key)
+		if !ok {
+			return nil, &NotFoundError{Path: path}
+		}
+		node = next
+	}
+
+	report := &BroadcastReport{}
+	node.Walk(func(p []string, _ Thread, _ Meta) bool {
+		if !slices.ContainsFunc(p, func(key string) bool { return isParam(key) || isWildcard(key) }) {
+			report.Results = append(report.Results, BroadcastResult{Path: slices.Concat(path, p)})
+		}
+		return true
+	})
+
+	var wg sync.WaitGroup
+	for i := range report.Results {
+		res := &report.Results[i]
+		thread, ok := Match(c, res.Path)
+		if !ok {
+			res.Err = &NotFoundError{Path: res.Path}
+			continue
+		}
+		in2 := *in
+		in2.Args = slices.Clone(in.Args)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res.Output, res.Err = captureOutput(executed(recoverThread(thread, nil)), &in2, nil)
+		}()
+	}
+	wg.Wait()
+	return report, nil
+}
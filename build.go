@@ -0,0 +1,78 @@
+package chord
+
+import (
+	"context"
+	"slices"
+)
+
+// Compiled is the read-only compiled form of a tree of chords, as returned by
+// Build, dispatching the threads of a snapshot of the tree which can't be
+// changed anymore.
+type Compiled struct {
+	root *Chord // Snapshot of the tree, never changed once built.
+	// index maps the static paths of the threads, by path ID, to their threads
+	// wrapped as by Match.
+	index map[string]Thread
+}
+
+// Build compiles the tree of the chord into its read-only form, for servers
+// which register everything at startup. The tree is copied as by Clone, and
+// the threads of its static paths are matched once, their middleware chains
+// built upfront and indexed by path, so that dispatching them is a single
+// lookup without any lock. The other paths, such as the ones of parameter and
+// wildcard keys, aliases, abbreviations and fallbacks, and the threads
+// registered by RegisterFactory, are matched on the copy as by Match. The
+// compiled form has no method changing it: the changes of the chord after the
+// build are not dispatched by it, and a new build is needed to take them into
+// account.
+func (c *Chord) Build() *Compiled {
+	root := c.Clone()
+	b := &Compiled{root: root, index: make(map[string]Thread)}
+	root.walkEntries(nil, func(path []string, e *entry) bool {
+		if e.factory != nil || slices.ContainsFunc(path, func(key string) bool { return isParam(key) || isWildcard(key) }) {
+			return true
+		}
+		if thread, ok := Match(root, path); ok {
+			b.index[pathID(path)] = thread
+		}
+		return true
+	})
+	return b
+}
+
+// Match returns the thread matched by the path, as Match does on the tree
+// compiled.
+func (b *Compiled) Match(path []string) (Thread, bool) {
+	if thread, ok := b.index[pathID(path)]; ok {
+		return thread, true
+	}
+	return Match(b.root, path)
+}
+
+// Execute matches the thread of the path and invokes it with the input and
+// output, as Chord.Execute does on the tree compiled.
+func (b *Compiled) Execute(path []string, in *Input, out *Output) error {
+	thread, ok := b.Match(path)
+	if !ok {
+		return b.root.notFoundError(path)
+	}
+	return execute(thread, in, out)
+}
+
+// Routes returns the paths of the visible threads of the tree compiled, as
+// Chord.Routes does.
+func (b *Compiled) Routes() [][]string {
+	return b.root.Routes()
+}
+
+// Stats returns the statistics of the threads executed through the compiled
+// form, as Chord.Stats does.
+func (b *Compiled) Stats() []ThreadStats {
+	return b.root.Stats()
+}
+
+// Shutdown stops the compiled form from accepting new executions and waits
+// for the executions in flight to return, as Chord.Shutdown does.
+func (b *Compiled) Shutdown(ctx context.Context) error {
+	return b.root.Shutdown(ctx)
+}
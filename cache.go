@@ -0,0 +1,146 @@
+package chord
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache returns a ThreadWrapper memoizing the output of the threads it wraps,
+// for expensive read-only threads such as those behind bots and HTTP adapters.
+// Executions are identified by the route matched, as returned by Route, and by
+// the Key, Args, Flags and Params of their input, so that the threads sharing
+// the cache don't receive the outputs of one another.
+// When an identical execution succeeded less than ttl ago, its output is
+// written again without invoking the thread; otherwise the thread writes to a
+// buffer copied to the Output once it returns, and its output is cached if it
+// returned no error. A ttl of zero or less never expires entries.
+//
+// At most maxEntries outputs are cached, the least recently used being evicted
+// first; zero or less doesn't bound the cache. The cache is shared by all the
+// threads wrapped by the returned wrapper, such as when passed to Use.
+func Cache(ttl time.Duration, maxEntries int) ThreadWrapper {
+	c := &outputCache{ttl: ttl, max: maxEntries, lru: list.New(), entries: make(map[string]*list.Element)}
+	return func(next Thread) Thread {
+		return func(in *Input, out *Output) error {
+			key := inputKey(in)
+			if data, ok := c.get(key); ok {
+				return writeOutput(out, data)
+			}
+			data, err := captureOutput(next, in, out)
+			if err == nil {
+				c.add(key, data)
+			}
+			if werr := writeOutput(out, data); err == nil {
+				err = werr
+			}
+			return err
+		}
+	}
+}
+
+// outputCache is a least-recently-used cache of outputs with expiration.
+type outputCache struct {
+	ttl     time.Duration
+	max     int
+	lru     *list.List // Elements of *cachedOutput, most recently used first.
+	entries map[string]*list.Element
+	mu      sync.Mutex
+}
+
+// cachedOutput is an output held by an outputCache.
+type cachedOutput struct {
+	key     string
+	data    []byte
+	expires time.Time // Zero when the output doesn't expire.
+}
+
+// get returns the output cached under the key, if not expired.
+func (c *outputCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	co := el.Value.(*cachedOutput)
+	if !co.expires.IsZero() && time.Now().After(co.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return co.data, true
+}
+
+// add caches the output under the key, evicting the least recently used
+// outputs beyond the size of the cache.
+func (c *outputCache) add(key string, data []byte) {
+	co := &cachedOutput{key: key, data: data}
+	if c.ttl > 0 {
+		co.expires = time.Now().Add(c.ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value = co
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(co)
+	for c.max > 0 && c.lru.Len() > c.max {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.entries, el.Value.(*cachedOutput).key)
+	}
+}
+
+// inputKey returns a string identifying the route matched and the Key, Args,
+// Flags and Params of the input.
+func inputKey(in *Input) string {
+	b, _ := json.Marshal([]any{strings.Join(Route(in), "\x00"), in.Key, in.Args, sortedPairs(in.Flags), sortedPairs(in.Params)})
+	return string(b)
+}
+
+// sortedPairs returns the entries of the map as key and value pairs, in lexical
+// order of the keys.
+func sortedPairs(m map[string]string) [][2]string {
+	pairs := make([][2]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, [2]string{k, v})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+	return pairs
+}
+
+// captureOutput invokes the thread with an Output reading from out, if not nil,
+// and writing to a buffer, and returns the content of the buffer.
+func captureOutput(thread Thread, in *Input, out *Output) ([]byte, error) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	rd := bufio.NewReader(bytes.NewReader(nil))
+	if out != nil && out.Reader != nil {
+		rd = out.Reader
+	}
+	err := thread(in, &Output{ReadWriter: *bufio.NewReadWriter(rd, bw)})
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return buf.Bytes(), err
+}
+
+// writeOutput writes the data to the output, if not nil, and flushes it.
+func writeOutput(out *Output, data []byte) error {
+	if out == nil || out.Writer == nil || len(data) == 0 {
+		return nil
+	}
+	if _, err := out.Write(data); err != nil {
+		return err
+	}
+	return out.Flush()
+}
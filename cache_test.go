@@ -0,0 +1,55 @@
+package chord_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/graphitects/chord"
+)
+
+func TestCache(t *testing.T) {
+	c := chord.NewChord()
+	calls := 0
+	c.Use(chord.Cache(time.Minute, 0))
+	c.Register("n", func(in *chord.Input, out *chord.Output) error {
+		calls++
+		fmt.Fprint(out, calls)
+		return out.Flush()
+	})
+	for range 3 {
+		if got, err := execute(t, c, []string{"n"}, &chord.Input{Args: []string{"x"}}); err != nil || got != "1" {
+			t.Fatalf("output = %q, %v, want the cached %q", got, err, "1")
+		}
+	}
+	if got, _ := execute(t, c, []string{"n"}, &chord.Input{Args: []string{"y"}}); got != "2" {
+		t.Fatalf("output for other arguments = %q, want %q", got, "2")
+	}
+}
+
+func TestCacheKeyedByRoute(t *testing.T) {
+	c := chord.NewChord()
+	c.Use(chord.Cache(time.Minute, 0))
+	c.Register("a", echo("a"))
+	c.Register("b", echo("b"))
+	for _, key := range []string{"a", "b", "a"} {
+		if got, err := execute(t, c, []string{key}, &chord.Input{}); err != nil || got != key {
+			t.Fatalf("Execute(%q) = %q, %v, want %q", key, got, err, key)
+		}
+	}
+}
+
+func TestCacheSkipsErrors(t *testing.T) {
+	c := chord.NewChord()
+	calls := 0
+	c.Use(chord.Cache(time.Minute, 0))
+	c.Register("x", func(*chord.Input, *chord.Output) error {
+		calls++
+		return fmt.Errorf("failure %d", calls)
+	})
+	execute(t, c, []string{"x"}, nil)
+	execute(t, c, []string{"x"}, nil)
+	if calls != 2 {
+		t.Fatalf("thread called %d times, want failures not to be cached", calls)
+	}
+}
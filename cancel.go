@@ -0,0 +1,34 @@
+package chord
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// cancelWriter writes to w until the context is done, then discards the
+// writes and fails with the error of the context.
+type cancelWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+// Write implements io.Writer.
+func (cw *cancelWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cw.w.Write(p)
+}
+
+// cancelOutput returns an Output reading from out and writing to it until
+// the context is done, and a function flushing it. Flushing the returned
+// Output flushes out, so that streaming threads keep streaming. When the
+// context can't be done, out is returned as is.
+func cancelOutput(ctx context.Context, out *Output) (*Output, func() error) {
+	if ctx.Done() == nil || out == nil || out.Writer == nil {
+		return out, func() error { return nil }
+	}
+	bw := bufio.NewWriter(&cancelWriter{ctx: ctx, w: &flushWriter{out: out}})
+	return &Output{ReadWriter: *bufio.NewReadWriter(out.Reader, bw)}, bw.Flush
+}
@@ -18,7 +18,10 @@ package chord
 
 import (
 	"bufio"
+	"context"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 // Input represents the input to a thread, including a key, arguments, and flags.
@@ -26,6 +29,38 @@ type Input struct {
 	Key   string            // Identifier for the thread execution context.
 	Args  []string          // Arguments to be passed to the thread.
 	Flags map[string]string // Optional flags to control thread behavior.
+
+	// Params holds the keys of the path captured by parameter and wildcard keys,
+	// such as "id" for a thread registered under "user/:id/show".
+	Params map[string]string
+
+	// ctx is the context of the thread execution. It is read through Context
+	// and replaced through WithContext, so that a zero Input stays usable.
+	ctx context.Context
+}
+
+// Context returns the context of the input. The returned context is never nil;
+// it defaults to the background context.
+//
+// The context is the one of the dispatch, such as the request of an adapter,
+// possibly narrowed by middleware such as Timeout. Threads should return once
+// it is done: Execute and ExecuteLine discard their output from then on.
+func (in *Input) Context() context.Context {
+	if in.ctx != nil {
+		return in.ctx
+	}
+	return context.Background()
+}
+
+// WithContext returns a shallow copy of the input with its context changed to ctx.
+// Threads observe cancellation, deadlines and request-scoped values through it.
+func (in *Input) WithContext(ctx context.Context) *Input {
+	if ctx == nil {
+		panic("chord: nil context")
+	}
+	in2 := *in
+	in2.ctx = ctx
+	return &in2
 }
 
 // Output represents the output from a thread, using a buffered read-writer.
@@ -35,106 +70,396 @@ type Output struct {
 
 // Thread is a function type that takes an Input and an Output.
 // This defines the basic execution unit in the chord system.
-type Thread func(*Input, *Output)
+// The returned error is propagated through the middleware chain to the caller
+// of the matched thread.
+type Thread func(*Input, *Output) error
+
+// ThreadCtx is a context-aware variant of Thread which receives the context of
+// the input as an explicit first parameter.
+type ThreadCtx func(context.Context, *Input, *Output) error
+
+// Thread adapts the ThreadCtx to a Thread, so it can be registered and wrapped
+// like any other thread. The context passed is the one carried by the Input.
+func (t ThreadCtx) Thread() Thread {
+	return func(in *Input, out *Output) error {
+		return t(in.Context(), in, out)
+	}
+}
+
+// SimpleThread is a variant of Thread which cannot fail. Failures can only be
+// communicated by writing to the Output.
+type SimpleThread func(*Input, *Output)
+
+// Thread adapts the SimpleThread to a Thread which always returns a nil error.
+func (t SimpleThread) Thread() Thread {
+	return func(in *Input, out *Output) error {
+		t(in, out)
+		return nil
+	}
+}
 
 // Chord holds a collection of threads and composite chords, managed via sync.Map
 // for safe concurrent access. It also supports middleware that can be applied
 // to threads and chords.
 type Chord struct {
 	// threads is a sync map that maps keys to threads.
-	// Key: string -> thread name
-	// Value: Thread -> the thread function
+	// Key: string    -> thread name
+	// Value: *entry  -> the thread function and its metadata
 	threads sync.Map
-	
+
 	// chords is a sync map that maps keys to composite chords.
 	// Key: string     -> chord name
-	// Value: *Chord   -> pointer to the chord itself
+	// Value: *mount   -> the chord itself and the wrappers supplied at mount time
 	chords sync.Map
 
 	// middlewares is a slice of thread wrappers that allow threads/chords to be
 	// wrapped in a pipeline pattern. The wrapping is applied in FIFO order,
 	// where the first middleware is the outermost wrapper.
-	middlewares []ThreadWrapper
+	// Each wrapper may be given a name, to be removed or replaced at runtime.
+	middlewares []middleware
+
+	// chordWrappers is a slice of chord wrappers applied, in FIFO order, to the
+	// threads matched through the chords mounted on this chord.
+	chordWrappers []ChordWrapper
+
+	// notFound is the fallback thread invoked when Match fails at this chord.
+	notFound Thread
+
+	// onPanic is the callback reporting panics recovered at this chord.
+	onPanic func(*Input, *PanicError)
+
+	// observers are the callbacks notified of the registration changes.
+	observers []*observer
+
+	// persistentFlags are the flags inherited by the threads matched through this chord.
+	persistentFlags *FlagSet
+
+	// lazy, when not nil, loads the threads and chords on first access.
+	lazy *lazyLoad
+
+	// aliases maps the aliases of the chord to the keys they target.
+	aliases map[string]string
+
+	// abbrev enables abbreviations on the chord and its nested chords.
+	abbrev atomic.Bool
+
+	// keyFold is the KeyFold normalizing the static keys of the chord.
+	keyFold atomic.Uint32
+
+	// strict enables the strict mode of the chord, as set by SetStrict.
+	strict atomic.Bool
+
+	// maxDepth is the maximum number of keys of the paths matched from the
+	// chord, as set by SetMaxDepth, or zero when unlimited.
+	maxDepth atomic.Int64
+
+	// keyValidator, when set, validates the keys registered on the chord.
+	keyValidator func(key string) error
+
+	// parent and parentKey locate the chord in the tree, as last mounted.
+	parent    *Chord
+	parentKey string
+
+	// defaultVersion is the version of the threads dispatched when the input
+	// selects none, as set by SetDefaultVersion.
+	defaultVersion string
+
+	// delimiter separates the keys of the path strings matched from the chord,
+	// "/" when empty.
+	delimiter string
+
+	// plugins maps the names of the plugins loaded on the chord to their contributions.
+	plugins map[string]*pluginContribution
+
+	// inflight tracks the executions of the threads matched through the chord.
+	inflight inflight
+
+	// stats maps the routes matched through the chord to their statistics.
+	// Key: string         -> keys of the route, joined with NUL characters
+	// Value: *threadStats -> the statistics of the executions of the route
+	stats sync.Map
+
+	// startHooks and stopHooks are the functions called by Start and Stop.
+	startHooks []func(context.Context) error
+	stopHooks  []func(context.Context) error
+
+	// mu guards the fields of the chord which are not sync maps.
+	mu sync.RWMutex
 }
 
 // NewChord returns an instance of a Chord
 func NewChord() *Chord {
 	return &Chord{
-		threads: sync.Map{},
-		chords: sync.Map{},
-		middlewares: make([]ThreadWrapper, 0),
+		threads:       sync.Map{},
+		chords:        sync.Map{},
+		middlewares:   make([]middleware, 0),
+		chordWrappers: make([]ChordWrapper, 0),
 	}
 }
 
 // FetchThread retrieves a thread from the threads map using its key.
 // Returns the thread and true if found, or nil and false otherwise.
 func (c *Chord) FetchThread(key string) (Thread, bool) {
-	thread, ok := c.threads.Load(key)
+	e, ok := c.fetchEntry(key)
+	if !ok {
+		return nil, false
+	}
+
+	return e.thread, true
+}
+
+// fetchEntry retrieves the entry of a thread from the threads map using its key.
+func (c *Chord) fetchEntry(key string) (*entry, bool) {
+	c.ensureLoaded()
+	e, ok := c.threads.Load(c.foldKey(key))
 	if !ok {
 		return nil, false
 	}
 
-	return thread.(Thread), true
+	return e.(*entry), true
 }
 
 // FetchChord retrieves a chord (composite type) from the chords map using its key.
 // Returns the chord pointer and true if found, or nil and false otherwise.
 func (c *Chord) FetchChord(key string) (*Chord, bool) {
-	chord, ok := c.chords.Load(key)
+	m, ok := c.fetchMount(key)
 	if !ok {
 		return nil, false
 	}
 
-	return chord.(*Chord), true
+	return m.chord, true
+}
+
+// fetchMount retrieves the mount of a chord from the chords map using its key.
+func (c *Chord) fetchMount(key string) (*mount, bool) {
+	c.ensureLoaded()
+	m, ok := c.chords.Load(c.foldKey(key))
+	if !ok {
+		return nil, false
+	}
+
+	return m.(*mount), true
 }
 
 // FetchMiddlewares returns a copy of the current slice of middleware wrappers.
 // A copy is provided to avoid external modifications to the original slice.
 func (c *Chord) FetchMiddlewares() []ThreadWrapper {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	md := make([]ThreadWrapper, len(c.middlewares))
-	copy(md, c.middlewares)
+	for i, m := range c.middlewares {
+		md[i] = m.wrapper
+	}
 	return md
 }
 
-// Register adds a thread to the threads map with the given key.
-// Optionally, additional thread wrappers (middleware) can be provided and are
-// applied in FIFO order.
-func (c *Chord) Register(key string, thread Thread, tw ...ThreadWrapper) {
-	thread = WrapThreads(thread, tw...)
-	c.threads.Store(key, thread)
+// FetchChordWrappers returns a copy of the current slice of chord wrappers.
+func (c *Chord) FetchChordWrappers() []ChordWrapper {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cw := make([]ChordWrapper, len(c.chordWrappers))
+	copy(cw, c.chordWrappers)
+	return cw
+}
+
+// Register adds a thread to the threads map with the given key, and returns the
+// Registration of the thread. Optionally, additional thread wrappers
+// (middleware) can be provided and are applied in FIFO order. Nothing is
+// registered when the key validator of the chord rejects the key, failing with
+// a *KeyError, or when a thread is already registered under the key, failing
+// with a *DuplicateError: Replace overrides it.
+func (c *Chord) Register(key string, thread Thread, tw ...ThreadWrapper) (*Registration, error) {
+	return c.RegisterWithMeta(key, thread, Meta{}, tw...)
+}
+
+// Replace registers a thread under key, as Register does, replacing the thread
+// already registered under it, if any.
+func (c *Chord) Replace(key string, thread Thread, tw ...ThreadWrapper) (*Registration, error) {
+	return c.ReplaceWithMeta(key, thread, Meta{}, tw...)
+}
+
+// Unregister removes the thread registered under key from the threads map, and
+// returns it and true, or nil and false when nothing was removed. When r is
+// not nil, the thread is only removed if it is still the one registered by r,
+// as reported by its Active method, so that holders of a stale Registration
+// don't remove a thread registered under the key since, such as by Replace.
+func (c *Chord) Unregister(key string, r *Registration) (Thread, bool) {
+	key = c.foldKey(key)
+	if r == nil {
+		v, ok := c.threads.Load(key)
+		if !ok {
+			return nil, false
+		}
+		return c.unregister(key, v.(*entry))
+	}
+	if r.c != c || r.key != key {
+		return nil, false
+	}
+	return c.unregister(key, r.e)
 }
 
-// Unregister removes a thread from the threads map using its key.
-// The provided thread parameter is not used for verification in this implementation.
-func (c *Chord) Unregister(key string, thread Thread) {
-	c.threads.Delete(key)
+// unregister removes the entry stored under key, if it is still stored, and
+// returns its thread.
+func (c *Chord) unregister(key string, e *entry) (Thread, bool) {
+	if !c.threads.CompareAndDelete(key, e) {
+		return nil, false
+	}
+	c.dropAliases(key)
+	c.notify(EventUnregister, key)
+	return e.thread, true
 }
 
 // Mount adds a composite chord (nested chord) to the chords map with the given key.
-func (c *Chord) Mount(key string, chord *Chord) {
-	c.chords.Store(key, chord)
+// Optionally, thread wrappers can be provided; they are applied in FIFO order to
+// every thread matched through the mounted chord, outside of its own middleware.
+// Nothing is mounted when the key validator of the chord rejects the key,
+// failing with a *KeyError, or when a chord is already mounted under the key,
+// failing with a *DuplicateError: ReplaceMount overrides it. Mounting a nil
+// chord fails with ErrNilChord, and mounting the chord on itself, or on one of
+// its nested chords, with ErrCycle.
+func (c *Chord) Mount(key string, chord *Chord, tw ...ThreadWrapper) error {
+	return c.MountWithMeta(key, chord, Meta{}, tw...)
 }
 
-// Unmount removes a composite chord from the chords map using its key.
-func (c *Chord) Unmount(key string) {
-	c.chords.Delete(key)
+// ReplaceMount mounts a chord under key, as Mount does, replacing the chord
+// already mounted under it, if any.
+func (c *Chord) ReplaceMount(key string, chord *Chord, tw ...ThreadWrapper) error {
+	return c.ReplaceMountWithMeta(key, chord, Meta{}, tw...)
+}
+
+// Unmount removes a composite chord from the chords map using its key, and
+// returns it and true, or nil and false when no chord was mounted under key.
+// The chord removed keeps its threads and nested chords, and can be mounted
+// elsewhere.
+func (c *Chord) Unmount(key string) (*Chord, bool) {
+	key = c.foldKey(key)
+	v, ok := c.chords.Load(key)
+	if !ok {
+		return nil, false
+	}
+	return c.unmount(key, v.(*mount))
+}
+
+// UnmountAll removes all the composite chords mounted on the chord, and returns
+// them by key.
+func (c *Chord) UnmountAll() map[string]*Chord {
+	removed := make(map[string]*Chord)
+	for _, key := range sortedKeys(&c.chords) {
+		if v, ok := c.chords.Load(key); ok {
+			if chord, ok := c.unmount(key, v.(*mount)); ok {
+				removed[key] = chord
+			}
+		}
+	}
+	return removed
+}
+
+// unmount removes the mount stored under key, if it is still stored, and
+// returns its chord.
+func (c *Chord) unmount(key string, m *mount) (*Chord, bool) {
+	if !c.chords.CompareAndDelete(key, m) {
+		return nil, false
+	}
+	c.dropAliases(key)
+	c.notify(EventUnmount, key)
+	return m.chord, true
 }
 
 // Use registers one or more thread wrappers (middleware) to the chord's middleware chain.
 // These wrappers will be applied to threads in the order they were added.
 func (c *Chord) Use(tw ...ThreadWrapper) {
-	c.middlewares = append(c.middlewares, tw...)
+	c.mu.Lock()
+	for _, w := range tw {
+		c.middlewares = append(c.middlewares, middleware{wrapper: w})
+	}
+	c.mu.Unlock()
+	c.notify(EventUse, "")
+}
+
+// UseChord registers one or more chord wrappers, which are applied whenever
+// traversal enters one of the chords mounted on this chord, such as to guard a
+// whole subtree. Unlike the wrappers of Use, they don't apply to the threads
+// registered directly on this chord.
+func (c *Chord) UseChord(cw ...ChordWrapper) {
+	c.mu.Lock()
+	c.chordWrappers = append(c.chordWrappers, cw...)
+	c.mu.Unlock()
+	c.notify(EventUse, "")
+}
+
+// NotFound sets the fallback thread invoked when Match fails at this chord.
+// The fallback receives the unmatched keys of the path in Input.Args, followed
+// by the original arguments. Passing nil removes the fallback.
+func (c *Chord) NotFound(thread Thread) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notFound = thread
+}
+
+// FetchNotFound returns the fallback thread of the chord and true if one is set,
+// or nil and false otherwise.
+func (c *Chord) FetchNotFound() (Thread, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.notFound, c.notFound != nil
+}
+
+// entry is a thread registered on a chord, along with its metadata.
+type entry struct {
+	thread   Thread
+	meta     Meta
+	disabled atomic.Bool // Whether the thread is taken out of rotation by Disable.
+
+	// factory, when not nil, builds the thread on first match.
+	factory *threadFactory
+
+	// warmup is the function set by SetWarmup, nil when unset.
+	warmup atomic.Pointer[func(context.Context) error]
+
+	// versions holds the versions registered by RegisterVersion, in order of
+	// registration, nil for the threads registered otherwise.
+	versions atomic.Pointer[[]threadVersion]
+}
+
+// middleware is a thread wrapper registered on a chord through Use or UseNamed.
+type middleware struct {
+	name    string // Name of the wrapper, empty when registered through Use.
+	wrapper ThreadWrapper
+}
+
+// mount is a composite chord mounted on a chord, along with the thread wrappers
+// supplied at mount time and its metadata.
+type mount struct {
+	chord    *Chord
+	wrappers []ThreadWrapper
+	meta     Meta
+}
+
+// sortedKeys returns the keys of a sync map in lexical order.
+func sortedKeys(m *sync.Map) []string {
+	var keys []string
+	m.Range(func(k, _ any) bool {
+		keys = append(keys, k.(string))
+		return true
+	})
+	sort.Strings(keys)
+	return keys
 }
 
 // ThreadWrapper is a function type that wraps a Thread.
 // It enables modifying or augmenting the behavior of a thread.
 type ThreadWrapper func(Thread) Thread
 
+// ChordWrapper is a function type that returns the ThreadWrapper to apply to
+// the threads matched through the chord mounted under key. It may return nil
+// to leave them unwrapped.
+type ChordWrapper func(key string, chord *Chord) ThreadWrapper
+
 // WrapThreads builds the fully wrapped thread as a pipeline in FIFO order.
 // The thread is wrapped by the provided wrappers, with the last wrapper in the slice
-// being applied first.
+// being applied first, so that the first wrapper is the outermost.
 func WrapThreads(thread Thread, tw ...ThreadWrapper) Thread {
-	for i := len(tw) - 1; i > 0; i-- {
+	for i := len(tw) - 1; i >= 0; i-- {
 		thread = tw[i](thread)
 	}
 	return thread
@@ -142,33 +467,78 @@ func WrapThreads(thread Thread, tw ...ThreadWrapper) Thread {
 
 // Match recursively traverses the chord structure to find and wrap the thread
 // corresponding to the given path. The path represents the keys to traverse.
-// If a valid thread is found, it is wrapped with its associated middleware.
+// Static keys are preferred over parameter and wildcard keys; the values they
+// capture are made available to the thread through Input.Params.
+// If a valid thread is found, it is wrapped with the middleware of every chord
+// traversed, from the chord it is registered on outwards, so that the
+// middleware of the outer chords runs before the middleware of the nested
+// ones.
 func Match(node *Chord, path []string) (Thread, bool) {
-	// Limit case: no keys in path.
-	if len(path) == 0 {
-		return nil, false
-	}
-	// Leaf case: single key in path implies direct thread lookup.
-	if len(path) == 1 {
-		thread, ok := node.FetchThread(path[0])
-		if !ok {
-			return nil, false
+	m := &matcher{params: make(map[string]string)}
+	return m.resolve(node, path)
+}
+
+// MatchPrefix matches the longest prefix of the path which resolves to a
+// thread, as CLI trees do, and returns the unconsumed keys, which are meant to
+// be passed to the thread as leading arguments, such as "deploy" and "prod"
+// when "app deploy prod" matches the thread registered under "app". The
+// NotFound fallbacks are ignored, unless no prefix resolves: then the whole
+// path is matched as by Match, so that the fallbacks still apply and receive
+// the unmatched keys, and no key is returned.
+func MatchPrefix(root *Chord, path []string) (Thread, []string, bool) {
+	for i := len(path); i > 0; i-- {
+		m := &matcher{params: make(map[string]string), strict: true}
+		if thread, ok := m.resolve(root, path[:i]); ok {
+			return thread, path[i:], true
 		}
-		thread = WrapThreads(thread, node.FetchMiddlewares()...)
-		return thread, true
 	}
+	thread, ok := Match(root, path)
+	return thread, nil, ok
+}
 
-	// Recursive case: traverse to the next chord in the path.
-	chord, ok := node.FetchChord(path[0])
+// Execute matches the thread for the given path, wrapped with the middleware of
+// every chord traversed, and invokes it with the input and output.
+// A *NotFoundError is returned when no thread matches the path; otherwise the
+// error returned by the thread is returned.
+//
+// The context of the input is propagated to the thread through every
+// middleware and nested chord traversed. The thread isn't invoked when the
+// context is already done, and its writes to the Output once the context is
+// done are discarded and fail with the error of the context.
+func (c *Chord) Execute(path []string, in *Input, out *Output) error {
+	thread, ok := Match(c, path)
 	if !ok {
-		return nil, false
+		return c.notFoundError(path)
 	}
-	// Recursively attempt to match the remaining path.
-	thread, ok := Match(chord, path[1:])
-	if !ok {
-		return nil, false
+	return execute(thread, in, out)
+}
+
+// Invoke invokes a thread, such as one returned by Match or MatchLine, with the
+// input and output as Execute does: the thread isn't invoked when the context
+// of the input is already done, and its writes are discarded once it is done.
+func Invoke(thread Thread, in *Input, out *Output) error {
+	return execute(thread, in, out)
+}
+
+// executed returns the thread invoking thread through execute, for the
+// executions capturing their output.
+func executed(thread Thread) Thread {
+	return func(in *Input, out *Output) error {
+		return execute(thread, in, out)
+	}
+}
+
+// execute invokes the thread matched by Execute with the input and output,
+// unless the context of the input is done.
+func execute(thread Thread, in *Input, out *Output) error {
+	ctx := in.Context()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	out, flush := cancelOutput(ctx, out)
+	err := thread(in, out)
+	if ferr := flush(); err == nil {
+		err = ferr
 	}
-	// Wrap the matched thread with the middleware from the nested chord.
-	thread = WrapThreads(thread, chord.FetchMiddlewares()...)
-	return thread, true
+	return err
 }
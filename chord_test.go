@@ -0,0 +1,140 @@
+package chord_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+// newOutput returns an Output writing to the returned buffer.
+func newOutput() (*chord.Output, *bytes.Buffer) {
+	var buf bytes.Buffer
+	rw := bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bufio.NewWriter(&buf))
+	return &chord.Output{ReadWriter: *rw}, &buf
+}
+
+// execute executes the thread of the path on c and returns its output.
+func execute(t *testing.T, c *chord.Chord, path []string, in *chord.Input) (string, error) {
+	t.Helper()
+	if in == nil {
+		in = &chord.Input{}
+	}
+	out, buf := newOutput()
+	err := c.Execute(path, in, out)
+	out.Flush()
+	return buf.String(), err
+}
+
+// echo returns a thread writing the text.
+func echo(text string) chord.Thread {
+	return func(in *chord.Input, out *chord.Output) error {
+		out.WriteString(text)
+		return out.Flush()
+	}
+}
+
+// nop is a thread doing nothing.
+func nop(*chord.Input, *chord.Output) error { return nil }
+
+func TestRegisterDuplicate(t *testing.T) {
+	c := chord.NewChord()
+	if _, err := c.Register("a", nop); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.Register("a", nop)
+	var de *chord.DuplicateError
+	if !errors.As(err, &de) || !errors.Is(err, chord.ErrDuplicate) {
+		t.Fatalf("Register of a duplicate key = %v, want a *DuplicateError", err)
+	}
+}
+
+func TestReplaceAndStaleUnregister(t *testing.T) {
+	c := chord.NewChord()
+	old, _ := c.Register("a", echo("old"))
+	cur, err := c.Replace("a", echo("new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.Active() || !cur.Active() {
+		t.Fatalf("Active() = %v, %v, want false, true", old.Active(), cur.Active())
+	}
+	if _, ok := c.Unregister("a", old); ok {
+		t.Fatal("Unregister with a stale registration removed the thread")
+	}
+	if got, _ := execute(t, c, []string{"a"}, nil); got != "new" {
+		t.Fatalf("output = %q, want %q", got, "new")
+	}
+	if _, ok := c.Unregister("a", cur); !ok {
+		t.Fatal("Unregister with the current registration removed nothing")
+	}
+}
+
+func TestRegisterOnce(t *testing.T) {
+	c := chord.NewChord()
+	r, err := c.RegisterOnce("setup", echo("done"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := execute(t, c, []string{"setup"}, nil); err != nil || got != "done" {
+		t.Fatalf("first execution = %q, %v", got, err)
+	}
+	if r.Active() {
+		t.Fatal("registration still active after the first success")
+	}
+	if _, err := execute(t, c, []string{"setup"}, nil); !errors.Is(err, chord.ErrNotFound) {
+		t.Fatalf("second execution = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRegisterFactory(t *testing.T) {
+	c := chord.NewChord()
+	calls := 0
+	_, err := c.RegisterFactory("db", func() (chord.Thread, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("unavailable")
+		}
+		return echo("ok"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := execute(t, c, []string{"db"}, nil); err == nil {
+		t.Fatal("execution succeeded although the factory failed")
+	}
+	for range 2 {
+		if got, err := execute(t, c, []string{"db"}, nil); err != nil || got != "ok" {
+			t.Fatalf("execution = %q, %v", got, err)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("factory called %d times, want 2", calls)
+	}
+}
+
+func TestMountNil(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		c := chord.NewChord()
+		c.SetStrict(strict)
+		if err := c.Mount("sub", nil); !errors.Is(err, chord.ErrNilChord) {
+			t.Fatalf("Mount of a nil chord (strict %v) = %v, want ErrNilChord", strict, err)
+		}
+		if _, ok := c.FetchChord("sub"); ok {
+			t.Fatal("nil chord mounted")
+		}
+	}
+}
+
+func TestMountCycle(t *testing.T) {
+	a, b := chord.NewChord(), chord.NewChord()
+	if err := a.Mount("b", b); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Mount("a", a); !errors.Is(err, chord.ErrCycle) {
+		t.Fatalf("Mount of a cycle = %v, want ErrCycle", err)
+	}
+}
@@ -0,0 +1,75 @@
+package chord
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a deep copy of the chord: an independent tree holding the
+// threads of the chord and copies of its nested chords, with their metadata,
+// middleware, chord wrappers, aliases, persistent flags and settings, so that
+// changing the copy, such as to customize a shared base tree per tenant,
+// leaves the original unchanged. The threads and wrappers themselves are
+// shared, as are the thread factories, and the threads registered by
+// RegisterOnce stay bound to the chord they were registered on. A chord
+// mounted several times in the tree is copied once. Lazy chords are loaded and
+// copied as regular chords. The observers, lifecycle hooks, plugins and
+// statistics are not copied, and the copy isn't mounted anywhere.
+func (c *Chord) Clone() *Chord {
+	return c.clone(make(map[*Chord]*Chord))
+}
+
+// clone implements Clone, recording the copies of the chords into copies.
+func (c *Chord) clone(copies map[*Chord]*Chord) *Chord {
+	if cc, ok := copies[c]; ok {
+		return cc
+	}
+	c.ensureLoaded()
+	cc := NewChord()
+	copies[c] = cc
+	cc.abbrev.Store(c.abbrev.Load())
+	cc.keyFold.Store(c.keyFold.Load())
+	cc.strict.Store(c.strict.Load())
+	cc.maxDepth.Store(c.maxDepth.Load())
+
+	c.mu.RLock()
+	cc.middlewares = slices.Clone(c.middlewares)
+	cc.chordWrappers = slices.Clone(c.chordWrappers)
+	cc.notFound = c.notFound
+	cc.onPanic = c.onPanic
+	if c.persistentFlags != nil {
+		cc.persistentFlags = &FlagSet{flags: slices.Clone(c.persistentFlags.flags)}
+	}
+	cc.aliases = maps.Clone(c.aliases)
+	cc.keyValidator = c.keyValidator
+	cc.defaultVersion = c.defaultVersion
+	cc.delimiter = c.delimiter
+	c.mu.RUnlock()
+
+	c.threads.Range(func(k, v any) bool {
+		key := k.(string)
+		cc.threads.Store(key, cloneEntry(cc, key, v.(*entry)))
+		return true
+	})
+	c.chords.Range(func(k, v any) bool {
+		key, m := k.(string), v.(*mount)
+		nm := &mount{chord: m.chord.clone(copies), wrappers: slices.Clone(m.wrappers), meta: m.meta.clone()}
+		nm.chord.attach(cc, key)
+		cc.chords.Store(key, nm)
+		return true
+	})
+	return cc
+}
+
+// cloneEntry returns a copy of the entry for registration under key on c.
+func cloneEntry(c *Chord, key string, e *entry) *entry {
+	ne := &entry{thread: e.thread, meta: e.meta.clone(), factory: e.factory}
+	ne.disabled.Store(e.disabled.Load())
+	ne.warmup.Store(e.warmup.Load())
+	if vs := e.versions.Load(); vs != nil {
+		versions := slices.Clone(*vs)
+		ne.versions.Store(&versions)
+		ne.thread = c.versionedThread(key, ne)
+	}
+	return ne
+}
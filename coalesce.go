@@ -0,0 +1,78 @@
+package chord
+
+import (
+	"errors"
+	"sync"
+)
+
+// Coalesce returns a ThreadWrapper sharing one execution among the concurrent
+// identical executions of the threads it wraps, to prevent thundering herds on
+// expensive threads. Executions are identified as by Cache, by the route
+// matched and their input, so that the threads sharing the wrapper never
+// receive the outputs of one another. The first one invokes the thread, with
+// an Output writing to a buffer, and the executions started before it returns
+// wait for it; all of them then receive a copy of the output and the error of
+// the thread. The shared execution reads from the Output and observes the
+// context of the first one only.
+//
+// The executions are tracked by all the threads wrapped by the returned
+// wrapper, such as when passed to Use.
+func Coalesce() ThreadWrapper {
+	g := &flightGroup{calls: make(map[string]*flight)}
+	return func(next Thread) Thread {
+		return func(in *Input, out *Output) error {
+			data, err := g.do(inputKey(in), func() ([]byte, error) {
+				return captureOutput(next, in, out)
+			})
+			if werr := writeOutput(out, data); err == nil {
+				err = werr
+			}
+			return err
+		}
+	}
+}
+
+// errFlightPanicked is the error received by the executions waiting for a
+// shared execution which panicked.
+var errFlightPanicked = errors.New("chord: coalesced execution panicked")
+
+// flightGroup tracks the executions in flight by key.
+type flightGroup struct {
+	calls map[string]*flight
+	mu    sync.Mutex
+}
+
+// flight is an execution in flight, whose result is set before done is closed.
+type flight struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// do calls fn unless an execution of the key is in flight, in which case it
+// waits for its result instead.
+func (g *flightGroup) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if f, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-f.done
+		return f.data, f.err
+	}
+	f := &flight{done: make(chan struct{})}
+	g.calls[key] = f
+	g.mu.Unlock()
+
+	returned := false
+	defer func() {
+		if !returned {
+			f.err = errFlightPanicked
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.data, f.err = fn()
+	returned = true
+	return f.data, f.err
+}
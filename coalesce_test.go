@@ -0,0 +1,48 @@
+package chord_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/graphitects/chord"
+)
+
+func TestCoalesce(t *testing.T) {
+	c := chord.NewChord()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	c.Use(chord.Coalesce())
+	c.Register("slow", func(in *chord.Input, out *chord.Output) error {
+		calls.Add(1)
+		<-release
+		out.WriteString("slow")
+		return out.Flush()
+	})
+	c.Register("other", echo("other"))
+
+	var wg sync.WaitGroup
+	outputs := make([]string, 3)
+	for i := range outputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outputs[i], _ = execute(t, c, []string{"slow"}, &chord.Input{})
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got, _ := execute(t, c, []string{"other"}, &chord.Input{}); got != "other" {
+		t.Fatalf("other route = %q, want %q", got, "other")
+	}
+	close(release)
+	wg.Wait()
+	for _, got := range outputs {
+		if got != "slow" {
+			t.Fatalf("outputs = %q, want all %q", outputs, "slow")
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("thread called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,172 @@
+package chord
+
+import (
+	"sort"
+	"strings"
+)
+
+// CandidateKind is the kind of a completion Candidate.
+type CandidateKind int
+
+const (
+	ThreadCandidate CandidateKind = iota // Key of a thread.
+	ChordCandidate                       // Key of a chord.
+	FlagCandidate                        // Flag, such as "--verbose".
+)
+
+// String returns the name of the candidate kind.
+func (k CandidateKind) String() string {
+	switch k {
+	case ThreadCandidate:
+		return "thread"
+	case ChordCandidate:
+		return "chord"
+	case FlagCandidate:
+		return "flag"
+	}
+	return "unknown"
+}
+
+// Candidate is a completion of a partial command line, as returned by Complete.
+type Candidate struct {
+	Value       string        `json:"value"` // Completed token.
+	Kind        CandidateKind `json:"kind"`
+	Description string        `json:"description,omitempty"`
+}
+
+// Complete returns the completions of the token being typed after the partial
+// path, such as for the tab completion of REPLs, SSH sessions or editors. The
+// tokens of the partial path are matched from the chord as by MatchPrefix,
+// skipping the flags; the keys of the threads and chords of the chord reached
+// starting with prefix are returned, in lexical order. When prefix starts with
+// "-", the flags declared by the metadata of the thread reached, then the
+// persistent flags of the chords traversed, are returned instead. Hidden and
+// internal threads and chords aren't completed.
+func (c *Chord) Complete(partial []string, prefix string) []Candidate {
+	node, thread, flags, ok := c.completePath(partial)
+	if !ok {
+		return nil
+	}
+
+	var candidates []Candidate
+	if strings.HasPrefix(prefix, "-") {
+		name := strings.TrimLeft(prefix, "-")
+		seen := make(map[string]bool)
+		add := func(f Flag) {
+			if !seen[f.Name] && strings.HasPrefix(f.Name, name) {
+				seen[f.Name] = true
+				candidates = append(candidates, Candidate{Value: "--" + f.Name, Kind: FlagCandidate, Description: f.Usage})
+			}
+		}
+		if thread != nil {
+			for _, f := range thread.meta.Flags {
+				add(f)
+			}
+		}
+		for i := len(flags) - 1; i >= 0; i-- {
+			if flags[i] != nil {
+				for _, f := range flags[i].Flags() {
+					add(f)
+				}
+			}
+		}
+		return candidates
+	}
+	if thread != nil {
+		return nil
+	}
+
+	node.ensureLoaded()
+	prefix = node.foldKey(prefix)
+	for _, key := range sortedKeys(&node.threads) {
+		if e, ok := node.fetchEntry(key); ok && completable(key, prefix, e.meta) {
+			candidates = append(candidates, Candidate{Value: key, Kind: ThreadCandidate, Description: e.meta.Description})
+		}
+	}
+	for _, key := range sortedKeys(&node.chords) {
+		if m, ok := node.fetchMount(key); ok && completable(key, prefix, m.meta) {
+			candidates = append(candidates, Candidate{Value: key, Kind: ChordCandidate, Description: m.meta.Description})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Value < candidates[j].Value })
+	return candidates
+}
+
+// completePath matches the partial path of Complete, and returns the chord
+// reached, the entry of the thread reached if any, and the persistent flags of
+// the chords traversed. It reports false when the path can't be matched.
+func (c *Chord) completePath(partial []string) (*Chord, *entry, []*FlagSet, bool) {
+	node := c
+	var thread *entry
+	flags := []*FlagSet{c.fetchPersistentFlags()}
+	for _, tok := range partial {
+		if strings.HasPrefix(tok, "-") || thread != nil {
+			continue
+		}
+		key := node.unalias(tok)
+		if m, ok := node.completeMount(key); ok {
+			node = m.chord
+			flags = append(flags, node.fetchPersistentFlags())
+		} else if e, ok := node.completeEntry(key); ok {
+			thread = e
+		} else {
+			return nil, nil, nil, false
+		}
+	}
+	return node, thread, flags, true
+}
+
+// completeMount returns the visible mount matching the key of a partial path,
+// statically or through a parameter key.
+func (c *Chord) completeMount(key string) (*mount, bool) {
+	m, ok := c.fetchMount(key)
+	if !ok {
+		var param string
+		if param, ok = dynamicKey(&c.chords, isParam); ok {
+			m, ok = c.fetchMount(param)
+		}
+	}
+	if !ok || m.meta.Hidden || m.meta.Internal {
+		return nil, false
+	}
+	return m, true
+}
+
+// completeEntry returns the visible entry of the thread matching the key of a
+// partial path, statically or through a parameter or wildcard key.
+func (c *Chord) completeEntry(key string) (*entry, bool) {
+	e, ok := c.fetchEntry(key)
+	if !ok {
+		var dynamic string
+		if dynamic, ok = dynamicKey(&c.threads, func(k string) bool { return isParam(k) || isWildcard(k) }); ok {
+			e, ok = c.fetchEntry(dynamic)
+		}
+	}
+	if !ok || e.meta.Hidden || e.meta.Internal {
+		return nil, false
+	}
+	return e, true
+}
+
+// completeParam returns the visible parameter key of a chord of the chord,
+// or else the visible parameter or wildcard key of a thread, reporting
+// whether it is the key of a thread.
+func (c *Chord) completeParam() (string, bool) {
+	if key, ok := dynamicKey(&c.chords, isParam); ok {
+		if m, ok := c.fetchMount(key); ok && !m.meta.Hidden && !m.meta.Internal {
+			return key, false
+		}
+	}
+	if key, ok := dynamicKey(&c.threads, func(k string) bool { return isParam(k) || isWildcard(k) }); ok {
+		if e, ok := c.fetchEntry(key); ok && !e.meta.Hidden && !e.meta.Internal {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// completable reports whether the key of a visible thread or chord completes
+// the prefix. Parameter and wildcard keys are never completed.
+func completable(key, prefix string, meta Meta) bool {
+	return !meta.Hidden && !meta.Internal && !isParam(key) && !isWildcard(key) && strings.HasPrefix(key, prefix)
+}
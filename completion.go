@@ -0,0 +1,272 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrUnsupportedShell is the error matched by errors.Is when completions are
+// requested for a shell other than bash, zsh and fish.
+var ErrUnsupportedShell = errors.New("unsupported shell")
+
+// CompletionThread returns a thread writing the completion script of the root
+// chord for the shell named by its first argument, "bash", "zsh" or "fish", to
+// the Output, as WriteCompletion does. It is meant to be registered on the root
+// itself:
+//
+//	root.Register("completion", chord.CompletionThread(root, "mycli"))
+//
+// so that users enable the completions with, for instance:
+//
+//	source <(mycli completion bash)
+func CompletionThread(root *Chord, program string) Thread {
+	return func(in *Input, out *Output) error {
+		if len(in.Args) == 0 {
+			return &ArgError{Index: 0, Name: "shell", Err: ErrMissingArg}
+		}
+		if err := WriteCompletion(out, root, in.Args[0], program); err != nil {
+			if errors.Is(err, ErrUnsupportedShell) {
+				return &ArgError{Index: 0, Name: "shell", Value: in.Args[0], Err: ErrUnsupportedShell}
+			}
+			return err
+		}
+		return out.Flush()
+	}
+}
+
+// WriteCompletion writes the completion script of the program, whose threads
+// are those of the root chord, for the shell, "bash", "zsh" or "fish". The
+// script holds the tree as completed by Complete, with the keys of the threads
+// and chords, and the flags declared by their metadata and the persistent
+// flags: it is generated again when the tree changes. Parameter keys complete
+// nothing but match any word.
+func WriteCompletion(w io.Writer, root *Chord, shell, program string) error {
+	nodes := completionNodes(root)
+	fn := "_" + nonIdent.ReplaceAllString(program, "_") + "_complete"
+	switch shell {
+	case "bash":
+		return writeBashCompletion(w, nodes, fn, program)
+	case "zsh":
+		return writeZshCompletion(w, nodes, fn, program)
+	case "fish":
+		return writeFishCompletion(w, nodes, fn, program)
+	}
+	return fmt.Errorf("chord: %w %q", ErrUnsupportedShell, shell)
+}
+
+// nonIdent matches the characters of a program name which can't appear in the
+// names of shell functions.
+var nonIdent = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// completionNode is a thread or a chord of the tree, as described by a
+// completion script. Nodes are identified by the keys of their path, each
+// followed by a slash, such as "/net/dns/" and "/" for the root.
+type completionNode struct {
+	id     string
+	thread bool
+	keys   []Candidate // Keys of the threads and chords of a chord.
+	flags  []Candidate
+	param  string // Parameter or wildcard key of a chord, matching any word.
+	// paramThread is set when the param key is the key of a thread.
+	paramThread bool
+}
+
+// completionNodes returns the nodes of the tree of root, the root first.
+func completionNodes(root *Chord) []completionNode {
+	var nodes []completionNode
+	var walk func(path []string, thread bool)
+	walk = func(path []string, thread bool) {
+		n := completionNode{id: "/", thread: thread, flags: root.Complete(path, "-")}
+		for _, key := range path {
+			n.id += key + "/"
+		}
+		if !thread {
+			n.keys = root.Complete(path, "")
+			if node, _, _, ok := root.completePath(path); ok {
+				n.param, n.paramThread = node.completeParam()
+			}
+		}
+		nodes = append(nodes, n)
+		for _, c := range n.keys {
+			walk(append(slices.Clip(path), c.Value), c.Kind == ThreadCandidate)
+		}
+		if n.param != "" {
+			walk(append(slices.Clip(path), n.param), n.paramThread)
+		}
+	}
+	walk(nil, false)
+	return nodes
+}
+
+// writeBashCompletion writes the bash completion script of the nodes.
+func writeBashCompletion(w io.Writer, nodes []completionNode, fn, program string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s, generated by chord.\n", program)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	writeShellTables(&b, nodes, "    local -A %s=(\n", "        [%s]=%s\n", "    )\n", shQuote)
+	b.WriteString(`    local node=/ word i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        word=${COMP_WORDS[i]}
+        [[ $word == -* || ${kinds[$node]} == t ]] && continue
+        if [[ -n ${kinds[$node$word/]} ]]; then
+            node=$node$word/
+        elif [[ -n ${params[$node]} ]]; then
+            node=$node${params[$node]}/
+        else
+            return
+        fi
+    done
+    local cur=${COMP_WORDS[COMP_CWORD]}
+    if [[ $cur == -* ]]; then
+        COMPREPLY=($(compgen -W "${flags[$node]}" -- "$cur"))
+    else
+        COMPREPLY=($(compgen -W "${keys[$node]}" -- "$cur"))
+    fi
+}
+`)
+	fmt.Fprintf(&b, "complete -F %s %s\n", fn, shQuote(program))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeZshCompletion writes the zsh completion script of the nodes.
+func writeZshCompletion(w io.Writer, nodes []completionNode, fn, program string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n# zsh completion for %s, generated by chord.\n", program, program)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	writeShellTables(&b, nodes, "    local -A %s\n    %[1]s=(\n", "        %s %s\n", "    )\n", shQuote)
+	b.WriteString(`    local node=/ word
+    for word in "${(@)words[2,CURRENT-1]}"; do
+        [[ $word == -* || ${kinds[$node]} == t ]] && continue
+        if [[ -n ${kinds[$node$word/]} ]]; then
+            node=$node$word/
+        elif [[ -n ${params[$node]} ]]; then
+            node=$node${params[$node]}/
+        else
+            return 1
+        fi
+    done
+    if [[ $PREFIX == -* ]]; then
+        compadd -- ${=flags[$node]}
+    else
+        compadd -- ${=keys[$node]}
+    fi
+}
+`)
+	fmt.Fprintf(&b, "compdef %s %s\n", fn, shQuote(program))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeFishCompletion writes the fish completion script of the nodes. The
+// candidates are declared with their descriptions, conditioned on the node
+// reached by the command line.
+func writeFishCompletion(w io.Writer, nodes []completionNode, fn, program string) error {
+	var b strings.Builder
+	prog := fishQuote(program)
+	fmt.Fprintf(&b, "# fish completion for %s, generated by chord.\n", program)
+	fmt.Fprintf(&b, "function %s_node\n", fn)
+	var ids, kinds, paramIDs, params []string
+	for _, n := range nodes {
+		ids = append(ids, fishQuote(n.id))
+		kinds = append(kinds, map[bool]string{false: "c", true: "t"}[n.thread])
+		if n.param != "" {
+			paramIDs = append(paramIDs, fishQuote(n.id))
+			params = append(params, fishQuote(n.param))
+		}
+	}
+	fmt.Fprintf(&b, "    set -l ids %s\n    set -l kinds %s\n", strings.Join(ids, " "), strings.Join(kinds, " "))
+	fmt.Fprintf(&b, "    set -l param_ids %s\n    set -l params %s\n", strings.Join(paramIDs, " "), strings.Join(params, " "))
+	b.WriteString(`    set -l tokens (commandline -opc)
+    set -l node /
+    for word in $tokens[2..-1]
+        string match -q -- '-*' $word; and continue
+        set -l i (contains -i -- $node $ids)
+        test "$kinds[$i]" = t; and continue
+        if contains -- "$node$word/" $ids
+            set node "$node$word/"
+        else if set -l j (contains -i -- $node $param_ids)
+            set node "$node$params[$j]/"
+        else
+            return 1
+        end
+    end
+    echo $node
+end
+`)
+	fmt.Fprintf(&b, "function %s_at\n    set -l node (%[1]s_node); and test \"$node\" = $argv[1]\nend\n", fn)
+	fmt.Fprintf(&b, "complete -c %s -f\n", prog)
+	for _, n := range nodes {
+		cond := fishQuote(fn + "_at " + fishQuote(n.id))
+		for _, c := range n.keys {
+			fmt.Fprintf(&b, "complete -c %s -n %s -a %s%s\n", prog, cond, fishQuote(c.Value), fishDescription(c))
+		}
+		for _, f := range n.flags {
+			fmt.Fprintf(&b, "complete -c %s -n %s -l %s%s\n", prog, cond, fishQuote(strings.TrimPrefix(f.Value, "--")), fishDescription(f))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeShellTables writes the associative arrays of the bash and zsh scripts:
+// the kinds of the nodes, "c" or "t", their keys and flags as words separated
+// by spaces, and their parameter keys.
+func writeShellTables(b *strings.Builder, nodes []completionNode, open, item, end string, quote func(string) string) {
+	table := func(name string, value func(completionNode) (string, bool)) {
+		fmt.Fprintf(b, open, name)
+		for _, n := range nodes {
+			if v, ok := value(n); ok {
+				fmt.Fprintf(b, item, quote(n.id), quote(v))
+			}
+		}
+		b.WriteString(end)
+	}
+	table("kinds", func(n completionNode) (string, bool) {
+		if n.thread {
+			return "t", true
+		}
+		return "c", true
+	})
+	table("keys", func(n completionNode) (string, bool) {
+		return candidateWords(n.keys), len(n.keys) > 0
+	})
+	table("flags", func(n completionNode) (string, bool) {
+		return candidateWords(n.flags), len(n.flags) > 0
+	})
+	table("params", func(n completionNode) (string, bool) {
+		return n.param, n.param != ""
+	})
+}
+
+// candidateWords returns the values of the candidates separated by spaces.
+func candidateWords(candidates []Candidate) string {
+	words := make([]string, len(candidates))
+	for i, c := range candidates {
+		words[i] = c.Value
+	}
+	return strings.Join(words, " ")
+}
+
+// shQuote quotes the string for bash and zsh.
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishDescription returns the description option of the candidate in a fish
+// script, if it has a description.
+func fishDescription(c Candidate) string {
+	if c.Description == "" {
+		return ""
+	}
+	return " -d " + fishQuote(c.Description)
+}
+
+// fishQuote quotes the string for fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,56 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTooManyExecutions is the error returned by the threads wrapped by
+// MaxConcurrent when their concurrent executions exceed the limit.
+var ErrTooManyExecutions = errors.New("chord: too many concurrent executions")
+
+// MaxConcurrent returns a ThreadWrapper bounding to n the executions of the
+// threads it wraps running simultaneously. Passed to Register, it bounds the
+// executions of the registered thread:
+//
+//	root.Register("export", export, chord.MaxConcurrent(2, false))
+//
+// The executions beyond the limit wait for a running one to return when wait
+// is true, until the context of their input is done. Otherwise, or when the
+// context is done, the thread isn't invoked: ErrTooManyExecutions, or the
+// error of the context, is written to the Output and returned. The limit is
+// shared by all the threads wrapped by the returned wrapper, such as when
+// passed to Use. A limit of zero or less doesn't bound the executions.
+func MaxConcurrent(n int, wait bool) ThreadWrapper {
+	if n <= 0 {
+		return func(next Thread) Thread { return next }
+	}
+	sem := make(chan struct{}, n)
+	return func(next Thread) Thread {
+		return func(in *Input, out *Output) error {
+			var err error
+			select {
+			case sem <- struct{}{}:
+			default:
+				err = ErrTooManyExecutions
+				if wait {
+					select {
+					case sem <- struct{}{}:
+						err = nil
+					case <-in.Context().Done():
+						err = in.Context().Err()
+					}
+				}
+			}
+			if err != nil {
+				if out != nil && out.Writer != nil {
+					fmt.Fprintln(out, err)
+					out.Flush()
+				}
+				return err
+			}
+			defer func() { <-sem }()
+			return next(in, out)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package chord
+
+import "errors"
+
+// ErrCycle is the error matched by errors.Is when mounting a chord would make
+// it a descendant of itself.
+var ErrCycle = errors.New("chord: mount cycle")
+
+// reaches reports whether target is the chord or one of the chords nested in
+// it, directly or transitively. The chords not loaded yet by NewLazyChord are
+// not loaded.
+func (c *Chord) reaches(target *Chord) bool {
+	seen := map[*Chord]bool{}
+	var walk func(node *Chord) bool
+	walk = func(node *Chord) bool {
+		if node == target {
+			return true
+		}
+		if seen[node] {
+			return false
+		}
+		seen[node] = true
+		found := false
+		node.chords.Range(func(_, v any) bool {
+			found = walk(v.(*mount).chord)
+			return !found
+		})
+		return found
+	}
+	return walk(c)
+}
@@ -0,0 +1,184 @@
+/*
+Package dag executes the threads of a chord as a graph of dependencies, such as
+the build and deploy steps of a release, with as many threads running in
+parallel as the dependencies allow.
+
+Every node of a Graph names the path of a thread and the nodes it depends on:
+
+	g := dag.New(root)
+	g.Add("build", []string{"build"})
+	g.Add("test", []string{"test"}, "build")
+	g.Add("lint", []string{"lint"})
+	g.Add("deploy", []string{"deploy", "prod"}, "test", "lint")
+	results, err := g.Run(ctx, &chord.Input{})
+
+A node runs once all its dependencies succeeded. The nodes depending on a
+failed node, directly or not, are skipped.
+*/
+package dag
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphitects/chord"
+)
+
+// ErrSkipped is the error of the nodes skipped because a dependency failed.
+var ErrSkipped = errors.New("dag: dependency failed")
+
+// Graph is a graph of threads of a chord ordered by their dependencies.
+type Graph struct {
+	root  *chord.Chord
+	nodes map[string]*node
+	names []string // Names of the nodes, in the order they were added.
+}
+
+// node is a node of a Graph.
+type node struct {
+	path []string
+	deps []string
+}
+
+// Result is the result of a node of a Graph.
+type Result struct {
+	Name     string        // Name of the node.
+	Output   []byte        // Output written by the thread.
+	Err      error         // Error of the thread, or ErrSkipped.
+	Duration time.Duration // Duration of the execution of the thread.
+}
+
+// New returns an empty graph of threads matched through root.
+func New(root *chord.Chord) *Graph {
+	return &Graph{root: root, nodes: make(map[string]*node)}
+}
+
+// Add adds a node executing the thread of the path once the nodes it depends
+// on succeeded. Dependencies may be added after the nodes depending on them.
+// It fails if a node of the same name was already added.
+func (g *Graph) Add(name string, path []string, deps ...string) error {
+	if _, ok := g.nodes[name]; ok {
+		return fmt.Errorf("dag: duplicate node %q", name)
+	}
+	g.nodes[name] = &node{path: path, deps: deps}
+	g.names = append(g.names, name)
+	return nil
+}
+
+// Validate reports the dependencies on nodes which weren't added and the
+// dependency cycles of the graph.
+func (g *Graph) Validate() error {
+	for _, name := range g.names {
+		for _, dep := range g.nodes[name].deps {
+			if _, ok := g.nodes[dep]; !ok {
+				return fmt.Errorf("dag: node %q depends on unknown node %q", name, dep)
+			}
+		}
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var stack []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("dag: dependency cycle %s -> %s", strings.Join(stack, " -> "), name)
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range g.nodes[name].deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, name := range g.names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run validates the graph and executes its nodes, each one as soon as its
+// dependencies succeeded, and returns their results in the order the nodes
+// were added. Every thread receives a copy of the input whose context is ctx,
+// and writes to a buffer returned in its result. The returned error is the
+// error of Validate, or the errors of the failed nodes joined. Nodes which
+// didn't start when ctx is done fail with the error of the context.
+func (g *Graph) Run(ctx context.Context, in *chord.Input) ([]Result, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	results := make(map[string]*Result, len(g.names))
+	done := make(map[string]chan struct{}, len(g.names))
+	for _, name := range g.names {
+		results[name] = &Result{Name: name}
+		done[name] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range g.names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[name])
+			res := results[name]
+			for _, dep := range g.nodes[name].deps {
+				<-done[dep]
+				if results[dep].Err != nil {
+					res.Err = ErrSkipped
+					return
+				}
+			}
+			if err := ctx.Err(); err != nil {
+				res.Err = err
+				return
+			}
+			start := time.Now()
+			res.Output, res.Err = g.execute(ctx, g.nodes[name].path, in)
+			res.Duration = time.Since(start)
+		}()
+	}
+	wg.Wait()
+
+	ordered := make([]Result, len(g.names))
+	var errs []error
+	for i, name := range g.names {
+		ordered[i] = *results[name]
+		if err := ordered[i].Err; err != nil && err != ErrSkipped {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return ordered, errors.Join(errs...)
+}
+
+// execute executes the thread of the path with a copy of the input, and
+// returns the output it wrote.
+func (g *Graph) execute(ctx context.Context, path []string, in *chord.Input) ([]byte, error) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bw)}
+	in = in.WithContext(ctx)
+	in.Args = slices.Clone(in.Args)
+	err := g.root.Execute(path, in, out)
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return buf.Bytes(), err
+}
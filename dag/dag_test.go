@@ -0,0 +1,77 @@
+package dag
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+func TestRun(t *testing.T) {
+	root := chord.NewChord()
+	var mu sync.Mutex
+	var order []string
+	step := func(name string, err error) chord.Thread {
+		return func(in *chord.Input, out *chord.Output) error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			out.WriteString(name)
+			out.Flush()
+			return err
+		}
+	}
+	root.Register("build", step("build", nil))
+	root.Register("test", step("test", nil))
+	root.Register("lint", step("lint", errors.New("lint failed")))
+	root.Register("deploy", step("deploy", nil))
+
+	g := New(root)
+	g.Add("deploy", []string{"deploy"}, "test", "lint")
+	g.Add("test", []string{"test"}, "build")
+	g.Add("build", []string{"build"})
+	g.Add("lint", []string{"lint"})
+	results, err := g.Run(context.Background(), &chord.Input{})
+	if err == nil || !strings.Contains(err.Error(), "lint: lint failed") {
+		t.Fatalf("error = %v, want the error of lint", err)
+	}
+
+	var names []string
+	for _, res := range results {
+		names = append(names, res.Name)
+	}
+	if got := strings.Join(names, " "); got != "deploy test build lint" {
+		t.Fatalf("results = %s, want them in the order the nodes were added", got)
+	}
+	if !errors.Is(results[0].Err, ErrSkipped) {
+		t.Errorf("error of deploy = %v, want ErrSkipped", results[0].Err)
+	}
+	if results[1].Err != nil || string(results[1].Output) != "test" {
+		t.Errorf("result of test = %q, %v, want %q", results[1].Output, results[1].Err, "test")
+	}
+	if slices.Index(order, "build") > slices.Index(order, "test") {
+		t.Errorf("execution order = %q, want build before test", order)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	g := New(chord.NewChord())
+	g.Add("a", []string{"a"}, "b")
+	g.Add("b", []string{"b"}, "a")
+	if err := g.Validate(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("Validate of a cycle = %v, want a cycle error", err)
+	}
+	if err := g.Add("a", []string{"a"}); err == nil {
+		t.Fatal("Add of a duplicate node succeeded")
+	}
+
+	g = New(chord.NewChord())
+	g.Add("a", []string{"a"}, "missing")
+	if err := g.Validate(); err == nil || !strings.Contains(err.Error(), "unknown node") {
+		t.Fatalf("Validate of an unknown dependency = %v, want an unknown node error", err)
+	}
+}
@@ -0,0 +1,32 @@
+package chord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// deprecationKey is the context key of the deprecation notice of the thread
+// executed.
+type deprecationKey struct{}
+
+// DeprecationOf returns the deprecation notice of the thread executed with the
+// input, as set by Meta.Deprecated on the thread or on a chord traversed, and
+// whether it is deprecated, such as for middleware counting the executions of
+// deprecated threads.
+func DeprecationOf(in *Input) (string, bool) {
+	notice, ok := in.Context().Value(deprecationKey{}).(string)
+	return notice, ok
+}
+
+// withDeprecation wraps the deprecated thread matched by the path so that a
+// warning holding the notice is written to the Output before it runs, and the
+// notice is reported by DeprecationOf.
+func withDeprecation(thread Thread, path []string, notice string) Thread {
+	return func(in *Input, out *Output) error {
+		if out != nil && out.Writer != nil {
+			fmt.Fprintf(out, "warning: %q is deprecated: %s\n", strings.Join(path, " "), notice)
+		}
+		return thread(in.WithContext(context.WithValue(in.Context(), deprecationKey{}, notice)), out)
+	}
+}
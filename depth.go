@@ -0,0 +1,53 @@
+package chord
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrTooDeep is the error matched by errors.Is when a path is longer than the
+// maximum depth of the chord it is matched from.
+var ErrTooDeep = errors.New("chord: path too deep")
+
+// TooDeepError is returned in place of the execution of a path longer than the
+// maximum depth of the chord it is matched from.
+type TooDeepError struct {
+	Depth int // Number of keys of the path.
+	Max   int // Maximum depth of the chord.
+}
+
+// Error implements the error interface.
+func (e *TooDeepError) Error() string {
+	return "chord: path of " + strconv.Itoa(e.Depth) + " keys exceeds the maximum depth of " + strconv.Itoa(e.Max)
+}
+
+// Unwrap returns ErrTooDeep, so that errors.Is(err, ErrTooDeep) reports true.
+func (e *TooDeepError) Unwrap() error {
+	return ErrTooDeep
+}
+
+// ExitCode returns ExitUsage.
+func (e *TooDeepError) ExitCode() int {
+	return ExitUsage
+}
+
+// SetMaxDepth limits the number of keys of the paths matched from the chord,
+// and so the depth of the traversal of its nested chords, such as for the root
+// of a tree exposed by a network adapter to untrusted input. Longer paths are
+// rejected before any traversal, matched as a thread failing with a
+// *TooDeepError. The limit applies to the paths matched from the chord only,
+// not from its nested chords. A depth of zero, the default, sets no limit.
+func (c *Chord) SetMaxDepth(depth int) {
+	c.maxDepth.Store(int64(max(depth, 0)))
+}
+
+// checkDepth returns a thread failing with a *TooDeepError when the path is
+// longer than the maximum depth of the chord.
+func (c *Chord) checkDepth(path []string) (Thread, bool) {
+	limit := int(c.maxDepth.Load())
+	if limit == 0 || len(path) <= limit {
+		return nil, false
+	}
+	err := &TooDeepError{Depth: len(path), Max: limit}
+	return func(*Input, *Output) error { return err }, true
+}
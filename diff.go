@@ -0,0 +1,136 @@
+package chord
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// TreeDiff describes the differences between two trees of chords, as returned
+// by Diff, such as for deployment tooling to verify what a configuration
+// reload or a plugin load changed. The paths are listed in the order Walk
+// visits them.
+type TreeDiff struct {
+	Added   [][]string // Paths of the threads of the new tree only.
+	Removed [][]string // Paths of the threads of the old tree only.
+	// Changed are the paths of the threads of both trees which differ.
+	Changed [][]string
+	// Middleware lists the chords of both trees whose numbers of middleware
+	// differ.
+	Middleware []MiddlewareChange
+}
+
+// MiddlewareChange reports the numbers of middleware of a chord in the old and
+// the new tree, counting the middleware registered on the chord, such as by
+// Use, and the wrappers supplied when mounting it.
+type MiddlewareChange struct {
+	Path   []string // Path of the chord, empty for the roots.
+	Before int
+	After  int
+}
+
+// Empty reports whether the trees don't differ.
+func (d *TreeDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && len(d.Middleware) == 0
+}
+
+// Diff compares the tree of the chord a, the old tree, with the tree of the
+// chord b, the new one. Threads are matched by path, with parameter and
+// wildcard keys as registered, and differ when their metadata, versions or
+// state, such as disabled, differ, or when their functions differ. Functions
+// are compared by their code, so that the threads built by the same function
+// as closures, and the threads wrapped by the same wrappers, compare equal.
+func Diff(a, b *Chord) *TreeDiff {
+	before, after := diffEntries(a), diffEntries(b)
+	d := &TreeDiff{}
+	for _, p := range after.paths {
+		old, ok := before.entries[pathID(p)]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, p)
+		case !sameEntry(old, after.entries[pathID(p)]):
+			d.Changed = append(d.Changed, p)
+		}
+	}
+	for _, p := range before.paths {
+		if _, ok := after.entries[pathID(p)]; !ok {
+			d.Removed = append(d.Removed, p)
+		}
+	}
+	for _, p := range after.chords {
+		n, ok := before.middleware[pathID(p)]
+		if m := after.middleware[pathID(p)]; ok && n != m {
+			d.Middleware = append(d.Middleware, MiddlewareChange{Path: p, Before: n, After: m})
+		}
+	}
+	return d
+}
+
+// diffTree indexes the threads and chords of a tree by path.
+type diffTree struct {
+	paths      [][]string        // Paths of the threads, in the order of Walk.
+	entries    map[string]*entry // Entries of the threads, by path ID.
+	chords     [][]string        // Paths of the chords, the root first.
+	middleware map[string]int    // Numbers of middleware of the chords, by path ID.
+}
+
+// diffEntries indexes the tree of the chord.
+func diffEntries(c *Chord) *diffTree {
+	t := &diffTree{entries: make(map[string]*entry), middleware: make(map[string]int)}
+	c.walkEntries(nil, func(path []string, e *entry) bool {
+		t.paths = append(t.paths, path)
+		t.entries[pathID(path)] = e
+		return true
+	})
+	var walk func(c *Chord, path []string, wrappers int)
+	walk = func(c *Chord, path []string, wrappers int) {
+		c.mu.RLock()
+		n := len(c.middlewares)
+		c.mu.RUnlock()
+		t.chords = append(t.chords, path)
+		t.middleware[pathID(path)] = n + wrappers
+		for _, key := range sortedKeys(&c.chords) {
+			if m, ok := c.fetchMount(key); ok {
+				walk(m.chord, append(slices.Clip(path), key), len(m.wrappers))
+			}
+		}
+	}
+	walk(c, []string{}, 0)
+	return t
+}
+
+// pathID identifies a path, joining its keys with NUL characters.
+func pathID(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// sameEntry reports whether the entries of two threads compare equal.
+func sameEntry(a, b *entry) bool {
+	if a == b {
+		return true
+	}
+	if !sameFunc(a.thread, b.thread) || a.disabled.Load() != b.disabled.Load() || !reflect.DeepEqual(a.meta, b.meta) {
+		return false
+	}
+	if a.factory != nil || b.factory != nil {
+		if a.factory == nil || b.factory == nil ||
+			reflect.ValueOf(a.factory.build).Pointer() != reflect.ValueOf(b.factory.build).Pointer() {
+			return false
+		}
+	}
+	var av, bv []threadVersion
+	if vs := a.versions.Load(); vs != nil {
+		av = *vs
+	}
+	if vs := b.versions.Load(); vs != nil {
+		bv = *vs
+	}
+	return slices.EqualFunc(av, bv, func(x, y threadVersion) bool {
+		return x.name == y.name && sameFunc(x.thread, y.thread)
+	})
+}
+
+// sameFunc reports whether two threads have the same code, or are both nil.
+func sameFunc(a, b Thread) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
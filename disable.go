@@ -0,0 +1,58 @@
+package chord
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrDisabled is the error matched by errors.Is when a disabled thread is executed.
+var ErrDisabled = errors.New("chord: thread disabled")
+
+// DisabledError is returned in place of the execution of a disabled thread.
+type DisabledError struct {
+	Key string // Key of the disabled thread.
+}
+
+// Error implements the error interface.
+func (e *DisabledError) Error() string {
+	return "chord: thread " + strconv.Quote(e.Key) + " is disabled"
+}
+
+// Unwrap returns ErrDisabled, so that errors.Is(err, ErrDisabled) reports true.
+func (e *DisabledError) Unwrap() error {
+	return ErrDisabled
+}
+
+// Disable takes the thread registered under key out of rotation, such as for a
+// maintenance window, without losing its registration: until enabled again,
+// matching it selects the NotFound fallback of the chord if set, or else a
+// thread failing with a *DisabledError. Registering a thread under the key
+// enables it. It reports whether a thread is registered under key.
+func (c *Chord) Disable(key string) bool {
+	return c.setDisabled(key, true, EventDisable)
+}
+
+// Enable puts the thread registered under key, disabled by Disable, back into
+// rotation. It reports whether a thread is registered under key.
+func (c *Chord) Enable(key string) bool {
+	return c.setDisabled(key, false, EventEnable)
+}
+
+// Disabled reports whether the thread registered under key is disabled.
+func (c *Chord) Disabled(key string) bool {
+	e, ok := c.fetchEntry(key)
+	return ok && e.disabled.Load()
+}
+
+// setDisabled sets the disabled state of the thread registered under key,
+// notifying the change.
+func (c *Chord) setDisabled(key string, disabled bool, typ EventType) bool {
+	e, ok := c.fetchEntry(key)
+	if !ok {
+		return false
+	}
+	if e.disabled.Swap(disabled) != disabled {
+		c.notify(typ, key)
+	}
+	return true
+}
@@ -0,0 +1,342 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DocFormat is the format of the documentation generated by WriteDoc.
+type DocFormat string
+
+const (
+	ManFormat      DocFormat = "man"      // Man pages, in roff, of section 1.
+	MarkdownFormat DocFormat = "markdown" // Markdown files.
+)
+
+// ErrUnsupportedFormat is the error matched by errors.Is when documentation
+// is requested in a format other than ManFormat and MarkdownFormat.
+var ErrUnsupportedFormat = errors.New("unsupported documentation format")
+
+// DocsThread returns a thread generating the documentation of the root chord
+// with GenerateDocs, in the format and the directory given by its arguments,
+// and writing the names of the files generated to the Output. It is meant to
+// be registered on the root itself:
+//
+//	root.Register("docs", chord.DocsThread(root, "mycli"))
+//
+// so that the man pages are generated with, for instance:
+//
+//	mycli docs man ./man
+func DocsThread(root *Chord, program string) Thread {
+	return func(in *Input, out *Output) error {
+		if len(in.Args) < 2 {
+			return &ArgError{Index: len(in.Args), Name: []string{"format", "dir"}[len(in.Args)], Err: ErrMissingArg}
+		}
+		files, err := GenerateDocs(root, program, in.Args[1], DocFormat(in.Args[0]))
+		if errors.Is(err, ErrUnsupportedFormat) {
+			return &ArgError{Index: 0, Name: "format", Value: in.Args[0], Err: ErrUnsupportedFormat}
+		}
+		if err != nil {
+			return err
+		}
+		for _, name := range files {
+			fmt.Fprintln(out, name)
+		}
+		return out.Flush()
+	}
+}
+
+// GenerateDocs writes the documentation of the program, whose threads are
+// those of the root chord, to files of the directory, created if needed: a
+// page for the threads registered on the root itself, named after the
+// program, and a page per top-level chord, named after the program and its
+// key, such as "mycli.1" and "mycli-net.1", or "mycli.md" and "mycli-net.md".
+// It returns the paths of the files written.
+func GenerateDocs(root *Chord, program, dir string, format DocFormat) ([]string, error) {
+	ext, ok := map[DocFormat]string{ManFormat: ".1", MarkdownFormat: ".md"}[format]
+	if !ok {
+		return nil, fmt.Errorf("chord: %w %q", ErrUnsupportedFormat, format)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	keys := []string{""}
+	for _, c := range root.docPage(program, nil).chords {
+		keys = append(keys, c.key)
+	}
+	var files []string
+	for _, key := range keys {
+		name := filepath.Join(dir, docName(program, key)+ext)
+		f, err := os.Create(name)
+		if err != nil {
+			return files, err
+		}
+		err = WriteDoc(f, root, program, key, format)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return files, err
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+// WriteDoc writes the documentation page of the top-level chord mounted under
+// key on the root chord, in the format, describing the visible threads of its
+// subtree with their metadata, and the persistent flags of the root and of the
+// chord. The page of an empty key describes the threads registered on the root
+// itself, and refers to the pages of the top-level chords. A *NotFoundError is
+// returned when no visible chord is mounted under key.
+func WriteDoc(w io.Writer, root *Chord, program, key string, format DocFormat) error {
+	var path []string
+	if key != "" {
+		key = root.foldKey(key)
+		if m, ok := root.fetchMount(key); !ok || m.meta.Hidden || m.meta.Internal {
+			return root.notFoundError([]string{key})
+		}
+		path = []string{key}
+	}
+	page := root.docPage(program, path)
+	switch format {
+	case ManFormat:
+		return page.writeMan(w)
+	case MarkdownFormat:
+		return page.writeMarkdown(w)
+	}
+	return fmt.Errorf("chord: %w %q", ErrUnsupportedFormat, format)
+}
+
+// docPage is a page of documentation, of the root or of a top-level chord.
+type docPage struct {
+	program string
+	path    []string // Key of the top-level chord, empty for the root.
+	meta    Meta     // Metadata of the top-level chord.
+	threads []docEntry
+	flags   []Flag     // Persistent flags of the root and of the chord.
+	chords  []docChord // Top-level chords, on the page of the root.
+}
+
+// docEntry is a thread described by a page.
+type docEntry struct {
+	path []string
+	meta Meta
+}
+
+// docChord is a top-level chord listed by the page of the root.
+type docChord struct {
+	key  string
+	meta Meta
+}
+
+// docPage returns the page of the chord mounted under the path, one key at
+// most, or of the root for an empty path.
+func (c *Chord) docPage(program string, path []string) *docPage {
+	p := &docPage{program: program, path: path}
+	c.ensureLoaded()
+	if fs := c.fetchPersistentFlags(); fs != nil {
+		p.flags = fs.Flags()
+	}
+	if len(path) == 0 {
+		for _, key := range sortedKeys(&c.threads) {
+			if e, ok := c.fetchEntry(key); ok && !e.meta.Hidden && !e.meta.Internal {
+				p.threads = append(p.threads, docEntry{path: []string{key}, meta: e.meta})
+			}
+		}
+		for _, key := range sortedKeys(&c.chords) {
+			if m, ok := c.fetchMount(key); ok && !m.meta.Hidden && !m.meta.Internal {
+				p.chords = append(p.chords, docChord{key: key, meta: m.meta})
+			}
+		}
+		return p
+	}
+	m, _ := c.fetchMount(path[0])
+	p.meta = m.meta
+	if fs := m.chord.fetchPersistentFlags(); fs != nil {
+		p.flags = append(p.flags, fs.Flags()...)
+	}
+	m.chord.walkVisible(path, func(path []string, meta Meta) {
+		p.threads = append(p.threads, docEntry{path: path, meta: meta})
+	})
+	return p
+}
+
+// docName returns the name of the page of the top-level chord mounted under
+// key, without extension.
+func docName(program, key string) string {
+	if key == "" {
+		return program
+	}
+	return program + "-" + key
+}
+
+// command returns the command line of the path.
+func (p *docPage) command(path []string) string {
+	return strings.Join(append([]string{p.program}, path...), " ")
+}
+
+// writeMan writes the page as a man page.
+func (p *docPage) writeMan(w io.Writer) error {
+	var b strings.Builder
+	name := docName(p.program, strings.Join(p.path, ""))
+	fmt.Fprintf(&b, ".TH %s 1 \"\" %s\n", roffQuote(strings.ToUpper(name)), roffQuote(p.program))
+	b.WriteString(".SH NAME\n")
+	if listedDescription(p.meta) != "" {
+		fmt.Fprintf(&b, "%s \\- %s\n", roff(name), roff(listedDescription(p.meta)))
+	} else {
+		fmt.Fprintf(&b, "%s\n", roff(name))
+	}
+	b.WriteString(".SH SYNOPSIS\n")
+	if p.meta.Usage != "" {
+		fmt.Fprintf(&b, "%s\n", roff(p.meta.Usage))
+	} else {
+		fmt.Fprintf(&b, "\\fB%s\\fR \\fIcommand\\fR [\\fIflags\\fR] [\\fIargs\\fR]\n", roff(p.command(p.path)))
+	}
+	if len(p.threads) > 0 {
+		b.WriteString(".SH COMMANDS\n")
+		for _, t := range p.threads {
+			fmt.Fprintf(&b, ".TP\n\\fB%s\\fR\n", roff(p.command(t.path)))
+			if listedDescription(t.meta) != "" {
+				fmt.Fprintf(&b, "%s\n", roff(listedDescription(t.meta)))
+			}
+			if t.meta.Usage == "" && len(t.meta.Flags) == 0 && len(t.meta.Examples) == 0 {
+				continue
+			}
+			b.WriteString(".RS\n")
+			if t.meta.Usage != "" {
+				fmt.Fprintf(&b, ".PP\nUsage: %s\n", roff(t.meta.Usage))
+			}
+			writeManFlags(&b, t.meta.Flags)
+			if len(t.meta.Examples) > 0 {
+				b.WriteString(".PP\nExamples:\n.RS\n.nf\n")
+				for _, example := range t.meta.Examples {
+					fmt.Fprintf(&b, "%s\n", roff(example))
+				}
+				b.WriteString(".fi\n.RE\n")
+			}
+			b.WriteString(".RE\n")
+		}
+	}
+	if len(p.flags) > 0 {
+		b.WriteString(".SH OPTIONS\n")
+		writeManFlags(&b, p.flags)
+	}
+	var refs []string
+	if len(p.path) > 0 {
+		refs = append(refs, fmt.Sprintf("\\fB%s\\fR(1)", roff(p.program)))
+	}
+	for _, c := range p.chords {
+		refs = append(refs, fmt.Sprintf("\\fB%s\\fR(1)", roff(docName(p.program, c.key))))
+	}
+	if len(refs) > 0 {
+		fmt.Fprintf(&b, ".SH SEE ALSO\n%s\n", strings.Join(refs, ", "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeManFlags writes the flags as a list of tagged paragraphs.
+func writeManFlags(b *strings.Builder, flags []Flag) {
+	for _, f := range flags {
+		fmt.Fprintf(b, ".TP\n\\fB\\-\\-%s\\fR \\fI%s\\fR\n", roff(f.Name), f.Kind)
+		fmt.Fprintf(b, "%s\n", roff(flagDoc(f)))
+	}
+}
+
+// roff escapes the text for a line of a man page.
+func roff(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`, "\n", " ").Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// roffQuote escapes and quotes the text for an argument of a man page macro.
+func roffQuote(s string) string {
+	return `"` + strings.ReplaceAll(roff(s), `"`, `\(dq`) + `"`
+}
+
+// writeMarkdown writes the page as a markdown file.
+func (p *docPage) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", p.command(p.path))
+	if listedDescription(p.meta) != "" {
+		fmt.Fprintf(&b, "%s\n\n", listedDescription(p.meta))
+	}
+	usage := p.meta.Usage
+	if usage == "" {
+		usage = p.command(p.path) + " <command> [flags] [args]"
+	}
+	fmt.Fprintf(&b, "```\n%s\n```\n\n", usage)
+	if len(p.threads) > 0 {
+		b.WriteString("## Commands\n\n")
+		for _, t := range p.threads {
+			fmt.Fprintf(&b, "### %s\n\n", p.command(t.path))
+			if listedDescription(t.meta) != "" {
+				fmt.Fprintf(&b, "%s\n\n", listedDescription(t.meta))
+			}
+			if t.meta.Usage != "" {
+				fmt.Fprintf(&b, "```\n%s\n```\n\n", t.meta.Usage)
+			}
+			writeMarkdownFlags(&b, t.meta.Flags)
+			if len(t.meta.Examples) > 0 {
+				b.WriteString("Examples:\n\n```\n")
+				for _, example := range t.meta.Examples {
+					fmt.Fprintf(&b, "%s\n", example)
+				}
+				b.WriteString("```\n\n")
+			}
+		}
+	}
+	if len(p.flags) > 0 {
+		b.WriteString("## Options\n\n")
+		writeMarkdownFlags(&b, p.flags)
+	}
+	var refs []string
+	if len(p.path) > 0 {
+		refs = append(refs, fmt.Sprintf("- [%s](%s.md)", p.program, p.program))
+	}
+	for _, c := range p.chords {
+		ref := fmt.Sprintf("- [%s](%s.md)", p.command([]string{c.key}), docName(p.program, c.key))
+		if listedDescription(c.meta) != "" {
+			ref += ": " + listedDescription(c.meta)
+		}
+		refs = append(refs, ref)
+	}
+	if len(refs) > 0 {
+		fmt.Fprintf(&b, "## See also\n\n%s\n", strings.Join(refs, "\n"))
+	}
+	_, err := io.WriteString(w, strings.TrimRight(b.String(), "\n")+"\n")
+	return err
+}
+
+// writeMarkdownFlags writes the flags as a markdown table.
+func writeMarkdownFlags(b *strings.Builder, flags []Flag) {
+	if len(flags) == 0 {
+		return
+	}
+	b.WriteString("| Flag | Type | Description |\n| --- | --- | --- |\n")
+	for _, f := range flags {
+		fmt.Fprintf(b, "| `--%s` | %s | %s |\n", f.Name, f.Kind, strings.ReplaceAll(flagDoc(f), "|", `\|`))
+	}
+	b.WriteString("\n")
+}
+
+// flagDoc returns the usage of the flag followed by its default value or
+// whether it is required.
+func flagDoc(f Flag) string {
+	doc := f.Usage
+	switch {
+	case f.Required:
+		doc += " (required)"
+	case f.Default != "":
+		doc += fmt.Sprintf(" (default %q)", f.Default)
+	}
+	return strings.TrimSpace(doc)
+}
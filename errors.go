@@ -0,0 +1,59 @@
+package chord
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is the error matched by errors.Is when no thread is found for a path.
+var ErrNotFound = errors.New("chord: thread not found")
+
+// ErrForbidden is the error matched by errors.Is when the caller isn't allowed
+// to execute a thread.
+var ErrForbidden = errors.New("chord: forbidden")
+
+// ErrDuplicate is the error matched by errors.Is when a key is already
+// registered.
+var ErrDuplicate = errors.New("chord: duplicate key")
+
+// DuplicateError is returned by the registrations of a key under which a
+// thread is already registered, or by the mounts of a key under which a chord
+// is already mounted.
+type DuplicateError struct {
+	Key   string // Key already registered.
+	Mount bool   // Whether a chord is mounted under the key, rather than a thread registered.
+}
+
+// Error implements the error interface.
+func (e *DuplicateError) Error() string {
+	if e.Mount {
+		return "chord: a chord is already mounted under " + strconv.Quote(e.Key)
+	}
+	return "chord: a thread is already registered under " + strconv.Quote(e.Key)
+}
+
+// Unwrap returns ErrDuplicate, so that errors.Is(err, ErrDuplicate) reports true.
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicate
+}
+
+// NotFoundError is returned when no thread matches the path of an execution.
+type NotFoundError struct {
+	Path        []string // Path which could not be matched.
+	Suggestions []string // Keys close to the mistyped key, as returned by Chord.Suggest.
+}
+
+// Error implements the error interface.
+func (e *NotFoundError) Error() string {
+	msg := "chord: no thread found for path " + strconv.Quote(strings.Join(e.Path, " "))
+	if len(e.Suggestions) > 0 {
+		msg += "; did you mean " + quoteList(e.Suggestions) + "?"
+	}
+	return msg
+}
+
+// Unwrap returns ErrNotFound, so that errors.Is(err, ErrNotFound) reports true.
+func (e *NotFoundError) Unwrap() error {
+	return ErrNotFound
+}
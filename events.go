@@ -0,0 +1,125 @@
+package chord
+
+import "slices"
+
+// EventType identifies the kind of change reported by an Event.
+type EventType int
+
+const (
+	EventRegister   EventType = iota // A thread was registered.
+	EventUnregister                  // A thread was unregistered.
+	EventMount                       // A chord was mounted.
+	EventUnmount                     // A chord was unmounted.
+	EventUse                         // The middleware of the chord changed.
+	EventDisable                     // A thread was disabled.
+	EventEnable                      // A thread was enabled.
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventRegister:
+		return "register"
+	case EventUnregister:
+		return "unregister"
+	case EventMount:
+		return "mount"
+	case EventUnmount:
+		return "unmount"
+	case EventUse:
+		return "use"
+	case EventDisable:
+		return "disable"
+	case EventEnable:
+		return "enable"
+	}
+	return "unknown"
+}
+
+// Event reports a change of the registrations of a chord.
+type Event struct {
+	Type  EventType // Kind of change.
+	Chord *Chord    // Chord whose registrations changed.
+	Key   string    // Key of the thread or chord, empty for EventUse.
+}
+
+// OnChange registers an observer called synchronously, after the change, for
+// every registration change of the chord. Changes of the nested chords are
+// only reported to the observers of those chords; the same observer can be
+// registered on several chords and tell them apart through Event.Chord.
+func (c *Chord) OnChange(fn func(Event)) {
+	c.addObserver(fn)
+}
+
+// observer is an observer of the changes of a chord, registered by pointer so
+// that it can be removed.
+type observer struct {
+	fn func(Event)
+}
+
+// addObserver registers an observer as OnChange does, and returns a function
+// removing it.
+func (c *Chord) addObserver(fn func(Event)) (remove func()) {
+	o := &observer{fn: fn}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observers = append(c.observers, o)
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		// The slice is copied, as notify may be ranging over it.
+		c.observers = slices.DeleteFunc(slices.Clone(c.observers), func(p *observer) bool { return p == o })
+	}
+}
+
+// notify reports a change of the chord to its observers.
+func (c *Chord) notify(typ EventType, key string) {
+	c.mu.RLock()
+	observers := c.observers
+	c.mu.RUnlock()
+	if len(observers) == 0 {
+		return
+	}
+	ev := Event{Type: typ, Chord: c, Key: key}
+	for _, o := range observers {
+		o.fn(ev)
+	}
+}
+
+// OnRegister registers a hook called, as by OnChange, with the key of every
+// thread registered on the chord, such as to update completion data or remote
+// advertisements.
+func (c *Chord) OnRegister(fn func(key string)) {
+	c.onEvent(EventRegister, func(ev Event) { fn(ev.Key) })
+}
+
+// OnUnregister registers a hook called, as by OnChange, with the key of every
+// thread unregistered from the chord.
+func (c *Chord) OnUnregister(fn func(key string)) {
+	c.onEvent(EventUnregister, func(ev Event) { fn(ev.Key) })
+}
+
+// OnMount registers a hook called, as by OnChange, with the key of every chord
+// mounted on the chord and the chord mounted.
+func (c *Chord) OnMount(fn func(key string, chord *Chord)) {
+	c.onEvent(EventMount, func(ev Event) {
+		if m, ok := c.FetchChord(ev.Key); ok {
+			fn(ev.Key, m)
+		}
+	})
+}
+
+// OnUnmount registers a hook called, as by OnChange, with the key of every
+// chord unmounted from the chord.
+func (c *Chord) OnUnmount(fn func(key string)) {
+	c.onEvent(EventUnmount, func(ev Event) { fn(ev.Key) })
+}
+
+// onEvent registers an observer called for the events of the given type.
+func (c *Chord) onEvent(typ EventType, fn func(Event)) {
+	c.OnChange(func(ev Event) {
+		if ev.Type == typ {
+			fn(ev)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package chord
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exec describes an external command run by a thread.
+type Exec struct {
+	// Name is the name or path of the program, resolved as by exec.Command.
+	Name string
+	// Args are the templates of the command-line arguments, as documented by
+	// ExecThread. When empty, the arguments of the input are passed as is.
+	Args []string
+	// Dir is the working directory of the command. It defaults to the one of
+	// the calling process.
+	Dir string
+	// Env are extra environment variables of the command, as "KEY=value".
+	Env []string
+	// Timeout, when positive, is the maximum duration of the command.
+	Timeout time.Duration
+	// WaitDelay bounds the time waited for the output of a killed command,
+	// such as when it left children running. It defaults to one second.
+	WaitDelay time.Duration
+}
+
+// ExecThread returns a thread running an external command, with the arguments
+// built from the templates and the input. In a template, "$1" to "$9" and
+// "${10}" and beyond expand to the arguments of the input, and "${name}" to
+// the value of the flag name; a template made only of "$@" expands to all the
+// arguments of the input, as separate arguments. Without templates, the
+// arguments of the input are passed as is:
+//
+//	root.Register("ping", chord.ExecThread("ping", "-c", "${count}", "$1"))
+//
+// The flags of the input are also passed as the environment variables
+// returned by FlagEnv, such as CHORD_FLAG_COUNT. The standard output and error
+// of the command are written to the output, and its standard input reads from
+// the output. The command is killed when the context of the input is done. A
+// command exiting with a non-zero status fails with an *exec.ExitError, whose
+// status is reported by ExitCode.
+func ExecThread(name string, argsTemplate ...string) Thread {
+	return (&Exec{Name: name, Args: argsTemplate}).Thread()
+}
+
+// Thread returns a thread running the command, as documented by ExecThread.
+func (e *Exec) Thread() Thread {
+	return func(in *Input, out *Output) error {
+		ctx := in.Context()
+		if e.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+			defer cancel()
+		}
+		cmd := exec.CommandContext(ctx, e.Name, expandArgs(e.Args, in)...)
+		cmd.Dir = e.Dir
+		cmd.WaitDelay = e.WaitDelay
+		if cmd.WaitDelay <= 0 {
+			cmd.WaitDelay = time.Second
+		}
+		cmd.Env = append(cmd.Environ(), e.Env...)
+		cmd.Env = append(cmd.Env, FlagEnv(in.Flags)...)
+		if out != nil && out.Writer != nil {
+			cmd.Stdout = out
+			cmd.Stderr = out
+		}
+		if out != nil && out.Reader != nil {
+			cmd.Stdin = out
+		}
+		err := cmd.Run()
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+}
+
+// expandArgs builds the arguments of a command from the templates and the input.
+func expandArgs(templates []string, in *Input) []string {
+	if len(templates) == 0 {
+		return in.Args
+	}
+	args := make([]string, 0, len(templates))
+	for _, t := range templates {
+		if t == "$@" {
+			args = append(args, in.Args...)
+			continue
+		}
+		args = append(args, os.Expand(t, func(name string) string {
+			if i, err := strconv.Atoi(name); err == nil {
+				if i >= 1 && i <= len(in.Args) {
+					return in.Args[i-1]
+				}
+				return ""
+			}
+			return in.Flags[name]
+		}))
+	}
+	return args
+}
+
+// FlagEnv returns the environment variables passing the flags to an external
+// program, as "CHORD_FLAG_<NAME>=value" with the upper-case name of the flag,
+// its dashes replaced by underscores, in lexical order.
+func FlagEnv(flags map[string]string) []string {
+	env := make([]string, 0, len(flags))
+	for k, v := range flags {
+		env = append(env, "CHORD_FLAG_"+strings.ToUpper(strings.ReplaceAll(k, "-", "_"))+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
@@ -0,0 +1,16 @@
+package chord_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+func TestFlagEnv(t *testing.T) {
+	got := chord.FlagEnv(map[string]string{"dry-run": "true", "count": "3"})
+	want := []string{"CHORD_FLAG_COUNT=3", "CHORD_FLAG_DRY_RUN=true"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("FlagEnv = %q, want %q", got, want)
+	}
+}
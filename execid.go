@@ -0,0 +1,76 @@
+package chord
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"maps"
+)
+
+// Flags set by ExecutionID.
+const (
+	// ExecutionIDFlag holds the unique identifier of an execution.
+	ExecutionIDFlag = "execution-id"
+	// CorrelationIDFlag holds the identifier shared by the executions
+	// triggered by a same request, across processes.
+	CorrelationIDFlag = "correlation-id"
+)
+
+// CorrelationIDHeader is the header carrying the correlation ID through the
+// adapters based on HTTP-like headers.
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// executionIDsKey is the context key of the IDs of an execution.
+type executionIDsKey struct{}
+
+// executionIDs holds the IDs of an execution.
+type executionIDs struct {
+	execution, correlation string
+}
+
+// ExecutionID is a ThreadWrapper assigning a unique ID to every execution of
+// the thread. The thread receives a copy of the input whose ExecutionIDFlag
+// flag and context hold the new ID. Its CorrelationIDFlag flag is kept when
+// set by the caller, such as a remote chord forwarding the flags of its
+// input through RemoteThread, and is otherwise set to the execution ID, so
+// that the executions triggered by a request can be correlated. The IDs are
+// read by ExecutionIDOf and CorrelationIDOf, and logged by Logger.
+func ExecutionID(next Thread) Thread {
+	return func(in *Input, out *Output) error {
+		ids := executionIDs{execution: NewID(), correlation: in.Flags[CorrelationIDFlag]}
+		if ids.correlation == "" {
+			ids.correlation = ids.execution
+		}
+		in = in.WithContext(context.WithValue(in.Context(), executionIDsKey{}, ids))
+		in.Flags = maps.Clone(in.Flags)
+		if in.Flags == nil {
+			in.Flags = make(map[string]string, 2)
+		}
+		in.Flags[ExecutionIDFlag] = ids.execution
+		in.Flags[CorrelationIDFlag] = ids.correlation
+		return next(in, out)
+	}
+}
+
+// ExecutionIDOf returns the ID assigned to the execution of the input by
+// ExecutionID, or an empty string if none was.
+func ExecutionIDOf(in *Input) string {
+	ids, _ := in.Context().Value(executionIDsKey{}).(executionIDs)
+	return ids.execution
+}
+
+// CorrelationIDOf returns the correlation ID of the execution of the input, as
+// set by ExecutionID, or else the value of its CorrelationIDFlag flag.
+func CorrelationIDOf(in *Input) string {
+	if ids, ok := in.Context().Value(executionIDsKey{}).(executionIDs); ok {
+		return ids.correlation
+	}
+	return in.Flags[CorrelationIDFlag]
+}
+
+// NewID returns a random 128-bit identifier, hex-encoded.
+func NewID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
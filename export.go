@@ -0,0 +1,116 @@
+package chord
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Tree describes the hierarchy of a chord, as rendered by ExportJSON.
+type Tree struct {
+	Middlewares   int          `json:"middlewares"`       // Number of wrappers registered through Use.
+	ChordWrappers int          `json:"chordWrappers"`     // Number of wrappers registered through UseChord.
+	Threads       []ThreadNode `json:"threads,omitempty"` // Threads registered on the chord.
+	Chords        []ChordNode  `json:"chords,omitempty"`  // Chords mounted on the chord.
+}
+
+// ThreadNode describes a thread registered on a chord.
+type ThreadNode struct {
+	Key     string   `json:"key"`
+	Aliases []string `json:"aliases,omitempty"` // Aliases of the key, defined by Alias.
+	Meta    Meta     `json:"meta"`
+}
+
+// ChordNode describes a chord mounted on a chord.
+type ChordNode struct {
+	Key      string   `json:"key"`
+	Aliases  []string `json:"aliases,omitempty"` // Aliases of the key, defined by Alias.
+	Meta     Meta     `json:"meta"`
+	Wrappers int      `json:"wrappers"` // Number of wrappers supplied at mount time.
+	Tree     Tree     `json:"tree"`
+}
+
+// Tree returns the description of the hierarchy of the chord, with threads and
+// chords in lexical order of their keys.
+func (c *Chord) Tree() Tree {
+	c.ensureLoaded()
+	c.mu.RLock()
+	t := Tree{
+		Middlewares:   len(c.middlewares),
+		ChordWrappers: len(c.chordWrappers),
+	}
+	c.mu.RUnlock()
+	for _, key := range sortedKeys(&c.threads) {
+		if e, ok := c.fetchEntry(key); ok {
+			t.Threads = append(t.Threads, ThreadNode{Key: key, Aliases: c.FetchAliases(key), Meta: e.meta.clone()})
+		}
+	}
+	for _, key := range sortedKeys(&c.chords) {
+		if m, ok := c.fetchMount(key); ok {
+			t.Chords = append(t.Chords, ChordNode{
+				Key:      key,
+				Aliases:  c.FetchAliases(key),
+				Meta:     m.meta.clone(),
+				Wrappers: len(m.wrappers),
+				Tree:     m.chord.Tree(),
+			})
+		}
+	}
+	return t
+}
+
+// ExportJSON writes the description of the hierarchy of the chord to w as an
+// indented JSON document.
+func (c *Chord) ExportJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c.Tree())
+}
+
+// ExportDOT writes the hierarchy of the chord to w as a Graphviz DOT digraph.
+// Chords are rendered as boxes labeled with their middleware counts, and
+// threads as ellipses labeled with their description.
+func (c *Chord) ExportDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph chord {\n")
+	writeDOTChord(&b, "/", "root", Meta{}, c.Tree())
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeDOTChord writes the nodes and edges of the chord identified by id.
+func writeDOTChord(b *strings.Builder, id, key string, meta Meta, t Tree) {
+	label := []string{key}
+	if meta.Description != "" {
+		label = append(label, meta.Description)
+	}
+	label = append(label, fmt.Sprintf("middlewares: %d, chord wrappers: %d", t.Middlewares, t.ChordWrappers))
+	fmt.Fprintf(b, "\t%s [shape=box, label=%s];\n", dotQuote(id), dotQuote(strings.Join(label, "\n")))
+	for _, thread := range t.Threads {
+		tid := strings.TrimSuffix(id, "/") + "/" + thread.Key
+		label := thread.Key
+		if thread.Meta.Description != "" {
+			label += "\n" + thread.Meta.Description
+		}
+		fmt.Fprintf(b, "\t%s [shape=ellipse, label=%s];\n", dotQuote(tid), dotQuote(label))
+		fmt.Fprintf(b, "\t%s -> %s;\n", dotQuote(id), dotQuote(tid))
+	}
+	for _, chord := range t.Chords {
+		cid := strings.TrimSuffix(id, "/") + "/" + chord.Key
+		writeDOTChord(b, cid, chord.Key, chord.Meta, chord.Tree)
+		if chord.Wrappers > 0 {
+			fmt.Fprintf(b, "\t%s -> %s [label=%s];\n", dotQuote(id), dotQuote(cid), dotQuote(fmt.Sprintf("wrappers: %d", chord.Wrappers)))
+		} else {
+			fmt.Fprintf(b, "\t%s -> %s;\n", dotQuote(id), dotQuote(cid))
+		}
+	}
+}
+
+// dotQuote returns s as a double-quoted DOT string, with line breaks rendered
+// as centered label lines.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,53 @@
+package chord
+
+import "sync"
+
+// RegisterFactory registers under key a thread built by a factory on first
+// Match, so that expensive initializations, such as opening database
+// connections or loading models, are deferred until the thread is needed.
+// The thread is then cached and wrapped with the provided wrappers, in FIFO
+// order. When the factory fails, the matched thread fails with its error and
+// the factory is called again on the next Match. The key is validated, and
+// duplicates rejected, as by Register, which the returned Registration also
+// mirrors.
+func (c *Chord) RegisterFactory(key string, factory func() (Thread, error), tw ...ThreadWrapper) (*Registration, error) {
+	key = c.foldKey(key)
+	if err := c.checkThread(key, factory == nil); err != nil {
+		return nil, err
+	}
+	f := &threadFactory{build: factory, wrappers: tw}
+	e := &entry{factory: f}
+	e.thread = func(in *Input, out *Output) error {
+		thread, err := f.get()
+		if err != nil {
+			return err
+		}
+		return thread(in, out)
+	}
+	if err := c.storeEntry(key, e, false); err != nil {
+		return nil, err
+	}
+	return &Registration{c: c, key: key, e: e}, nil
+}
+
+// threadFactory builds the thread of an entry registered by RegisterFactory.
+type threadFactory struct {
+	build    func() (Thread, error)
+	wrappers []ThreadWrapper
+	thread   Thread // Thread built, nil until the factory succeeds.
+	mu       sync.Mutex
+}
+
+// get returns the thread of the factory, building it if not built yet.
+func (f *threadFactory) get() (Thread, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.thread == nil {
+		thread, err := f.build()
+		if err != nil {
+			return nil, err
+		}
+		f.thread = WrapThreads(thread, f.wrappers...)
+	}
+	return f.thread, nil
+}
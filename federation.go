@@ -0,0 +1,63 @@
+package chord
+
+import (
+	"context"
+	"slices"
+)
+
+// MountRemote fetches the description of the chord served by Serve at the
+// endpoint, as accepted by RemoteThread, and mounts under key a chord mirroring
+// it, whose threads forward their input to the remote ones. The metadata of
+// the remote threads and chords is preserved, so that help and listings
+// present local and remote threads alike; the middleware of the remote chord
+// runs remotely. The thread wrappers are supplied to the mount as by Mount.
+func (c *Chord) MountRemote(ctx context.Context, key, endpoint string, tw ...ThreadWrapper) error {
+	network, address, err := parseEndpoint(endpoint)
+	if err != nil {
+		return err
+	}
+	cl, err := dialContext(ctx, network, address)
+	if err != nil {
+		return err
+	}
+	defer cl.Close()
+	tree, err := cl.Tree(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Mount(key, proxyChord(network, address, nil, tree), tw...)
+}
+
+// SyncRemote mounts the remote chord under key as MountRemote does, and keeps
+// the mount in sync with the changes of the remote tree until the context is
+// done or the connection fails, remounting a new mirror on every change. The
+// mount is left in place when SyncRemote returns, so that callers may retry.
+func (c *Chord) SyncRemote(ctx context.Context, key, endpoint string, tw ...ThreadWrapper) error {
+	network, address, err := parseEndpoint(endpoint)
+	if err != nil {
+		return err
+	}
+	cl, err := dialContext(ctx, network, address)
+	if err != nil {
+		return err
+	}
+	defer cl.Close()
+	return cl.Watch(ctx, func(tree Tree) {
+		c.ReplaceMount(key, proxyChord(network, address, nil, tree), tw...)
+	})
+}
+
+// proxyChord returns a chord mirroring the remote tree found under the prefix,
+// whose threads forward their input to the remote ones.
+func proxyChord(network, address string, prefix []string, tree Tree) *Chord {
+	chord := NewChord()
+	for _, t := range tree.Threads {
+		path := slices.Concat(prefix, []string{t.Key})
+		chord.RegisterWithMeta(t.Key, remoteThread(network, address, path), t.Meta)
+	}
+	for _, n := range tree.Chords {
+		path := slices.Concat(prefix, []string{n.Key})
+		chord.MountWithMeta(n.Key, proxyChord(network, address, path, n.Tree), n.Meta)
+	}
+	return chord
+}
@@ -0,0 +1,331 @@
+package chord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FlagKind is the type of the value of a declared flag.
+type FlagKind int
+
+const (
+	StringFlag      FlagKind = iota // Any string.
+	IntFlag                         // An integer, as parsed by strconv.Atoi.
+	BoolFlag                        // A boolean, as parsed by strconv.ParseBool; an empty value means true.
+	DurationFlag                    // A duration, as parsed by time.ParseDuration.
+	StringSliceFlag                 // A comma-separated list of strings.
+)
+
+// String returns the name of the flag kind.
+func (k FlagKind) String() string {
+	switch k {
+	case StringFlag:
+		return "string"
+	case IntFlag:
+		return "int"
+	case BoolFlag:
+		return "bool"
+	case DurationFlag:
+		return "duration"
+	case StringSliceFlag:
+		return "strings"
+	}
+	return "unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler, so that flag kinds are
+// encoded by their names.
+func (k FlagKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding the names of
+// the flag kinds.
+func (k *FlagKind) UnmarshalText(text []byte) error {
+	for kind := StringFlag; kind <= StringSliceFlag; kind++ {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("chord: unknown flag kind %q", text)
+}
+
+// Flag is the declaration of a flag of a FlagSet.
+type Flag struct {
+	Name     string   `json:"name"`
+	Kind     FlagKind `json:"kind"`
+	Default  string   `json:"default,omitempty"` // Default value, in its string form.
+	Usage    string   `json:"usage,omitempty"`
+	Required bool     `json:"required,omitempty"`
+}
+
+// ErrMissingFlag is the error matched by errors.Is when a required flag is missing.
+var ErrMissingFlag = errors.New("missing required flag")
+
+// FlagError is returned when the flags of an input don't satisfy a FlagSet.
+type FlagError struct {
+	Name  string // Name of the flag.
+	Value string // Value of the flag, empty when missing.
+	Err   error  // Parse error, or ErrMissingFlag.
+}
+
+// Error implements the error interface.
+func (e *FlagError) Error() string {
+	if errors.Is(e.Err, ErrMissingFlag) {
+		return "chord: missing required flag --" + e.Name
+	}
+	return fmt.Sprintf("chord: invalid value %q for flag --%s: %v", e.Value, e.Name, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *FlagError) Unwrap() error {
+	return e.Err
+}
+
+// FlagSet declares the typed flags accepted by a thread, with their defaults
+// and whether they are required. Flags which are not declared are left
+// unchecked, so that flags meant for other threads or middleware pass through.
+// A FlagSet is meant to be fully declared before it is used concurrently.
+type FlagSet struct {
+	flags []Flag
+}
+
+// NewFlagSet returns an empty FlagSet.
+func NewFlagSet() *FlagSet {
+	return &FlagSet{}
+}
+
+// String declares a string flag. It returns the FlagSet to allow chaining.
+func (fs *FlagSet) String(name, def, usage string) *FlagSet {
+	return fs.declare(Flag{Name: name, Kind: StringFlag, Default: def, Usage: usage})
+}
+
+// Int declares an integer flag. It returns the FlagSet to allow chaining.
+func (fs *FlagSet) Int(name string, def int, usage string) *FlagSet {
+	return fs.declare(Flag{Name: name, Kind: IntFlag, Default: strconv.Itoa(def), Usage: usage})
+}
+
+// Bool declares a boolean flag. It returns the FlagSet to allow chaining.
+func (fs *FlagSet) Bool(name string, def bool, usage string) *FlagSet {
+	return fs.declare(Flag{Name: name, Kind: BoolFlag, Default: strconv.FormatBool(def), Usage: usage})
+}
+
+// Duration declares a duration flag. It returns the FlagSet to allow chaining.
+func (fs *FlagSet) Duration(name string, def time.Duration, usage string) *FlagSet {
+	return fs.declare(Flag{Name: name, Kind: DurationFlag, Default: def.String(), Usage: usage})
+}
+
+// StringSlice declares a comma-separated list flag. It returns the FlagSet to
+// allow chaining.
+func (fs *FlagSet) StringSlice(name string, def []string, usage string) *FlagSet {
+	return fs.declare(Flag{Name: name, Kind: StringSliceFlag, Default: strings.Join(def, ","), Usage: usage})
+}
+
+// Required marks the declared flags with the given names as required.
+// It returns the FlagSet to allow chaining.
+func (fs *FlagSet) Required(names ...string) *FlagSet {
+	for _, name := range names {
+		if i := fs.index(name); i >= 0 {
+			fs.flags[i].Required = true
+		}
+	}
+	return fs
+}
+
+// Flags returns a copy of the declarations of the FlagSet, in order.
+func (fs *FlagSet) Flags() []Flag {
+	flags := make([]Flag, len(fs.flags))
+	copy(flags, fs.flags)
+	return flags
+}
+
+// declare adds or replaces the declaration of a flag.
+func (fs *FlagSet) declare(f Flag) *FlagSet {
+	if i := fs.index(f.Name); i >= 0 {
+		fs.flags[i] = f
+	} else {
+		fs.flags = append(fs.flags, f)
+	}
+	return fs
+}
+
+// index returns the index of the declaration of the flag, or -1 if none.
+func (fs *FlagSet) index(name string) int {
+	for i, f := range fs.flags {
+		if f.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Parse checks the flags against the declarations of the FlagSet and returns
+// their typed values, with the defaults of the missing flags applied.
+// A *FlagError is returned for the first invalid or missing required flag.
+func (fs *FlagSet) Parse(flags map[string]string) (*FlagValues, error) {
+	v := &FlagValues{raw: make(map[string]string, len(flags)+len(fs.flags)), values: make(map[string]any)}
+	for name, value := range flags {
+		v.raw[name] = value
+	}
+	for _, f := range fs.flags {
+		raw, ok := flags[f.Name]
+		if !ok {
+			if f.Required {
+				return nil, &FlagError{Name: f.Name, Err: ErrMissingFlag}
+			}
+			raw = f.Default
+		}
+		value, err := parseFlag(f.Kind, raw)
+		if err != nil {
+			return nil, &FlagError{Name: f.Name, Value: raw, Err: err}
+		}
+		v.raw[f.Name] = raw
+		v.values[f.Name] = value
+	}
+	return v, nil
+}
+
+// Validate is a ThreadWrapper parsing the flags of the input before the thread
+// is invoked. On failure the error is written to the Output and returned
+// without invoking the thread; on success the thread receives a copy of the
+// input with the defaults of the missing flags merged into Input.Flags, and
+// retrieves the typed values through Flags.
+func (fs *FlagSet) Validate(next Thread) Thread {
+	return func(in *Input, out *Output) error {
+		v, err := fs.Parse(in.Flags)
+		if err != nil {
+			if out != nil && out.Writer != nil {
+				fmt.Fprintln(out, err)
+				out.Flush()
+			}
+			return err
+		}
+		in = in.WithContext(context.WithValue(in.Context(), flagValuesKey{}, v))
+		in.Flags = make(map[string]string, len(v.raw))
+		for name, value := range v.raw {
+			in.Flags[name] = value
+		}
+		return next(in, out)
+	}
+}
+
+// PersistentFlags returns the flags declared on the chord which are inherited
+// by all the threads matched through it, including those of nested chords.
+// When such a thread is invoked, the flags are validated and their defaults
+// merged into Input.Flags as with FlagSet.Validate, before the middleware of
+// the chord runs. The flags are meant to be declared before the chord is used
+// concurrently.
+func (c *Chord) PersistentFlags() *FlagSet {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.persistentFlags == nil {
+		c.persistentFlags = NewFlagSet()
+	}
+	return c.persistentFlags
+}
+
+// fetchPersistentFlags returns the persistent flags of the chord, or nil if
+// none are declared.
+func (c *Chord) fetchPersistentFlags() *FlagSet {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.persistentFlags == nil || len(c.persistentFlags.flags) == 0 {
+		return nil
+	}
+	return c.persistentFlags
+}
+
+// flagValuesKey is the context key of the values parsed by FlagSet.Validate.
+type flagValuesKey struct{}
+
+// Flags returns the typed values of the flags of the input, as parsed by the
+// FlagSet.Validate wrapper. When the thread isn't wrapped by one, the values
+// are the raw flags of the input, parsed on access.
+func Flags(in *Input) *FlagValues {
+	if v, ok := in.Context().Value(flagValuesKey{}).(*FlagValues); ok {
+		return v
+	}
+	v, _ := NewFlagSet().Parse(in.Flags)
+	return v
+}
+
+// FlagValues holds the typed values of flags. Accessors return the zero value
+// of their type when the flag is missing or doesn't hold a value of that type.
+type FlagValues struct {
+	raw    map[string]string
+	values map[string]any
+}
+
+// Has reports whether the flag is set or has a default value.
+func (v *FlagValues) Has(name string) bool {
+	_, ok := v.raw[name]
+	return ok
+}
+
+// String returns the value of the flag in its string form.
+func (v *FlagValues) String(name string) string {
+	return v.raw[name]
+}
+
+// Int returns the value of an integer flag.
+func (v *FlagValues) Int(name string) int {
+	i, _ := v.value(name, IntFlag).(int)
+	return i
+}
+
+// Bool returns the value of a boolean flag.
+func (v *FlagValues) Bool(name string) bool {
+	b, _ := v.value(name, BoolFlag).(bool)
+	return b
+}
+
+// Duration returns the value of a duration flag.
+func (v *FlagValues) Duration(name string) time.Duration {
+	d, _ := v.value(name, DurationFlag).(time.Duration)
+	return d
+}
+
+// StringSlice returns the value of a comma-separated list flag.
+func (v *FlagValues) StringSlice(name string) []string {
+	s, _ := v.value(name, StringSliceFlag).([]string)
+	return s
+}
+
+// value returns the typed value of the flag, parsing undeclared flags as kind.
+func (v *FlagValues) value(name string, kind FlagKind) any {
+	if value, ok := v.values[name]; ok {
+		return value
+	}
+	raw, ok := v.raw[name]
+	if !ok {
+		return nil
+	}
+	value, _ := parseFlag(kind, raw)
+	return value
+}
+
+// parseFlag parses the string form of a flag value of the given kind.
+func parseFlag(kind FlagKind, raw string) (any, error) {
+	switch kind {
+	case IntFlag:
+		return strconv.Atoi(raw)
+	case BoolFlag:
+		if raw == "" {
+			return true, nil
+		}
+		return strconv.ParseBool(raw)
+	case DurationFlag:
+		return time.ParseDuration(raw)
+	case StringSliceFlag:
+		if raw == "" {
+			return []string{}, nil
+		}
+		return strings.Split(raw, ","), nil
+	}
+	return raw, nil
+}
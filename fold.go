@@ -0,0 +1,46 @@
+package chord
+
+import (
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// KeyFold selects how the static keys of a chord are normalized, both when
+// registered and when matched, so that differently spelled keys resolve
+// identically, such as the keys typed by the users of a chat bot.
+type KeyFold uint32
+
+// Key normalizations, combined with |.
+const (
+	// FoldCase folds the case of the keys, so that "Status" and "status"
+	// resolve identically.
+	FoldCase KeyFold = 1 << iota
+	// FoldUnicode normalizes the keys to the Unicode NFKC form, so that
+	// canonically and compatibility equivalent keys, such as composed and
+	// decomposed accents or full-width letters, resolve identically.
+	FoldUnicode
+)
+
+// SetKeyFold sets the normalization of the static keys of the chord, which
+// applies to its registrations, mounts, aliases and lookups, but not to its
+// nested chords. Parameter and wildcard keys are left untouched. It is meant
+// to be set before anything is registered on the chord: keys registered
+// before aren't normalized.
+func (c *Chord) SetKeyFold(fold KeyFold) {
+	c.keyFold.Store(uint32(fold))
+}
+
+// foldKey returns the key normalized as set by SetKeyFold.
+func (c *Chord) foldKey(key string) string {
+	fold := KeyFold(c.keyFold.Load())
+	if fold == 0 || isParam(key) || isWildcard(key) {
+		return key
+	}
+	if fold&FoldUnicode != 0 {
+		key = norm.NFKC.String(key)
+	}
+	if fold&FoldCase != 0 {
+		key = cases.Fold().String(key)
+	}
+	return key
+}
@@ -0,0 +1,241 @@
+/*
+Package fschord serves a chord tree from a filesystem, such as a directory of
+scripts.
+
+The directories of an fs.FS are mapped to nested chords and its files to
+threads, keyed by their name without extension. The thread of a file is built
+by the Handler of its extension: JSON descriptors of remote threads and shell
+scripts are supported by default. Directories are loaded lazily, on the first
+match of a path through them, and reloaded once changed when watched.
+*/
+package fschord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphitects/chord"
+)
+
+// Handler returns the thread of a file, along with its metadata, from its name
+// in the filesystem and its content.
+type Handler func(name string, data []byte) (chord.Thread, chord.Meta, error)
+
+// FS serves the files of a filesystem as the threads of a chord tree.
+type FS struct {
+	// Handlers maps the extensions of the files, such as ".sh", to the handlers
+	// building their threads. Files of other extensions are ignored. It
+	// defaults to the Descriptor handler for ".json" and to the Script handler
+	// running "sh" for ".sh".
+	Handlers map[string]Handler
+	// OnError, when set, is called with the errors of the files and
+	// directories which can't be loaded.
+	OnError func(name string, err error)
+
+	fsys fs.FS
+	root *chord.Chord
+
+	// dirs maps the names of the directories to their chord and the stamp of
+	// their content when loaded, empty while not loaded.
+	dirs map[string]*dir
+	mu   sync.Mutex
+}
+
+// dir is a directory served as a chord.
+type dir struct {
+	chord *chord.Chord
+	stamp string
+}
+
+// New returns an FS serving the files of fsys.
+func New(fsys fs.FS) *FS {
+	f := &FS{
+		Handlers: map[string]Handler{
+			".json": Descriptor,
+			".sh":   Script("sh", "-s", "--"),
+		},
+		fsys: fsys,
+		dirs: make(map[string]*dir),
+	}
+	f.root = f.chord(".")
+	return f
+}
+
+// Root returns the chord of the root directory, to be mounted or used as the
+// root of a tree.
+func (f *FS) Root() *chord.Chord {
+	return f.root
+}
+
+// chord returns a new lazy chord loading the directory.
+func (f *FS) chord(name string) *chord.Chord {
+	c := chord.NewLazyChord(func(c *chord.Chord) {
+		f.load(name, c)
+	})
+	f.mu.Lock()
+	f.dirs[name] = &dir{chord: c}
+	f.mu.Unlock()
+	return c
+}
+
+// load registers the threads and chords of the directory on c.
+func (f *FS) load(name string, c *chord.Chord) {
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		f.report(name, err)
+		return
+	}
+	f.mu.Lock()
+	if d, ok := f.dirs[name]; ok {
+		d.stamp = stamp(entries)
+	}
+	f.mu.Unlock()
+
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		sub := path.Join(name, e.Name())
+		if e.IsDir() {
+			if err := c.Mount(e.Name(), f.chord(sub)); err != nil {
+				f.report(sub, err)
+			}
+			continue
+		}
+		ext := path.Ext(e.Name())
+		h, ok := f.Handlers[ext]
+		if !ok {
+			continue
+		}
+		data, err := fs.ReadFile(f.fsys, sub)
+		if err != nil {
+			f.report(sub, err)
+			continue
+		}
+		thread, meta, err := h(sub, data)
+		if err != nil {
+			f.report(sub, err)
+			continue
+		}
+		if _, err := c.RegisterWithMeta(strings.TrimSuffix(e.Name(), ext), thread, meta); err != nil {
+			f.report(sub, err)
+		}
+	}
+}
+
+// report reports the error of the file to OnError, if set.
+func (f *FS) report(name string, err error) {
+	if f.OnError != nil {
+		f.OnError(name, err)
+	}
+}
+
+// Watch checks the loaded directories for changes at every interval, until
+// the context is done, and invalidates the chords of the changed ones so that
+// they are loaded again on their next match. Changes are detected from the
+// names, sizes and modification times of the entries of the directories.
+func (f *FS) Watch(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			f.check()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// check invalidates the chords of the loaded directories which changed.
+func (f *FS) check() {
+	var changed []*chord.Chord
+	f.mu.Lock()
+	for name, d := range f.dirs {
+		if d.stamp == "" {
+			continue
+		}
+		entries, err := fs.ReadDir(f.fsys, name)
+		if err == nil && stamp(entries) == d.stamp {
+			continue
+		}
+		// The chords of the subdirectories are replaced on reload.
+		for sub := range f.dirs {
+			if name == "." && sub != "." || strings.HasPrefix(sub, name+"/") {
+				delete(f.dirs, sub)
+			}
+		}
+		d.stamp = ""
+		changed = append(changed, d.chord)
+	}
+	f.mu.Unlock()
+	// Chords are invalidated without holding the lock, which loading takes.
+	for _, c := range changed {
+		c.Invalidate()
+	}
+}
+
+// stamp returns a summary of the entries of a directory which changes with
+// their names, sizes and modification times.
+func stamp(entries []fs.DirEntry) string {
+	var b strings.Builder
+	b.WriteString("/")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%s:%t", e.Name(), e.IsDir())
+		if info, err := e.Info(); err == nil && !e.IsDir() {
+			fmt.Fprintf(&b, ":%d:%d", info.Size(), info.ModTime().UnixNano())
+		}
+		b.WriteString("/")
+	}
+	return b.String()
+}
+
+// DescriptorFile is the content of a JSON descriptor file, describing a remote
+// thread as accepted by chord.RemoteThread.
+type DescriptorFile struct {
+	Endpoint string     `json:"endpoint"`
+	Path     []string   `json:"path"`
+	Meta     chord.Meta `json:"meta"`
+}
+
+// Descriptor is a Handler building a remote thread from a JSON DescriptorFile.
+func Descriptor(name string, data []byte) (chord.Thread, chord.Meta, error) {
+	var d DescriptorFile
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, chord.Meta{}, fmt.Errorf("fschord: invalid descriptor: %w", err)
+	}
+	if d.Endpoint == "" {
+		return nil, chord.Meta{}, errors.New("fschord: descriptor without endpoint")
+	}
+	return chord.RemoteThread(d.Endpoint, d.Path), d.Meta, nil
+}
+
+// Script returns a Handler building threads which run a file as a script, by
+// passing its content to the standard input of the command, followed by the
+// arguments of the input. The flags of the input are passed as the
+// environment variables returned by chord.FlagEnv. The
+// standard output and error of the command are written to the output. The
+// error of a failing command reports its exit status through ExitCode.
+func Script(command string, args ...string) Handler {
+	return func(name string, data []byte) (chord.Thread, chord.Meta, error) {
+		thread := func(in *chord.Input, out *chord.Output) error {
+			cmd := exec.CommandContext(in.Context(), command, append(args[:len(args):len(args)], in.Args...)...)
+			cmd.Stdin = strings.NewReader(string(data))
+			if out != nil && out.Writer != nil {
+				cmd.Stdout = out
+				cmd.Stderr = out
+			}
+			cmd.Env = append(cmd.Environ(), chord.FlagEnv(in.Flags)...)
+			return cmd.Run()
+		}
+		return thread, chord.Meta{}, nil
+	}
+}
@@ -0,0 +1,86 @@
+package fschord
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/graphitects/chord"
+)
+
+// execute executes the thread of the path on c and returns its output.
+func execute(c *chord.Chord, path []string, in *chord.Input) (string, error) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bw)}
+	err := c.Execute(path, in, out)
+	bw.Flush()
+	return buf.String(), err
+}
+
+// text is a Handler building threads writing the content of their file.
+func text(name string, data []byte) (chord.Thread, chord.Meta, error) {
+	return func(in *chord.Input, out *chord.Output) error {
+		out.Write(data)
+		return out.Flush()
+	}, chord.Meta{Description: name}, nil
+}
+
+func TestFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"hello.txt":      {Data: []byte("hello")},
+		"ops/deploy.txt": {Data: []byte("deploying")},
+		".hidden.txt":    {Data: []byte("hidden")},
+		"notes.md":       {Data: []byte("ignored")},
+	}
+	f := New(fsys)
+	f.Handlers[".txt"] = text
+	root := f.Root()
+
+	if got, err := execute(root, []string{"hello"}, &chord.Input{}); err != nil || got != "hello" {
+		t.Fatalf("output = %q, %v, want %q", got, err, "hello")
+	}
+	if got, err := execute(root, []string{"ops", "deploy"}, &chord.Input{}); err != nil || got != "deploying" {
+		t.Fatalf("output = %q, %v, want %q", got, err, "deploying")
+	}
+	if meta, _ := root.FetchMeta("hello"); meta.Description != "hello.txt" {
+		t.Fatalf("description = %q, want the name of the file", meta.Description)
+	}
+	for _, key := range []string{".hidden", "notes"} {
+		if _, err := execute(root, []string{key}, &chord.Input{}); err == nil {
+			t.Errorf("%s was loaded", key)
+		}
+	}
+}
+
+func TestFSReload(t *testing.T) {
+	fsys := fstest.MapFS{"hello.txt": {Data: []byte("hello")}}
+	f := New(fsys)
+	f.Handlers[".txt"] = text
+	root := f.Root()
+	execute(root, []string{"hello"}, &chord.Input{})
+
+	fsys["hello.txt"] = &fstest.MapFile{Data: []byte("hello again")}
+	fsys["bye.txt"] = &fstest.MapFile{Data: []byte("bye")}
+	f.check()
+	if got, _ := execute(root, []string{"hello"}, &chord.Input{}); got != "hello again" {
+		t.Fatalf("output after a change = %q, want %q", got, "hello again")
+	}
+	if got, _ := execute(root, []string{"bye"}, &chord.Input{}); got != "bye" {
+		t.Fatalf("output of a new file = %q, want %q", got, "bye")
+	}
+}
+
+func TestScript(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip(err)
+	}
+	f := New(fstest.MapFS{"greet.sh": {Data: []byte(`echo "hello $1 $CHORD_FLAG_GREETING_STYLE"`)}})
+	in := &chord.Input{Args: []string{"bob"}, Flags: map[string]string{"greeting-style": "warmly"}}
+	if got, err := execute(f.Root(), []string{"greet"}, in); err != nil || got != "hello bob warmly\n" {
+		t.Fatalf("output = %q, %v, want %q", got, err, "hello bob warmly\n")
+	}
+}
@@ -0,0 +1,82 @@
+package chord
+
+import "context"
+
+// Future is the handle of an execution running asynchronously, such as one
+// started by ExecuteAsync or submitted to a Pool.
+type Future struct {
+	done   chan struct{}
+	err    error  // Error of the execution, set before done is closed.
+	output []byte // Output captured by the execution, set before done is closed.
+}
+
+// newFuture returns the future of an execution which hasn't completed yet.
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+// complete records the error of the execution and marks it as completed.
+func (f *Future) complete(err error) {
+	f.err = err
+	close(f.done)
+}
+
+// Done returns a channel closed once the execution has completed.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Err returns the error of the execution once it has completed, or nil while
+// it is running.
+func (f *Future) Err() error {
+	select {
+	case <-f.done:
+		return f.err
+	default:
+		return nil
+	}
+}
+
+// Output returns the output written by the thread once the execution has
+// completed, or nil while it is running. Only the executions started by
+// ExecuteAsync capture their output.
+func (f *Future) Output() []byte {
+	select {
+	case <-f.done:
+		return f.output
+	default:
+		return nil
+	}
+}
+
+// Wait waits for the execution to complete and returns its error, or returns
+// the error of the context if it is done first.
+func (f *Future) Wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ExecuteAsync matches the thread for a path, as Execute does, and invokes it
+// in a new goroutine, returning a Future to collect its error and output
+// later. The output of the thread is captured into a buffer, returned by
+// Future.Output, and the reader side of its Output reads nothing. A panic of
+// the thread is recovered as by Recover. A *NotFoundError is returned when
+// nothing is registered under the path. The thread isn't invoked when the
+// context of the input is done, as by Execute.
+func (c *Chord) ExecuteAsync(path []string, in *Input) (*Future, error) {
+	thread, ok := Match(c, path)
+	if !ok {
+		return nil, c.notFoundError(path)
+	}
+	f := newFuture()
+	go func() {
+		data, err := captureOutput(executed(recoverThread(thread, nil)), in, nil)
+		f.output = data
+		f.complete(err)
+	}()
+	return f, nil
+}
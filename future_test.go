@@ -0,0 +1,60 @@
+package chord_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+func TestExecuteAsync(t *testing.T) {
+	c := chord.NewChord()
+	c.Register("a", echo("out"))
+	f, err := c.ExecuteAsync([]string{"a"}, &chord.Input{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(f.Output()); got != "out" {
+		t.Fatalf("Output = %q, want %q", got, "out")
+	}
+	if _, err := c.ExecuteAsync([]string{"missing"}, &chord.Input{}); !errors.Is(err, chord.ErrNotFound) {
+		t.Fatalf("ExecuteAsync of a missing path = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCanceledExecutionsSkipThread(t *testing.T) {
+	c := chord.NewChord()
+	invoked := false
+	c.Register("a", func(*chord.Input, *chord.Output) error {
+		invoked = true
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	in := func() *chord.Input { return (&chord.Input{}).WithContext(ctx) }
+
+	if _, err := execute(t, c, []string{"a"}, in()); !errors.Is(err, context.Canceled) {
+		t.Errorf("Execute = %v, want context.Canceled", err)
+	}
+	f, _ := c.ExecuteAsync([]string{"a"}, in())
+	if err := f.Wait(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Errorf("ExecuteAsync = %v, want context.Canceled", err)
+	}
+	if _, err := c.ExecuteAll([]chord.Request{{Path: []string{"a"}, Input: in()}}, chord.BatchOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("ExecuteAll = %v, want context.Canceled", err)
+	}
+	report, err := c.Broadcast(nil, in())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Broadcast = %v, want context.Canceled", err)
+	}
+	if invoked {
+		t.Fatal("thread invoked with a canceled context")
+	}
+}
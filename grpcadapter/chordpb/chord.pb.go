@@ -0,0 +1,223 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: chord.proto
+
+package chordpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ExecuteRequest is the input of a thread execution.
+type ExecuteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Keys of the path of the thread.
+	Path []string `protobuf:"bytes,1,rep,name=path,proto3" json:"path,omitempty"`
+	// Arguments passed to the thread.
+	Args []string `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	// Flags passed to the thread.
+	Flags map[string]string `protobuf:"bytes,3,rep,name=flags,proto3" json:"flags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Data readable from the reader side of the output of the thread.
+	Stdin []byte `protobuf:"bytes,4,opt,name=stdin,proto3" json:"stdin,omitempty"`
+	// Identifier of the execution context, defaulting to the joined path.
+	Key           string `protobuf:"bytes,5,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteRequest) Reset() {
+	*x = ExecuteRequest{}
+	mi := &file_chord_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteRequest) ProtoMessage() {}
+
+func (x *ExecuteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chord_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteRequest) Descriptor() ([]byte, []int) {
+	return file_chord_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ExecuteRequest) GetPath() []string {
+	if x != nil {
+		return x.Path
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetFlags() map[string]string {
+	if x != nil {
+		return x.Flags
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetStdin() []byte {
+	if x != nil {
+		return x.Stdin
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// OutputChunk is a chunk of the output of a thread.
+type OutputChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputChunk) Reset() {
+	*x = OutputChunk{}
+	mi := &file_chord_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputChunk) ProtoMessage() {}
+
+func (x *OutputChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_chord_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputChunk.ProtoReflect.Descriptor instead.
+func (*OutputChunk) Descriptor() ([]byte, []int) {
+	return file_chord_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OutputChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_chord_proto protoreflect.FileDescriptor
+
+const file_chord_proto_rawDesc = "" +
+	"\n" +
+	"\vchord.proto\x12\bchord.v1\"\xd5\x01\n" +
+	"\x0eExecuteRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x03(\tR\x04path\x12\x12\n" +
+	"\x04args\x18\x02 \x03(\tR\x04args\x129\n" +
+	"\x05flags\x18\x03 \x03(\v2#.chord.v1.ExecuteRequest.FlagsEntryR\x05flags\x12\x14\n" +
+	"\x05stdin\x18\x04 \x01(\fR\x05stdin\x12\x10\n" +
+	"\x03key\x18\x05 \x01(\tR\x03key\x1a8\n" +
+	"\n" +
+	"FlagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"!\n" +
+	"\vOutputChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data2J\n" +
+	"\n" +
+	"Dispatcher\x12<\n" +
+	"\aExecute\x12\x18.chord.v1.ExecuteRequest\x1a\x15.chord.v1.OutputChunk0\x01B2Z0github.com/graphitects/chord/grpcadapter/chordpbb\x06proto3"
+
+var (
+	file_chord_proto_rawDescOnce sync.Once
+	file_chord_proto_rawDescData []byte
+)
+
+func file_chord_proto_rawDescGZIP() []byte {
+	file_chord_proto_rawDescOnce.Do(func() {
+		file_chord_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_chord_proto_rawDesc), len(file_chord_proto_rawDesc)))
+	})
+	return file_chord_proto_rawDescData
+}
+
+var file_chord_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_chord_proto_goTypes = []any{
+	(*ExecuteRequest)(nil), // 0: chord.v1.ExecuteRequest
+	(*OutputChunk)(nil),    // 1: chord.v1.OutputChunk
+	nil,                    // 2: chord.v1.ExecuteRequest.FlagsEntry
+}
+var file_chord_proto_depIdxs = []int32{
+	2, // 0: chord.v1.ExecuteRequest.flags:type_name -> chord.v1.ExecuteRequest.FlagsEntry
+	0, // 1: chord.v1.Dispatcher.Execute:input_type -> chord.v1.ExecuteRequest
+	1, // 2: chord.v1.Dispatcher.Execute:output_type -> chord.v1.OutputChunk
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_chord_proto_init() }
+func file_chord_proto_init() {
+	if File_chord_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_chord_proto_rawDesc), len(file_chord_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_chord_proto_goTypes,
+		DependencyIndexes: file_chord_proto_depIdxs,
+		MessageInfos:      file_chord_proto_msgTypes,
+	}.Build()
+	File_chord_proto = out.File
+	file_chord_proto_goTypes = nil
+	file_chord_proto_depIdxs = nil
+}
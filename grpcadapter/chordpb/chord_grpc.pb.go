@@ -0,0 +1,134 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: chord.proto
+
+package chordpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Dispatcher_Execute_FullMethodName = "/chord.v1.Dispatcher/Execute"
+)
+
+// DispatcherClient is the client API for Dispatcher service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Dispatcher executes the threads of a chord tree remotely.
+type DispatcherClient interface {
+	// Execute matches the thread registered under the path and invokes it,
+	// streaming its output back as it is flushed. The deadline of the call is
+	// propagated to the context of the thread.
+	Execute(ctx context.Context, in *ExecuteRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OutputChunk], error)
+}
+
+type dispatcherClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDispatcherClient(cc grpc.ClientConnInterface) DispatcherClient {
+	return &dispatcherClient{cc}
+}
+
+func (c *dispatcherClient) Execute(ctx context.Context, in *ExecuteRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OutputChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Dispatcher_ServiceDesc.Streams[0], Dispatcher_Execute_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExecuteRequest, OutputChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Dispatcher_ExecuteClient = grpc.ServerStreamingClient[OutputChunk]
+
+// DispatcherServer is the server API for Dispatcher service.
+// All implementations must embed UnimplementedDispatcherServer
+// for forward compatibility.
+//
+// Dispatcher executes the threads of a chord tree remotely.
+type DispatcherServer interface {
+	// Execute matches the thread registered under the path and invokes it,
+	// streaming its output back as it is flushed. The deadline of the call is
+	// propagated to the context of the thread.
+	Execute(*ExecuteRequest, grpc.ServerStreamingServer[OutputChunk]) error
+	mustEmbedUnimplementedDispatcherServer()
+}
+
+// UnimplementedDispatcherServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDispatcherServer struct{}
+
+func (UnimplementedDispatcherServer) Execute(*ExecuteRequest, grpc.ServerStreamingServer[OutputChunk]) error {
+	return status.Error(codes.Unimplemented, "method Execute not implemented")
+}
+func (UnimplementedDispatcherServer) mustEmbedUnimplementedDispatcherServer() {}
+func (UnimplementedDispatcherServer) testEmbeddedByValue()                    {}
+
+// UnsafeDispatcherServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DispatcherServer will
+// result in compilation errors.
+type UnsafeDispatcherServer interface {
+	mustEmbedUnimplementedDispatcherServer()
+}
+
+func RegisterDispatcherServer(s grpc.ServiceRegistrar, srv DispatcherServer) {
+	// If the following call panics, it indicates UnimplementedDispatcherServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Dispatcher_ServiceDesc, srv)
+}
+
+func _Dispatcher_Execute_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExecuteRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DispatcherServer).Execute(m, &grpc.GenericServerStream[ExecuteRequest, OutputChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Dispatcher_ExecuteServer = grpc.ServerStreamingServer[OutputChunk]
+
+// Dispatcher_ServiceDesc is the grpc.ServiceDesc for Dispatcher service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Dispatcher_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chord.v1.Dispatcher",
+	HandlerType: (*DispatcherServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Execute",
+			Handler:       _Dispatcher_Execute_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "chord.proto",
+}
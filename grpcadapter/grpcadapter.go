@@ -0,0 +1,206 @@
+/*
+Package grpcadapter exposes a chord tree as a gRPC service.
+
+The Dispatcher service of the chordpb package executes a thread from its path,
+arguments and flags, streaming the output of the thread back in chunks as it
+is flushed. The deadline and cancellation of the call are propagated to the
+context of the input, along with the principal authenticated by the
+Authenticator of the Server, if any.
+*/
+package grpcadapter
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative chordpb/chord.proto
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/graphitects/chord"
+	"github.com/graphitects/chord/grpcadapter/chordpb"
+)
+
+// Server implements the Dispatcher service by executing the threads of a root chord.
+type Server struct {
+	chordpb.UnimplementedDispatcherServer
+
+	// Authenticator, when set, authenticates every call before its thread is
+	// matched, with the credentials of its basic or bearer "authorization"
+	// metadata, its peer address and the state of its TLS connection. Calls
+	// failing are answered with the status code of the error, as returned by
+	// Code, usually codes.Unauthenticated.
+	Authenticator chord.Authenticator
+
+	root *chord.Chord
+}
+
+// New returns a Server executing the threads of root.
+func New(root *chord.Chord) *Server {
+	return &Server{root: root}
+}
+
+// Register registers a Server executing the threads of root on the gRPC server.
+func Register(gs grpc.ServiceRegistrar, root *chord.Chord) {
+	chordpb.RegisterDispatcherServer(gs, New(root))
+}
+
+// Execute implements chordpb.DispatcherServer. Each flush of the output of the
+// thread is sent as an OutputChunk, and the error of the thread is returned as
+// a gRPC status by Code.
+func (s *Server) Execute(req *chordpb.ExecuteRequest, stream grpc.ServerStreamingServer[chordpb.OutputChunk]) error {
+	in := &chord.Input{
+		Key:   req.GetKey(),
+		Args:  req.GetArgs(),
+		Flags: req.GetFlags(),
+	}
+	if in.Key == "" {
+		in.Key = strings.Join(req.GetPath(), " ")
+	}
+	if in.Flags == nil {
+		in.Flags = make(map[string]string)
+	}
+	ctx := chord.WithExternal(stream.Context())
+	if s.Authenticator != nil {
+		p, err := chord.Authenticate(ctx, s.Authenticator, callCredentials(ctx))
+		if err != nil {
+			return status.Error(Code(err), err.Error())
+		}
+		ctx = chord.WithPrincipal(ctx, p)
+	}
+	in = in.WithContext(ctx)
+
+	bw := bufio.NewWriter(&chunkWriter{stream: stream})
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(req.GetStdin())), bw)}
+	err := s.root.Execute(req.GetPath(), in, out)
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		return status.Error(Code(err), err.Error())
+	}
+	return nil
+}
+
+// Code returns the gRPC status code reporting an error returned by a thread:
+// ResourceExhausted for errors matching chord.ErrRateLimited, and Unavailable
+// for errors matching chord.ErrCircuitOpen, chord.ErrDisabled or
+// chord.ErrShutdown, besides the codes matching the errors of the chord and of
+// the contexts.
+func Code(err error) codes.Code {
+	var fe *chord.FlagError
+	var ae *chord.ArgError
+	switch {
+	case errors.Is(err, chord.ErrNotFound):
+		return codes.NotFound
+	case errors.As(err, &fe), errors.As(err, &ae), errors.Is(err, chord.ErrTooDeep):
+		return codes.InvalidArgument
+	case errors.Is(err, chord.ErrUnauthenticated):
+		return codes.Unauthenticated
+	case errors.Is(err, chord.ErrForbidden):
+		return codes.PermissionDenied
+	case errors.Is(err, chord.ErrRateLimited):
+		return codes.ResourceExhausted
+	case errors.Is(err, chord.ErrCircuitOpen), errors.Is(err, chord.ErrDisabled), errors.Is(err, chord.ErrShutdown):
+		return codes.Unavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	}
+	if s, ok := status.FromError(err); ok {
+		return s.Code()
+	}
+	return codes.Unknown
+}
+
+// callCredentials returns the credentials presented by the call of the context.
+func callCredentials(ctx context.Context) *chord.Credentials {
+	cred := &chord.Credentials{}
+	if p, ok := peer.FromContext(ctx); ok {
+		cred.RemoteAddr = p.Addr
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			cred.TLS = &info.State
+		}
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		scheme, value, ok := strings.Cut(v, " ")
+		switch {
+		case !ok:
+			continue
+		case strings.EqualFold(scheme, "Basic"):
+			if b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value)); err == nil {
+				cred.User, cred.Password, _ = strings.Cut(string(b), ":")
+			}
+		case strings.EqualFold(scheme, "Bearer"):
+			cred.Token = strings.TrimSpace(value)
+		}
+		break
+	}
+	return cred
+}
+
+// chunkWriter is an io.Writer sending every write as an OutputChunk.
+type chunkWriter struct {
+	stream grpc.ServerStreamingServer[chordpb.OutputChunk]
+}
+
+// Write implements io.Writer.
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := w.stream.Send(&chordpb.OutputChunk{Data: bytes.Clone(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// RemoteThread returns a thread forwarding its input to the thread of the path
+// on the Dispatcher service of the connection, and streaming the remote output
+// to its own. The key, arguments and flags of the input are forwarded, and its
+// context bounds the call. Errors of the remote thread are returned as gRPC
+// status errors.
+func RemoteThread(cc grpc.ClientConnInterface, path ...string) chord.Thread {
+	client := chordpb.NewDispatcherClient(cc)
+	return func(in *chord.Input, out *chord.Output) error {
+		stream, err := client.Execute(in.Context(), &chordpb.ExecuteRequest{
+			Path:  path,
+			Args:  in.Args,
+			Flags: in.Flags,
+			Key:   in.Key,
+		})
+		if err != nil {
+			return err
+		}
+		for {
+			chunk, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if out == nil || out.Writer == nil {
+				continue
+			}
+			if _, err := out.Write(chunk.GetData()); err != nil {
+				return err
+			}
+			if err := out.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
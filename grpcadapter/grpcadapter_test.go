@@ -0,0 +1,114 @@
+package grpcadapter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/graphitects/chord"
+	"github.com/graphitects/chord/grpcadapter/chordpb"
+)
+
+// dial serves s on an in-memory listener and returns a connection to it.
+func dial(t *testing.T, s *Server) *grpc.ClientConn {
+	t.Helper()
+	l := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	chordpb.RegisterDispatcherServer(gs, s)
+	go gs.Serve(l)
+	t.Cleanup(gs.Stop)
+	cc, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return cc
+}
+
+// call executes the thread of the path through the remote thread of cc.
+func call(ctx context.Context, cc *grpc.ClientConn, path ...string) (string, error) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bw)}
+	err := RemoteThread(cc, path...)((&chord.Input{}).WithContext(ctx), out)
+	bw.Flush()
+	return buf.String(), err
+}
+
+func TestExecute(t *testing.T) {
+	root := chord.NewChord()
+	root.Register("hello", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString("hello")
+		return out.Flush()
+	})
+	cc := dial(t, New(root))
+	if got, err := call(context.Background(), cc, "hello"); err != nil || got != "hello" {
+		t.Fatalf("output = %q, %v, want %q", got, err, "hello")
+	}
+	if _, err := call(context.Background(), cc, "missing"); status.Code(err) != codes.NotFound {
+		t.Fatalf("error = %v, want NotFound", err)
+	}
+}
+
+func TestAuthenticator(t *testing.T) {
+	root := chord.NewChord()
+	root.Register("whoami", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString(chord.PrincipalOf(in).Name)
+		return out.Flush()
+	})
+	s := New(root)
+	s.Authenticator = chord.AuthenticatorFunc(func(ctx context.Context, cred *chord.Credentials) (*chord.Principal, error) {
+		if cred.User != "bob" || cred.Password != "secret" {
+			return nil, errors.New("invalid password")
+		}
+		return &chord.Principal{Name: cred.User}, nil
+	})
+	cc := dial(t, s)
+
+	if _, err := call(context.Background(), cc, "whoami"); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("error without credentials = %v, want Unauthenticated", err)
+	}
+	// "Ym9iOnNlY3JldA==" is "bob:secret" in base64.
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic Ym9iOnNlY3JldA==")
+	if got, err := call(ctx, cc, "whoami"); err != nil || got != "bob" {
+		t.Fatalf("output = %q, %v, want %q", got, err, "bob")
+	}
+}
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{&chord.NotFoundError{}, codes.NotFound},
+		{&chord.ArgError{}, codes.InvalidArgument},
+		{chord.ErrUnauthenticated, codes.Unauthenticated},
+		{chord.ErrForbidden, codes.PermissionDenied},
+		{chord.ErrRateLimited, codes.ResourceExhausted},
+		{chord.ErrCircuitOpen, codes.Unavailable},
+		{&chord.DisabledError{}, codes.Unavailable},
+		{chord.ErrShutdown, codes.Unavailable},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{context.Canceled, codes.Canceled},
+		{status.Error(codes.Unavailable, "down"), codes.Unavailable},
+		{errors.New("other"), codes.Unknown},
+	}
+	for _, tt := range tests {
+		if got := Code(tt.err); got != tt.want {
+			t.Errorf("Code(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package chord
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// HelpThread returns a thread writing help about the threads and chords of the
+// root chord to the Output, using their metadata. Hidden and internal threads
+// and chords are omitted. It is meant to be registered on the root itself:
+//
+//	root.Register("help", chord.HelpThread(root))
+//
+// The arguments of the input select the chord or thread to describe: a chord
+// is described by a listing of the threads and chords of its subtree, while a
+// thread is described by its description, usage and examples.
+func HelpThread(root *Chord) Thread {
+	return func(in *Input, out *Output) error {
+		node, meta := root, Meta{}
+		for i, key := range in.Args {
+			key = node.unalias(key)
+			m, ok := node.fetchMount(key)
+			if ok {
+				node, meta = m.chord, m.meta
+				continue
+			}
+			if e, ok := node.fetchEntry(key); ok && i == len(in.Args)-1 {
+				writeThreadHelp(out, in.Args, e.meta)
+				return out.Flush()
+			}
+			return root.notFoundError(in.Args)
+		}
+		writeChordHelp(out, node, meta)
+		return out.Flush()
+	}
+}
+
+// writeThreadHelp writes the help of the thread registered under the path.
+func writeThreadHelp(w io.Writer, path []string, meta Meta) {
+	fmt.Fprintln(w, strings.Join(path, " "))
+	if meta.Description != "" {
+		fmt.Fprintf(w, "\n  %s\n", meta.Description)
+	}
+	if meta.Deprecated != "" {
+		fmt.Fprintf(w, "\nDeprecated: %s\n", meta.Deprecated)
+	}
+	if meta.Usage != "" {
+		fmt.Fprintf(w, "\nUsage:\n  %s\n", meta.Usage)
+	}
+	if len(meta.Flags) > 0 {
+		fmt.Fprintln(w, "\nFlags:")
+		tw := tabwriter.NewWriter(w, 0, 4, 4, ' ', 0)
+		for _, f := range meta.Flags {
+			fmt.Fprintf(tw, "  --%s %s\t%s\n", f.Name, f.Kind, f.Usage)
+		}
+		tw.Flush()
+	}
+	if len(meta.Examples) > 0 {
+		fmt.Fprintln(w, "\nExamples:")
+		for _, example := range meta.Examples {
+			fmt.Fprintf(w, "  %s\n", example)
+		}
+	}
+}
+
+// writeChordHelp writes the listing of the threads and chords of the subtree of node.
+func writeChordHelp(w io.Writer, node *Chord, meta Meta) {
+	if meta.Description != "" {
+		fmt.Fprintf(w, "%s\n\n", meta.Description)
+	}
+	if meta.Usage != "" {
+		fmt.Fprintf(w, "Usage:\n  %s\n\n", meta.Usage)
+	}
+	var threads, chords [][2]string
+	var walk func(node *Chord, path []string)
+	walk = func(node *Chord, path []string) {
+		node.ensureLoaded()
+		for _, key := range sortedKeys(&node.threads) {
+			if e, ok := node.fetchEntry(key); ok && !e.meta.Hidden && !e.meta.Internal {
+				name := strings.Join(append(path, key), " ")
+				threads = append(threads, [2]string{withAliases(name, node.FetchAliases(key)), listedDescription(e.meta)})
+			}
+		}
+		for _, key := range sortedKeys(&node.chords) {
+			if m, ok := node.fetchMount(key); ok && !m.meta.Hidden && !m.meta.Internal {
+				sub := append(path[:len(path):len(path)], key)
+				chords = append(chords, [2]string{withAliases(strings.Join(sub, " "), node.FetchAliases(key)), listedDescription(m.meta)})
+				walk(m.chord, sub)
+			}
+		}
+	}
+	walk(node, nil)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 4, ' ', 0)
+	if len(threads) > 0 {
+		fmt.Fprintln(tw, "Threads:")
+		for _, t := range threads {
+			fmt.Fprintf(tw, "  %s\t%s\n", t[0], t[1])
+		}
+	}
+	if len(chords) > 0 {
+		if len(threads) > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, "Chords:")
+		for _, c := range chords {
+			fmt.Fprintf(tw, "  %s\t%s\n", c[0], c[1])
+		}
+	}
+	tw.Flush()
+}
+
+// listedDescription returns the description of a listed thread or chord,
+// followed by its deprecation notice if deprecated.
+func listedDescription(meta Meta) string {
+	if meta.Deprecated == "" {
+		return meta.Description
+	}
+	return strings.TrimSpace(meta.Description + " (deprecated: " + meta.Deprecated + ")")
+}
+
+// withAliases returns the name of a listed thread or chord followed by its
+// aliases, such as "files remove, rm".
+func withAliases(name string, aliases []string) string {
+	if len(aliases) == 0 {
+		return name
+	}
+	return name + ", " + strings.Join(aliases, ", ")
+}
@@ -0,0 +1,147 @@
+/*
+Package httpadapter serves a chord tree over HTTP.
+
+The segments of the URL path are mapped to the path of the thread, the query
+parameters to the flags of its input, the request body to the reader side of
+its output and the response writer to the writer side of its output, so that
+the same tree can serve both command-line and HTTP callers.
+*/
+package httpadapter
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/graphitects/chord"
+)
+
+// Handler is an http.Handler dispatching requests through a root chord.
+type Handler struct {
+	// Prefix is stripped from the URL path before it is mapped to a chord path,
+	// such as "/api" when the handler is mounted under that pattern.
+	Prefix string
+	// Authenticator, when set, authenticates every request before its thread
+	// is matched, with the credentials of its basic or bearer Authorization
+	// header and of its TLS connection. Requests failing are answered with the
+	// status of the error, usually 401, and its status text rather than the
+	// error, which may tell why the credentials were rejected.
+	Authenticator chord.Authenticator
+
+	root *chord.Chord
+}
+
+// New returns a Handler dispatching requests through root.
+func New(root *chord.Chord) *Handler {
+	return &Handler{root: root}
+}
+
+// ServeHTTP implements http.Handler. A query parameter given several times is
+// mapped to a comma-separated flag, as read by StringSlice flags. The
+// chord.CorrelationIDHeader header is mapped to the chord.CorrelationIDFlag
+// flag. The context of the input is the context of the request, holding the
+// principal authenticated by the Authenticator.
+//
+// When the thread fails before writing anything, the error is written with
+// a status reporting it, as returned by Status. Output buffered but not
+// flushed by a failing thread is discarded.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := Path(strings.TrimPrefix(r.URL.Path, h.Prefix))
+	in := &chord.Input{
+		Key:   strings.Join(path, "/"),
+		Flags: make(map[string]string),
+	}
+	for name, values := range r.URL.Query() {
+		in.Flags[name] = strings.Join(values, ",")
+	}
+	if id := r.Header.Get(chord.CorrelationIDHeader); id != "" && in.Flags[chord.CorrelationIDFlag] == "" {
+		in.Flags[chord.CorrelationIDFlag] = id
+	}
+	ctx := chord.WithExternal(r.Context())
+	if h.Authenticator != nil {
+		p, err := chord.Authenticate(ctx, h.Authenticator, Credentials(r))
+		if err != nil {
+			status := Status(err)
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		ctx = chord.WithPrincipal(ctx, p)
+	}
+	in = in.WithContext(ctx)
+
+	rw := &responseWriter{ResponseWriter: w}
+	bw := bufio.NewWriter(rw)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(r.Body), bw)}
+	err := h.root.Execute(path, in, out)
+	if err != nil && !rw.wrote {
+		bw.Reset(rw)
+		http.Error(w, err.Error(), Status(err))
+		return
+	}
+	bw.Flush()
+}
+
+// Path splits a URL path into the keys of a chord path, ignoring empty segments.
+func Path(urlPath string) []string {
+	return strings.FieldsFunc(urlPath, func(r rune) bool { return r == '/' })
+}
+
+// Status returns the HTTP status reporting an error returned by a thread: 404
+// when no thread matches the path, 400 for invalid flags or arguments, paths
+// exceeding chord.SetMaxDepth and ambiguous abbreviations, 401 for errors
+// matching chord.ErrUnauthenticated, 403 for errors matching
+// chord.ErrForbidden, 429 for errors matching chord.ErrRateLimited, 503 for
+// errors matching chord.ErrCircuitOpen, chord.ErrDisabled or
+// chord.ErrShutdown, 504 for a *chord.TimeoutError, and 500 otherwise.
+func Status(err error) int {
+	var fe *chord.FlagError
+	var ae *chord.ArgError
+	var te *chord.TimeoutError
+	switch {
+	case errors.Is(err, chord.ErrNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &fe), errors.As(err, &ae), errors.Is(err, chord.ErrTooDeep), errors.Is(err, chord.ErrAmbiguous):
+		return http.StatusBadRequest
+	case errors.Is(err, chord.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, chord.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, chord.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, chord.ErrCircuitOpen), errors.Is(err, chord.ErrDisabled), errors.Is(err, chord.ErrShutdown):
+		return http.StatusServiceUnavailable
+	case errors.As(err, &te):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// Credentials returns the credentials presented by the request: its remote
+// address, the state of its TLS connection, and the user and password or the
+// token of its basic or bearer Authorization header.
+func Credentials(r *http.Request) *chord.Credentials {
+	cred := &chord.Credentials{TLS: r.TLS}
+	if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		cred.RemoteAddr = addr
+	}
+	if user, password, ok := r.BasicAuth(); ok {
+		cred.User, cred.Password = user, password
+	} else if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		cred.Token = strings.TrimSpace(token)
+	}
+	return cred
+}
+
+// responseWriter records whether anything was written to the response.
+type responseWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+// Write implements io.Writer.
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(p)
+}
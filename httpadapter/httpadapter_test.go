@@ -0,0 +1,88 @@
+package httpadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+func TestServeHTTP(t *testing.T) {
+	root := chord.NewChord()
+	root.RegisterPattern("users/:id", func(in *chord.Input, out *chord.Output) error {
+		fmt.Fprintf(out, "%s %s", in.Params["id"], in.Flags["v"])
+		return out.Flush()
+	})
+	h := New(root)
+	h.Prefix = "/api"
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/users/42?v=a&v=b", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "42 a,b" {
+		t.Fatalf("response = %d %q, want 200 %q", w.Code, w.Body.String(), "42 a,b")
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", w.Code)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{&chord.NotFoundError{Path: []string{"x"}}, http.StatusNotFound},
+		{&chord.FlagError{}, http.StatusBadRequest},
+		{chord.ErrTooDeep, http.StatusBadRequest},
+		{&chord.AmbiguousError{Key: "st"}, http.StatusBadRequest},
+		{chord.ErrUnauthenticated, http.StatusUnauthorized},
+		{chord.ErrForbidden, http.StatusForbidden},
+		{chord.ErrRateLimited, http.StatusTooManyRequests},
+		{chord.ErrCircuitOpen, http.StatusServiceUnavailable},
+		{&chord.DisabledError{}, http.StatusServiceUnavailable},
+		{chord.ErrShutdown, http.StatusServiceUnavailable},
+		{&chord.TimeoutError{}, http.StatusGatewayTimeout},
+		{fmt.Errorf("wrapped: %w", chord.ErrRateLimited), http.StatusTooManyRequests},
+		{errors.New("other"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := Status(tt.err); got != tt.want {
+			t.Errorf("Status(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestAuthenticator(t *testing.T) {
+	root := chord.NewChord()
+	root.Register("whoami", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString(chord.PrincipalOf(in).Name)
+		return out.Flush()
+	})
+	h := New(root)
+	h.Authenticator = chord.AuthenticatorFunc(func(ctx context.Context, cred *chord.Credentials) (*chord.Principal, error) {
+		if cred.Token != "secret" {
+			return nil, errors.New("invalid token")
+		}
+		return &chord.Principal{Name: "bob"}, nil
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/whoami", nil))
+	if w.Code != http.StatusUnauthorized || strings.Contains(w.Body.String(), "invalid token") {
+		t.Fatalf("response without credentials = %d %q, want 401 without the error", w.Code, w.Body.String())
+	}
+	r := httptest.NewRequest("GET", "/whoami", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != "bob" {
+		t.Fatalf("response = %d %q, want 200 %q", w.Code, w.Body.String(), "bob")
+	}
+}
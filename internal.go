@@ -0,0 +1,32 @@
+package chord
+
+import "context"
+
+// externalKey is the context key marking the executions dispatched from
+// outside the program.
+type externalKey struct{}
+
+// WithExternal returns a copy of ctx marking the executions of the inputs it is
+// the context of as dispatched from outside the program, as the adapters, Run
+// and the Serve server do. Internal threads aren't matched by such executions.
+func WithExternal(ctx context.Context) context.Context {
+	return context.WithValue(ctx, externalKey{}, true)
+}
+
+// IsExternal reports whether ctx was marked by WithExternal.
+func IsExternal(ctx context.Context) bool {
+	external, _ := ctx.Value(externalKey{}).(bool)
+	return external
+}
+
+// internalThread wraps the thread matched for the path through an internal
+// thread or chord, so that it fails with a *NotFoundError when executed from
+// outside the program.
+func internalThread(thread Thread, path []string) Thread {
+	return func(in *Input, out *Output) error {
+		if IsExternal(in.Context()) {
+			return &NotFoundError{Path: path}
+		}
+		return thread(in, out)
+	}
+}
@@ -0,0 +1,306 @@
+/*
+Package jsonrpcadapter serves a chord tree as a JSON-RPC 2.0 server.
+
+The method name of a request is mapped to the path of the thread by splitting
+it on dots, so that "system.status" executes the thread registered under
+["system", "status"]. Positional params are passed as the arguments of the
+input and named params as its flags. The output of the thread is returned as
+the result of the request, as a string. Batch requests and notifications are
+supported, up to a maximum size, with bounded concurrency.
+*/
+package jsonrpcadapter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/graphitects/chord"
+)
+
+// Error codes defined by the JSON-RPC 2.0 specification, and the code of the
+// errors returned by threads.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeThreadError    = -32000
+)
+
+// Default limits of a Server.
+const (
+	DefaultMaxBodySize  = 1 << 20 // Maximum size of the body of a request, in bytes.
+	DefaultMaxBatchSize = 100     // Maximum number of requests of a batch.
+)
+
+// Error is the error object of a JSON-RPC response.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// request is a JSON-RPC request object.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// response is a JSON-RPC response object.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  *string         `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// Server is a JSON-RPC 2.0 server executing the threads of a root chord.
+type Server struct {
+	// MaxBodySize, when positive, is the maximum size of the body of an HTTP
+	// request, in bytes, larger bodies being rejected with the 413 status. It
+	// defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+	// MaxBatchSize, when positive, is the maximum number of requests of a
+	// batch, larger batches being rejected with CodeInvalidRequest. It defaults
+	// to DefaultMaxBatchSize.
+	MaxBatchSize int
+	// BatchConcurrency is the maximum number of requests of a batch executed
+	// simultaneously. It defaults to GOMAXPROCS.
+	BatchConcurrency int
+
+	root *chord.Chord
+}
+
+// New returns a Server executing the threads of root.
+func New(root *chord.Chord) *Server {
+	return &Server{root: root}
+}
+
+// ServeHTTP implements http.Handler, handling the body of POST requests as a
+// JSON-RPC message. Messages made only of notifications get an empty response
+// with the 204 status, and bodies larger than MaxBodySize the 413 status.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := s.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+	msg, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		status := http.StatusBadRequest
+		var me *http.MaxBytesError
+		if errors.As(err, &me) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	res := s.Handle(r.Context(), msg)
+	if res == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(res)
+}
+
+// Handle handles a JSON-RPC message, either a single request or a batch, and
+// returns the response message, or nil when no response is due. The requests
+// of a batch are executed concurrently, up to BatchConcurrency at a time; their
+// responses keep their order. Batches larger than MaxBatchSize are rejected.
+func (s *Server) Handle(ctx context.Context, msg []byte) []byte {
+	msg = bytes.TrimSpace(msg)
+	if len(msg) > 0 && msg[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(msg, &batch); err != nil {
+			return marshal(errorResponse(nil, CodeParseError, err.Error()))
+		}
+		if len(batch) == 0 {
+			return marshal(errorResponse(nil, CodeInvalidRequest, "empty batch"))
+		}
+		limit := s.MaxBatchSize
+		if limit <= 0 {
+			limit = DefaultMaxBatchSize
+		}
+		if len(batch) > limit {
+			return marshal(errorResponse(nil, CodeInvalidRequest, "batch too large"))
+		}
+		responses := make([]*response, len(batch))
+		n := s.BatchConcurrency
+		if n <= 0 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		var next atomic.Int64
+		var wg sync.WaitGroup
+		for range min(n, len(batch)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					i := int(next.Add(1) - 1)
+					if i >= len(batch) {
+						return
+					}
+					responses[i] = s.handle(ctx, batch[i])
+				}
+			}()
+		}
+		wg.Wait()
+		var out []*response
+		for _, res := range responses {
+			if res != nil {
+				out = append(out, res)
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return marshal(out)
+	}
+	if res := s.handle(ctx, msg); res != nil {
+		return marshal(res)
+	}
+	return nil
+}
+
+// handle handles a single request, returning nil for notifications.
+func (s *Server) handle(ctx context.Context, raw json.RawMessage) *response {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		var se *json.SyntaxError
+		if errors.As(err, &se) {
+			return errorResponse(nil, CodeParseError, err.Error())
+		}
+		return errorResponse(nil, CodeInvalidRequest, err.Error())
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, CodeInvalidRequest, "invalid request")
+	}
+	in, err := input(req)
+	if err != nil {
+		return errorResponse(req.ID, CodeInvalidParams, err.Error())
+	}
+
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bw)}
+	err = s.root.Execute(strings.Split(req.Method, "."), in.WithContext(chord.WithExternal(ctx)), out)
+	bw.Flush()
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		res := errorResponse(req.ID, Code(err), err.Error())
+		if buf.Len() > 0 {
+			res.Error.Data = buf.String()
+		}
+		return res
+	}
+	result := buf.String()
+	return &response{JSONRPC: "2.0", Result: &result, ID: req.ID}
+}
+
+// input builds the input of the thread from the params of the request.
+func input(req request) (*chord.Input, error) {
+	in := &chord.Input{Key: req.Method, Flags: make(map[string]string)}
+	params := bytes.TrimSpace(req.Params)
+	switch {
+	case len(params) == 0 || bytes.Equal(params, []byte("null")):
+	case params[0] == '[':
+		var args []json.RawMessage
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, err
+		}
+		for _, arg := range args {
+			in.Args = append(in.Args, stringify(arg))
+		}
+	case params[0] == '{':
+		var flags map[string]json.RawMessage
+		if err := json.Unmarshal(params, &flags); err != nil {
+			return nil, err
+		}
+		for name, value := range flags {
+			in.Flags[name] = stringify(value)
+		}
+	default:
+		return nil, errors.New("params must be an array or an object")
+	}
+	return in, nil
+}
+
+// stringify returns the string form of a JSON value: strings are unquoted,
+// arrays are joined by commas, null is empty and other values are kept as is.
+func stringify(v json.RawMessage) string {
+	var s string
+	if json.Unmarshal(v, &s) == nil {
+		return s
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(v, &list) == nil {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = stringify(item)
+		}
+		return strings.Join(items, ",")
+	}
+	if bytes.Equal(v, []byte("null")) {
+		return ""
+	}
+	return string(v)
+}
+
+// Code returns the JSON-RPC error code reporting an error returned by a thread.
+func Code(err error) int {
+	var fe *chord.FlagError
+	var ae *chord.ArgError
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e.Code
+	case errors.Is(err, chord.ErrNotFound):
+		return CodeMethodNotFound
+	case errors.Is(err, chord.ErrTooDeep):
+		return CodeInvalidRequest
+	case errors.As(err, &fe), errors.As(err, &ae):
+		return CodeInvalidParams
+	}
+	return CodeThreadError
+}
+
+// errorResponse returns a response reporting an error.
+func errorResponse(id json.RawMessage, code int, message string) *response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &response{JSONRPC: "2.0", Error: &Error{Code: code, Message: message}, ID: id}
+}
+
+// marshal encodes a response message.
+func marshal(v any) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(errorResponse(nil, CodeInternalError, err.Error()))
+	}
+	return b
+}
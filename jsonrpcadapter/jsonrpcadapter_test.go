@@ -0,0 +1,85 @@
+package jsonrpcadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+// newServer returns a Server of a tree echoing the arguments and flags of
+// "echo" and failing "fail".
+func newServer() *Server {
+	root, system := chord.NewChord(), chord.NewChord()
+	system.Register("echo", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString(strings.Join(in.Args, " ") + in.Flags["name"])
+		return out.Flush()
+	})
+	system.Register("fail", func(in *chord.Input, out *chord.Output) error {
+		return &Error{Code: 42, Message: "failed"}
+	})
+	root.Mount("system", system)
+	return New(root)
+}
+
+func TestHandle(t *testing.T) {
+	s := newServer()
+	tests := []struct {
+		msg, want string
+	}{
+		{`{"jsonrpc":"2.0","method":"system.echo","params":["a",1],"id":1}`, `{"jsonrpc":"2.0","result":"a 1","id":1}`},
+		{`{"jsonrpc":"2.0","method":"system.echo","params":{"name":"bob"},"id":"x"}`, `{"jsonrpc":"2.0","result":"bob","id":"x"}`},
+		{`{"jsonrpc":"2.0","method":"system.fail","id":1}`, `{"jsonrpc":"2.0","error":{"code":42,"message":"failed"},"id":1}`},
+		{`{"jsonrpc":"2.0","method":"missing","id":1}`, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"chord: no thread found for path \"missing\""},"id":1}`},
+		{`{"method":"system.echo","id":1}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":1}`},
+		{`[]`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"empty batch"},"id":null}`},
+		{`[{"jsonrpc":"2.0","method":"system.echo","params":["a"],"id":1},{"jsonrpc":"2.0","method":"system.echo"},{"jsonrpc":"2.0","method":"system.echo","params":["b"],"id":2}]`,
+			`[{"jsonrpc":"2.0","result":"a","id":1},{"jsonrpc":"2.0","result":"b","id":2}]`},
+	}
+	for _, tt := range tests {
+		if got := string(s.Handle(context.Background(), []byte(tt.msg))); got != tt.want {
+			t.Errorf("Handle(%s) = %s, want %s", tt.msg, got, tt.want)
+		}
+	}
+	if got := s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","method":"system.echo"}`)); got != nil {
+		t.Errorf("Handle of a notification = %s, want nil", got)
+	}
+}
+
+func TestBatchLimits(t *testing.T) {
+	s := newServer()
+	s.MaxBatchSize = 2
+	s.BatchConcurrency = 1
+	req := `{"jsonrpc":"2.0","method":"system.echo","params":["a"],"id":1}`
+	if got := string(s.Handle(context.Background(), []byte("["+req+","+req+"]"))); !strings.Contains(got, `"result"`) {
+		t.Fatalf("Handle of a batch within the limit = %s", got)
+	}
+	got := string(s.Handle(context.Background(), []byte("["+req+","+req+","+req+"]")))
+	if !strings.Contains(got, `"code":-32600`) {
+		t.Fatalf("Handle of a batch beyond the limit = %s, want an invalid request", got)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	s := newServer()
+	s.MaxBodySize = 100
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(`{"jsonrpc":"2.0","method":"system.echo"}`)))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status of a notification = %d, want 204", w.Code)
+	}
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status of a GET = %d, want 405", w.Code)
+	}
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat(" ", 101))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status of a large body = %d, want 413", w.Code)
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Package kafkaadapter bridges Kafka topics to a chord tree.
+
+A Bridge consumes the records of a reader and dispatches them through a root
+chord. The path of the thread is read from the PathHeader header of a record,
+or from its key when the header is missing, split on slashes, so that a record
+with the "orders/ship" key executes the thread registered under
+["orders", "ship"]. The value of the record is parsed as a command line with
+chord.ParseLine, its tokens becoming the arguments and flags of the input, and
+is also available, raw, to the reader side of the output.
+
+The output of a thread is written to the output writer of the bridge, and the
+records whose thread failed are written, along with their error, to the
+dead-letter writer. Offsets are committed in order once the records are
+handled, so that no record is skipped when the consumer restarts.
+*/
+package kafkaadapter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/graphitects/chord"
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers of the records read and written by a bridge.
+const (
+	PathHeader  = "chord-path"  // Path of the thread, such as "orders/ship".
+	ErrorHeader = "chord-error" // Error of the thread, on dead-letter records.
+)
+
+// Bridge consumes records and dispatches them through a root chord.
+type Bridge struct {
+	// Concurrency is the number of records handled concurrently. It defaults to 1,
+	// handling the records in order.
+	Concurrency int
+	// Output, when set, receives the outputs of the threads, with the key and
+	// path of the records they were dispatched from.
+	Output *kafka.Writer
+	// DeadLetter, when set, receives the records whose thread failed, with
+	// their error in the ErrorHeader header.
+	DeadLetter *kafka.Writer
+	// OnError, when set, is called with the errors of the threads which aren't
+	// written to a dead-letter topic, and with the errors of the writers.
+	OnError func(error)
+
+	reader *kafka.Reader
+	root   *chord.Chord
+}
+
+// New returns a Bridge dispatching the records of the reader through root.
+// Offsets are only committed if the reader is part of a consumer group.
+func New(reader *kafka.Reader, root *chord.Chord) *Bridge {
+	return &Bridge{Concurrency: 1, reader: reader, root: root}
+}
+
+// Run consumes and dispatches the records until the context is done or the
+// reader fails, and waits for the records being handled before returning.
+func (b *Bridge) Run(ctx context.Context) error {
+	n := max(b.Concurrency, 1)
+	records := make(chan kafka.Message, n)
+	t := &tracker{pending: make(map[partition][]*pending)}
+	commit := b.reader.Config().GroupID != ""
+
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range records {
+				b.Handle(ctx, msg)
+				if last, ok := t.done(msg); ok && commit {
+					if err := b.reader.CommitMessages(context.WithoutCancel(ctx), last); err != nil {
+						b.report(err)
+					}
+				}
+			}
+		}()
+	}
+
+	var err error
+	for {
+		var msg kafka.Message
+		msg, err = b.reader.FetchMessage(ctx)
+		if err != nil {
+			break
+		}
+		t.add(msg)
+		records <- msg
+	}
+	close(records)
+	wg.Wait()
+	return err
+}
+
+// Handle dispatches a record through the root chord and writes the output of
+// the thread, or the record to the dead-letter topic if it failed.
+func (b *Bridge) Handle(ctx context.Context, msg kafka.Message) {
+	path := recordPath(msg)
+	out, err := b.dispatch(ctx, path, msg.Value)
+	if err != nil {
+		if b.DeadLetter == nil {
+			b.report(err)
+			return
+		}
+		dead := kafka.Message{
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: append(slices.Clone(msg.Headers), kafka.Header{Key: ErrorHeader, Value: []byte(err.Error())}),
+		}
+		if err := b.DeadLetter.WriteMessages(ctx, dead); err != nil {
+			b.report(err)
+		}
+		return
+	}
+	if b.Output == nil {
+		return
+	}
+	res := kafka.Message{
+		Key:     msg.Key,
+		Value:   out,
+		Headers: []kafka.Header{{Key: PathHeader, Value: []byte(strings.Join(path, "/"))}},
+	}
+	if err := b.Output.WriteMessages(ctx, res); err != nil {
+		b.report(err)
+	}
+}
+
+// recordPath returns the path of the thread of a record.
+func recordPath(msg kafka.Message) []string {
+	raw := string(msg.Key)
+	for _, h := range msg.Headers {
+		if h.Key == PathHeader {
+			raw = string(h.Value)
+			break
+		}
+	}
+	return strings.FieldsFunc(raw, func(r rune) bool { return r == '/' })
+}
+
+// dispatch executes the thread of the path with the value and returns its output.
+func (b *Bridge) dispatch(ctx context.Context, path []string, value []byte) ([]byte, error) {
+	if len(path) == 0 {
+		return nil, errors.New("kafkaadapter: record without path")
+	}
+	args, in, err := chord.ParseLine(string(value))
+	if err != nil {
+		return nil, err
+	}
+	thread, ok := chord.Match(b.root, path)
+	if !ok {
+		return nil, &chord.NotFoundError{Path: path, Suggestions: b.root.Suggest(path)}
+	}
+	in.Key = strings.Join(path, " ")
+	in.Args = slices.Concat(args, in.Args)
+
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(value)), bw)}
+	err = thread(in.WithContext(chord.WithExternal(ctx)), out)
+	bw.Flush()
+	return buf.Bytes(), err
+}
+
+// report reports the error to OnError, if set.
+func (b *Bridge) report(err error) {
+	if b.OnError != nil {
+		b.OnError(err)
+	}
+}
+
+// tracker tracks the records being handled, per partition in fetch order, so
+// that the offset of a record is only committed once all the records before
+// it are handled.
+type tracker struct {
+	mu      sync.Mutex
+	pending map[partition][]*pending
+}
+
+// partition identifies a partition of a topic.
+type partition struct {
+	topic string
+	id    int
+}
+
+// pending is a record being handled.
+type pending struct {
+	msg  kafka.Message
+	done bool
+}
+
+// add tracks a fetched record.
+func (t *tracker) add(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := partition{msg.Topic, msg.Partition}
+	t.pending[key] = append(t.pending[key], &pending{msg: msg})
+}
+
+// done marks a record as handled, and returns the last record of its
+// partition whose offset can be committed, if any.
+func (t *tracker) done(msg kafka.Message) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := partition{msg.Topic, msg.Partition}
+	queue := t.pending[key]
+	for _, p := range queue {
+		if p.msg.Offset == msg.Offset {
+			p.done = true
+			break
+		}
+	}
+	var last kafka.Message
+	n := 0
+	for n < len(queue) && queue[n].done {
+		last = queue[n].msg
+		n++
+	}
+	t.pending[key] = queue[n:]
+	return last, n > 0
+}
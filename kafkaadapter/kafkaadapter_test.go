@@ -0,0 +1,68 @@
+package kafkaadapter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/graphitects/chord"
+)
+
+func TestRecordPath(t *testing.T) {
+	tests := []struct {
+		msg  kafka.Message
+		want string
+	}{
+		{kafka.Message{Key: []byte("orders/ship")}, "orders ship"},
+		{kafka.Message{Key: []byte("42"), Headers: []kafka.Header{{Key: PathHeader, Value: []byte("/orders//cancel")}}}, "orders cancel"},
+		{kafka.Message{}, ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(recordPath(tt.msg), " "); got != tt.want {
+			t.Errorf("recordPath(%q) = %q, want %q", tt.msg.Key, got, tt.want)
+		}
+	}
+}
+
+func TestHandle(t *testing.T) {
+	root, orders := chord.NewChord(), chord.NewChord()
+	orders.Register("ship", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString("shipping " + strings.Join(in.Args, " ") + " by " + in.Flags["carrier"])
+		return out.Flush()
+	})
+	root.Mount("orders", orders)
+	b := New(nil, root)
+	var errs []error
+	b.OnError = func(err error) { errs = append(errs, err) }
+
+	out, err := b.dispatch(context.Background(), []string{"orders", "ship"}, []byte("42 --carrier=ups"))
+	if err != nil || string(out) != "shipping 42 by ups" {
+		t.Fatalf("output = %q, %v, want %q", out, err, "shipping 42 by ups")
+	}
+	b.Handle(context.Background(), kafka.Message{Key: []byte("orders/ship"), Value: []byte("42")})
+	b.Handle(context.Background(), kafka.Message{Key: []byte("orders/missing")})
+	b.Handle(context.Background(), kafka.Message{})
+	if len(errs) != 2 || chord.ExitCode(errs[0]) != chord.ExitUsage || !strings.Contains(errs[1].Error(), "without path") {
+		t.Fatalf("errors = %v, want a not found error and a missing path error", errs)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	tr := &tracker{pending: make(map[partition][]*pending)}
+	records := make([]kafka.Message, 3)
+	for i := range records {
+		records[i] = kafka.Message{Topic: "orders", Offset: int64(i)}
+		tr.add(records[i])
+	}
+	if _, ok := tr.done(records[1]); ok {
+		t.Fatal("offset 1 committable before offset 0 is handled")
+	}
+	if last, ok := tr.done(records[0]); !ok || last.Offset != 1 {
+		t.Fatalf("committable offset = %d, %t, want 1", last.Offset, ok)
+	}
+	if last, ok := tr.done(records[2]); !ok || last.Offset != 2 {
+		t.Fatalf("committable offset = %d, %t, want 2", last.Offset, ok)
+	}
+}
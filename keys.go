@@ -0,0 +1,97 @@
+package chord
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ErrInvalidKey is the error matched by errors.Is when a key is rejected by
+// the key validator of a chord.
+var ErrInvalidKey = errors.New("chord: invalid key")
+
+// KeyError is returned by the registrations of a key rejected by the key
+// validator of a chord, and by RegisterPattern for an invalid pattern.
+type KeyError struct {
+	Key    string // Key rejected.
+	Reason string // Why it was rejected.
+}
+
+// Error implements the error interface.
+func (e *KeyError) Error() string {
+	return "chord: invalid key " + strconv.Quote(e.Key) + ": " + e.Reason
+}
+
+// Unwrap returns ErrInvalidKey, so that errors.Is(err, ErrInvalidKey) reports
+// true.
+func (e *KeyError) Unwrap() error {
+	return ErrInvalidKey
+}
+
+// KeyRules is a key validator, for use with SetKeyValidator, checking the
+// static keys and the names of the parameter and wildcard keys.
+type KeyRules struct {
+	// MaxLen, when positive, is the maximum length of a key, in runes.
+	MaxLen int
+	// Pattern, when set, must match the keys, such as
+	// regexp.MustCompile(`^[a-z][a-z0-9-]*$`).
+	Pattern *regexp.Regexp
+	// Reserved are the keys which can't be registered, such as "help".
+	Reserved []string
+}
+
+// Validate checks the key against the rules, returning a *KeyError if it
+// breaks one of them.
+func (r *KeyRules) Validate(key string) error {
+	name := key
+	switch {
+	case isParam(key):
+		name = key[1:]
+	case isWildcard(key):
+		name = wildcardName(key)
+	}
+	switch {
+	case name == "":
+		return &KeyError{Key: key, Reason: "empty key"}
+	case r.MaxLen > 0 && utf8.RuneCountInString(name) > r.MaxLen:
+		return &KeyError{Key: key, Reason: "longer than " + strconv.Itoa(r.MaxLen) + " characters"}
+	case r.Pattern != nil && !r.Pattern.MatchString(name):
+		return &KeyError{Key: key, Reason: "doesn't match " + r.Pattern.String()}
+	case slices.Contains(r.Reserved, key):
+		return &KeyError{Key: key, Reason: "reserved"}
+	}
+	return nil
+}
+
+// SetKeyValidator sets the function validating the keys registered on the
+// chord by the Register and Mount methods, and the aliases defined by Alias,
+// such as the Validate method of KeyRules. The registrations of the keys it
+// rejects fail with its error, as a *KeyError. The chords mounted by
+// RegisterPattern inherit the validator of their parent. A nil validator,
+// the default, accepts any key.
+func (c *Chord) SetKeyValidator(fn func(key string) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keyValidator = fn
+}
+
+// validateKey checks the key with the key validator of the chord.
+func (c *Chord) validateKey(key string) error {
+	c.mu.RLock()
+	fn := c.keyValidator
+	c.mu.RUnlock()
+	if fn == nil {
+		return nil
+	}
+	err := fn(key)
+	if err == nil {
+		return nil
+	}
+	var ke *KeyError
+	if errors.As(err, &ke) {
+		return err
+	}
+	return &KeyError{Key: key, Reason: err.Error()}
+}
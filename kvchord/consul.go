@@ -0,0 +1,112 @@
+package kvchord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Consul is a Source watching the key-value store of Consul through blocking
+// queries of its HTTP API.
+type Consul struct {
+	// Address is the base URL of the agent. It defaults to "http://127.0.0.1:8500".
+	Address string
+	// Token, when set, is the ACL token of the requests.
+	Token string
+	// HTTPClient is the client used for the requests. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// consulEntry is an entry of a Consul key-value listing.
+type consulEntry struct {
+	Key         string
+	Value       []byte // Decoded from base64 by encoding/json.
+	ModifyIndex uint64
+}
+
+// Watch implements Source. The changes are computed by comparing the
+// successive listings of the prefix.
+func (c *Consul) Watch(ctx context.Context, prefix string, fn func(Change)) error {
+	seen := make(map[string]uint64)
+	var index uint64
+	for {
+		entries, next, err := c.list(ctx, prefix, index)
+		if err != nil {
+			return err
+		}
+		// The index may go backwards, such as after a snapshot restore.
+		if next < index {
+			next = 0
+		}
+		index = next
+
+		present := make(map[string]bool, len(entries))
+		for _, e := range entries {
+			present[e.Key] = true
+			if seen[e.Key] != e.ModifyIndex {
+				seen[e.Key] = e.ModifyIndex
+				fn(Change{Key: e.Key, Value: e.Value})
+			}
+		}
+		for key := range seen {
+			if !present[key] {
+				delete(seen, key)
+				fn(Change{Key: key, Deleted: true})
+			}
+		}
+	}
+}
+
+// list returns the entries under the prefix once the index of the store
+// exceeds index, along with the new index.
+func (c *Consul) list(ctx context.Context, prefix string, index uint64) ([]consulEntry, uint64, error) {
+	base := c.Address
+	if base == "" {
+		base = "http://127.0.0.1:8500"
+	}
+	q := url.Values{"recurse": {"true"}, "wait": {"5m"}}
+	if index > 0 {
+		q.Set("index", strconv.FormatUint(index, 10))
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, 0, err
+	}
+	u = u.JoinPath("v1", "kv", prefix)
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if c.Token != "" {
+		req.Header.Set("X-Consul-Token", c.Token)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+	next, _ := strconv.ParseUint(res.Header.Get("X-Consul-Index"), 10, 64)
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, next, nil
+	default:
+		var msg bytes.Buffer
+		msg.ReadFrom(res.Body)
+		return nil, 0, fmt.Errorf("kvchord: consul: %s: %s", res.Status, bytes.TrimSpace(msg.Bytes()))
+	}
+	var entries []consulEntry
+	if err := json.NewDecoder(res.Body).Decode(&entries); err != nil {
+		return nil, 0, fmt.Errorf("kvchord: consul: %w", err)
+	}
+	return entries, next, nil
+}
@@ -0,0 +1,143 @@
+package kvchord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Etcd is a Source watching the key-value store of etcd through the JSON
+// gateway of its v3 API.
+type Etcd struct {
+	// Endpoint is the base URL of a member. It defaults to "http://127.0.0.1:2379".
+	Endpoint string
+	// HTTPClient is the client used for the requests. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// etcdKV is a key-value pair of the v3 API. Bytes are encoded in base64.
+type etcdKV struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// Watch implements Source. The entries under the prefix are read first, then
+// watched from the following revision.
+func (e *Etcd) Watch(ctx context.Context, prefix string, fn func(Change)) error {
+	key, end := []byte(prefix), prefixEnd([]byte(prefix))
+
+	var list struct {
+		Header struct {
+			Revision string `json:"revision"`
+		} `json:"header"`
+		KVs []etcdKV `json:"kvs"`
+	}
+	res, err := e.post(ctx, "/v3/kv/range", map[string]any{"key": key, "range_end": end})
+	if err != nil {
+		return err
+	}
+	err = json.NewDecoder(res.Body).Decode(&list)
+	res.Body.Close()
+	if err != nil {
+		return fmt.Errorf("kvchord: etcd: %w", err)
+	}
+	for _, kv := range list.KVs {
+		fn(Change{Key: string(kv.Key), Value: kv.Value})
+	}
+	rev, _ := strconv.ParseInt(list.Header.Revision, 10, 64)
+
+	res, err = e.post(ctx, "/v3/watch", map[string]any{
+		"create_request": map[string]any{"key": key, "range_end": end, "start_revision": strconv.FormatInt(rev+1, 10)},
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	dec := json.NewDecoder(res.Body)
+	for {
+		var msg struct {
+			Result struct {
+				Canceled     bool   `json:"canceled"`
+				CancelReason string `json:"cancel_reason"`
+				Events       []struct {
+					Type string `json:"type"`
+					KV   etcdKV `json:"kv"`
+				} `json:"events"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := dec.Decode(&msg); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("kvchord: etcd: %w", err)
+		}
+		if msg.Error != nil {
+			return errors.New("kvchord: etcd: " + msg.Error.Message)
+		}
+		if msg.Result.Canceled {
+			return errors.New("kvchord: etcd: watch canceled: " + msg.Result.CancelReason)
+		}
+		for _, ev := range msg.Result.Events {
+			if ev.Type == "DELETE" {
+				fn(Change{Key: string(ev.KV.Key), Deleted: true})
+			} else {
+				fn(Change{Key: string(ev.KV.Key), Value: ev.KV.Value})
+			}
+		}
+	}
+}
+
+// post sends a JSON request to the gateway.
+func (e *Etcd) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	base := e.Endpoint
+	if base == "" {
+		base = "http://127.0.0.1:2379"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+path, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := e.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		res.Body.Close()
+		return nil, fmt.Errorf("kvchord: etcd: %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	return res, nil
+}
+
+// prefixEnd returns the end of the range of the keys starting with the prefix.
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// All the keys are greater than the prefix.
+	return []byte{0}
+}
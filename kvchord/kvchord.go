@@ -0,0 +1,209 @@
+/*
+Package kvchord registers the threads of a chord from the entries of a
+key-value store, such as etcd or Consul, so that routing can be reconfigured
+without redeploying.
+
+A Backend watches the keys under a prefix of a Source. The key of an entry,
+relative to the prefix, is the slash-separated path of a thread, and its value
+is a JSON Descriptor of the thread: either the endpoint and path of a remote
+thread, as accepted by chord.RemoteThread, or the name of a thread referenced
+through Backend.Reference, such as one loaded from a plugin. Threads are
+registered as entries appear and unregistered as they disappear:
+
+	etcdctl put /chord/billing/invoice '{"endpoint": "unix:///run/billing.sock", "path": ["invoice"]}'
+*/
+package kvchord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/graphitects/chord"
+)
+
+// Change is a change of an entry of a key-value store.
+type Change struct {
+	Key     string // Full key of the entry.
+	Value   []byte // Value of the entry, nil when deleted.
+	Deleted bool   // Whether the entry was deleted.
+}
+
+// Source is a key-value store whose entries can be watched.
+type Source interface {
+	// Watch calls fn with a change for every entry under the prefix, then
+	// with every change of these entries, until the context is done or the
+	// store fails. Calls to fn are sequential.
+	Watch(ctx context.Context, prefix string, fn func(Change)) error
+}
+
+// Descriptor describes the thread of an entry.
+type Descriptor struct {
+	// Endpoint and Path select a remote thread, as by chord.RemoteThread.
+	Endpoint string   `json:"endpoint,omitempty"`
+	Path     []string `json:"path,omitempty"`
+	// Thread is the name of a thread referenced through Backend.Reference.
+	Thread string `json:"thread,omitempty"`
+	// Meta is the metadata the thread is registered with.
+	Meta chord.Meta `json:"meta"`
+}
+
+// Backend registers the threads described by the entries of a source on a
+// root chord.
+type Backend struct {
+	// OnError, when set, is called with the errors of the entries which can't
+	// be registered, such as invalid descriptors.
+	OnError func(key string, err error)
+
+	root   *chord.Chord
+	source Source
+	prefix string
+
+	// refs are the threads referenced by name by the descriptors.
+	refs map[string]chord.Thread
+	mu   sync.RWMutex
+
+	// regs are the registrations of the threads of the entries, by path, and
+	// mounts the chords mounted by the backend for them.
+	regs    map[string]*chord.Registration
+	mounts  map[*chord.Chord]bool
+	applyMu sync.Mutex // Serializes the changes applied.
+}
+
+// New returns a Backend registering on root the threads described by the
+// entries under the prefix of the source, such as "/chord/".
+func New(root *chord.Chord, source Source, prefix string) *Backend {
+	return &Backend{
+		root:   root,
+		source: source,
+		prefix: prefix,
+		refs:   make(map[string]chord.Thread),
+		regs:   make(map[string]*chord.Registration),
+		mounts: make(map[*chord.Chord]bool),
+	}
+}
+
+// Reference makes the thread available to the descriptors under the name.
+// Entries already registered with the name keep their former thread until
+// they change.
+func (b *Backend) Reference(name string, thread chord.Thread) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refs[name] = thread
+}
+
+// Run watches the source and applies the changes of its entries until the
+// context is done or the source fails.
+func (b *Backend) Run(ctx context.Context) error {
+	return b.source.Watch(ctx, b.prefix, b.Apply)
+}
+
+// Apply registers or unregisters the thread of a changed entry. Entries outside
+// of the prefix are ignored. The backend only replaces and unregisters the
+// threads it registered, and only unmounts the chords it mounted: an entry
+// whose path holds a thread registered otherwise fails with a
+// *chord.DuplicateError.
+func (b *Backend) Apply(ch Change) {
+	b.applyMu.Lock()
+	defer b.applyMu.Unlock()
+	rel, ok := strings.CutPrefix(ch.Key, b.prefix)
+	if !ok {
+		return
+	}
+	path := strings.FieldsFunc(rel, func(r rune) bool { return r == '/' })
+	if len(path) == 0 {
+		return
+	}
+	if ch.Deleted {
+		b.unregister(path)
+		return
+	}
+	if err := b.register(path, ch.Value); err != nil && b.OnError != nil {
+		b.OnError(ch.Key, err)
+	}
+}
+
+// register registers the thread described by the value under the path,
+// mounting new chords for the missing intermediate keys.
+func (b *Backend) register(path []string, value []byte) error {
+	var d Descriptor
+	if err := json.Unmarshal(value, &d); err != nil {
+		return fmt.Errorf("kvchord: invalid descriptor: %w", err)
+	}
+	thread, err := b.thread(d)
+	if err != nil {
+		return err
+	}
+	node := b.root
+	for _, key := range path[:len(path)-1] {
+		next, ok := node.FetchChord(key)
+		if !ok {
+			next = chord.NewChord()
+			if err := node.Mount(key, next); err != nil {
+				return err
+			}
+			b.mounts[next] = true
+		}
+		node = next
+	}
+	id := strings.Join(path, "/")
+	var r *chord.Registration
+	if old, ok := b.regs[id]; ok && old.Active() && old.Chord() == node {
+		r, err = node.ReplaceWithMeta(path[len(path)-1], thread, d.Meta)
+	} else {
+		r, err = node.RegisterWithMeta(path[len(path)-1], thread, d.Meta)
+	}
+	if err != nil {
+		return err
+	}
+	b.regs[id] = r
+	return nil
+}
+
+// thread returns the thread of a descriptor.
+func (b *Backend) thread(d Descriptor) (chord.Thread, error) {
+	switch {
+	case d.Thread != "":
+		b.mu.RLock()
+		thread, ok := b.refs[d.Thread]
+		b.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("kvchord: unknown thread %q", d.Thread)
+		}
+		return thread, nil
+	case d.Endpoint != "":
+		return chord.RemoteThread(d.Endpoint, d.Path), nil
+	}
+	return nil, errors.New("kvchord: descriptor without endpoint or thread")
+}
+
+// unregister unregisters the thread registered for the path, then unmounts
+// the chords of the path mounted by the backend and left empty.
+func (b *Backend) unregister(path []string) {
+	id := strings.Join(path, "/")
+	r, ok := b.regs[id]
+	if !ok {
+		return
+	}
+	delete(b.regs, id)
+	r.Chord().Unregister(r.Key(), r)
+	nodes := []*chord.Chord{b.root}
+	for _, key := range path[:len(path)-1] {
+		next, ok := nodes[len(nodes)-1].FetchChord(key)
+		if !ok {
+			return
+		}
+		nodes = append(nodes, next)
+	}
+	for i := len(nodes) - 1; i > 0; i-- {
+		tree := nodes[i].Tree()
+		if !b.mounts[nodes[i]] || len(tree.Threads) > 0 || len(tree.Chords) > 0 {
+			return
+		}
+		nodes[i-1].Unmount(path[i-1])
+		delete(b.mounts, nodes[i])
+	}
+}
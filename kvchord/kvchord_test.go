@@ -0,0 +1,114 @@
+package kvchord
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+// memSource is a Source replaying a fixed list of changes.
+type memSource []Change
+
+// Watch implements Source.
+func (s memSource) Watch(ctx context.Context, prefix string, fn func(Change)) error {
+	for _, ch := range s {
+		fn(ch)
+	}
+	return nil
+}
+
+// run executes the thread of the path on root and returns its output.
+func run(root *chord.Chord, path ...string) (string, error) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bw)}
+	err := root.Execute(path, &chord.Input{}, out)
+	bw.Flush()
+	return buf.String(), err
+}
+
+// echo returns a thread writing the text.
+func echo(text string) chord.Thread {
+	return func(in *chord.Input, out *chord.Output) error {
+		out.WriteString(text)
+		return out.Flush()
+	}
+}
+
+func TestBackendApply(t *testing.T) {
+	root := chord.NewChord()
+	b := New(root, memSource{
+		{Key: "/chord/billing/invoice", Value: []byte(`{"thread": "v1"}`)},
+		{Key: "/other/ignored", Value: []byte(`{"thread": "v1"}`)},
+	}, "/chord/")
+	b.Reference("v1", echo("v1"))
+	b.Reference("v2", echo("v2"))
+	if err := b.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := run(root, "billing", "invoice"); err != nil || got != "v1" {
+		t.Fatalf("output = %q, %v, want %q", got, err, "v1")
+	}
+	if _, ok := root.FetchChord("other"); ok {
+		t.Fatal("entry outside of the prefix applied")
+	}
+
+	b.Apply(Change{Key: "/chord/billing/invoice", Value: []byte(`{"thread": "v2"}`)})
+	if got, _ := run(root, "billing", "invoice"); got != "v2" {
+		t.Fatalf("output after the change = %q, want %q", got, "v2")
+	}
+
+	b.Apply(Change{Key: "/chord/billing/invoice", Deleted: true})
+	if _, err := run(root, "billing", "invoice"); !errors.Is(err, chord.ErrNotFound) {
+		t.Fatalf("execution after the deletion = %v, want ErrNotFound", err)
+	}
+	if _, ok := root.FetchChord("billing"); ok {
+		t.Fatal("empty chord mounted by the backend left mounted")
+	}
+}
+
+func TestBackendKeepsOtherThreads(t *testing.T) {
+	root, users := chord.NewChord(), chord.NewChord()
+	root.Register("status", echo("user"))
+	root.Mount("users", users)
+	var errs []error
+	b := New(root, nil, "/chord/")
+	b.OnError = func(key string, err error) { errs = append(errs, err) }
+	b.Reference("kv", echo("kv"))
+
+	b.Apply(Change{Key: "/chord/status", Value: []byte(`{"thread": "kv"}`)})
+	var de *chord.DuplicateError
+	if len(errs) != 1 || !errors.As(errs[0], &de) {
+		t.Fatalf("errors = %v, want a *DuplicateError", errs)
+	}
+	if got, _ := run(root, "status"); got != "user" {
+		t.Fatalf("output = %q, want the thread registered otherwise", got)
+	}
+
+	b.Apply(Change{Key: "/chord/users/list", Value: []byte(`{"thread": "kv"}`)})
+	b.Apply(Change{Key: "/chord/users/list", Deleted: true})
+	if c, ok := root.FetchChord("users"); !ok || c != users {
+		t.Fatal("chord mounted otherwise unmounted")
+	}
+	b.Apply(Change{Key: "/chord/status", Deleted: true})
+	if got, _ := run(root, "status"); got != "user" {
+		t.Fatalf("output = %q, want the thread registered otherwise kept", got)
+	}
+}
+
+func TestBackendInvalidDescriptor(t *testing.T) {
+	var errs []error
+	b := New(chord.NewChord(), nil, "/chord/")
+	b.OnError = func(key string, err error) { errs = append(errs, err) }
+	for _, value := range []string{`not json`, `{}`, `{"thread": "unknown"}`} {
+		b.Apply(Change{Key: "/chord/x", Value: []byte(value)})
+	}
+	if len(errs) != 3 {
+		t.Fatalf("errors = %v, want 3", errs)
+	}
+}
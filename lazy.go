@@ -0,0 +1,61 @@
+package chord
+
+import "sync"
+
+// lazyLoad is the state of the loading of a lazy chord.
+type lazyLoad struct {
+	load   func(*Chord)
+	loaded bool
+	mu     sync.Mutex
+}
+
+// NewLazyChord returns a Chord whose threads and nested chords are registered
+// by load the first time they are accessed, such as by Match, FetchThread,
+// Walk or Tree, rather than upfront. This suits trees backed by slow or large
+// sources, such as filesystems. The chord passed to load is a new chord whose
+// threads and chords are then moved to the lazy chord; its middleware and
+// other settings are ignored. Invalidate discards them so that load runs again
+// on the next access.
+func NewLazyChord(load func(*Chord)) *Chord {
+	c := NewChord()
+	c.lazy = &lazyLoad{load: load}
+	return c
+}
+
+// Invalidate discards the threads and chords of a lazy chord, so that they are
+// loaded again on the next access. It has no effect on other chords.
+func (c *Chord) Invalidate() {
+	if c.lazy == nil {
+		return
+	}
+	c.lazy.mu.Lock()
+	defer c.lazy.mu.Unlock()
+	c.lazy.loaded = false
+	c.threads.Clear()
+	c.chords.Clear()
+}
+
+// ensureLoaded loads the threads and chords of a lazy chord, if not loaded yet.
+func (c *Chord) ensureLoaded() {
+	if c.lazy == nil {
+		return
+	}
+	c.lazy.mu.Lock()
+	defer c.lazy.mu.Unlock()
+	if c.lazy.loaded {
+		return
+	}
+	tmp := NewChord()
+	c.lazy.load(tmp)
+	tmp.threads.Range(func(k, v any) bool {
+		c.threads.Store(c.foldKey(k.(string)), v)
+		return true
+	})
+	tmp.chords.Range(func(k, v any) bool {
+		key := c.foldKey(k.(string))
+		c.chords.Store(key, v)
+		v.(*mount).chord.attach(c, key)
+		return true
+	})
+	c.lazy.loaded = true
+}
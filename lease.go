@@ -0,0 +1,101 @@
+package chord
+
+import (
+	"sync"
+	"time"
+)
+
+// Lease is the registration of a thread which expires unless renewed, as
+// returned by RegisterLease.
+type Lease struct {
+	c       *Chord
+	key     string
+	e       *entry
+	ttl     time.Duration
+	timer   *time.Timer
+	expires time.Time
+	ended   bool // Whether the lease expired or was revoked.
+	mu      sync.Mutex
+}
+
+// RegisterLease registers a thread under key, as RegisterWithMeta does, for the
+// duration of a lease: the thread is unregistered once ttl elapsed without the
+// lease being renewed. This suits ephemeral threads registered on behalf of
+// short-lived workers or remote nodes, which renew the lease while alive.
+// Replacing the thread under key, such as with Replace, ends the lease without
+// unregistering the new thread. The key is validated, and duplicates rejected,
+// as by Register.
+func (c *Chord) RegisterLease(key string, thread Thread, ttl time.Duration, meta Meta, tw ...ThreadWrapper) (*Lease, error) {
+	key = c.foldKey(key)
+	if err := c.checkThread(key, thread == nil); err != nil {
+		return nil, err
+	}
+	e, err := c.register(key, WrapThreads(thread, tw...), meta, false)
+	if err != nil {
+		return nil, err
+	}
+	l := &Lease{c: c, key: key, e: e, ttl: ttl, expires: time.Now().Add(ttl)}
+	l.mu.Lock()
+	l.timer = time.AfterFunc(ttl, l.expire)
+	l.mu.Unlock()
+	return l, nil
+}
+
+// Renew extends the lease by its duration from now, and reports whether it
+// was still active.
+func (l *Lease) Renew() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ended || !l.active() {
+		return false
+	}
+	l.timer.Reset(l.ttl)
+	l.expires = time.Now().Add(l.ttl)
+	return true
+}
+
+// Revoke ends the lease, unregistering its thread, and reports whether it was
+// still active.
+func (l *Lease) Revoke() bool {
+	l.mu.Lock()
+	if l.ended {
+		l.mu.Unlock()
+		return false
+	}
+	l.ended = true
+	l.timer.Stop()
+	l.mu.Unlock()
+	return l.unregister()
+}
+
+// Expires returns the time the lease expires unless renewed.
+func (l *Lease) Expires() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.expires
+}
+
+// expire ends the lease when its timer fires, unless it was renewed meanwhile.
+func (l *Lease) expire() {
+	l.mu.Lock()
+	if l.ended || time.Now().Before(l.expires) {
+		l.mu.Unlock()
+		return
+	}
+	l.ended = true
+	l.mu.Unlock()
+	l.unregister()
+}
+
+// unregister unregisters the thread of the lease if it is still the registered
+// one, and reports whether it was.
+func (l *Lease) unregister() bool {
+	_, ok := l.c.unregister(l.key, l.e)
+	return ok
+}
+
+// active reports whether the thread of the lease is still the registered one.
+func (l *Lease) active() bool {
+	e, ok := l.c.threads.Load(l.key)
+	return ok && e == l.e
+}
@@ -0,0 +1,94 @@
+package chord
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// OnStart registers a function called by Start, such as to set up the
+// resources shared by the threads of the chord.
+func (c *Chord) OnStart(fn func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.startHooks = append(c.startHooks, fn)
+}
+
+// OnStop registers a function called by Stop, such as to tear down the
+// resources set up by OnStart.
+func (c *Chord) OnStop(fn func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopHooks = append(c.stopHooks, fn)
+}
+
+// Start calls the start functions of the chord and of its nested chords. A
+// chord is started before the chords mounted on it, which are started in
+// lexical order of their keys, and its start functions are called in the
+// order they were registered. A chord mounted several times is started once.
+// When a start function fails, the chords already started are stopped, as by
+// Stop, and its error is returned.
+func (c *Chord) Start(ctx context.Context) error {
+	chords := c.lifecycleOrder()
+	for i, ch := range chords {
+		for _, fn := range ch.fetchLifecycleHooks(true) {
+			if err := fn(ctx); err != nil {
+				stopChords(ctx, chords[:i])
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Stop calls the stop functions of the chord and of its nested chords, in the
+// reverse order of Start: the chords mounted on a chord are stopped before it,
+// and its stop functions are called in the reverse order they were
+// registered. All of them are called, and their errors are returned joined.
+func (c *Chord) Stop(ctx context.Context) error {
+	return stopChords(ctx, c.lifecycleOrder())
+}
+
+// stopChords calls the stop functions of the chords, in reverse order.
+func stopChords(ctx context.Context, chords []*Chord) error {
+	var errs []error
+	for _, ch := range slices.Backward(chords) {
+		for _, fn := range slices.Backward(ch.fetchLifecycleHooks(false)) {
+			errs = append(errs, fn(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lifecycleOrder returns the chord and its nested chords in the order they are
+// started, each one once.
+func (c *Chord) lifecycleOrder() []*Chord {
+	var chords []*Chord
+	seen := make(map[*Chord]bool)
+	var visit func(ch *Chord)
+	visit = func(ch *Chord) {
+		if seen[ch] {
+			return
+		}
+		seen[ch] = true
+		chords = append(chords, ch)
+		ch.ensureLoaded()
+		for _, key := range sortedKeys(&ch.chords) {
+			if m, ok := ch.fetchMount(key); ok {
+				visit(m.chord)
+			}
+		}
+	}
+	visit(c)
+	return chords
+}
+
+// fetchLifecycleHooks returns a copy of the start or stop functions of the chord.
+func (c *Chord) fetchLifecycleHooks(start bool) []func(context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if start {
+		return slices.Clone(c.startHooks)
+	}
+	return slices.Clone(c.stopHooks)
+}
@@ -0,0 +1,115 @@
+package chord
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Logger logs the executions of the threads it wraps with log/slog, recording
+// their route, duration, arguments, caller and outcome.
+type Logger struct {
+	// Logger is the logger written to. It defaults to slog.Default().
+	Logger *slog.Logger
+	// Level is the level of the successful executions. It defaults to Info.
+	Level slog.Level
+	// ErrorLevel is the level of the failed executions. It defaults to Error
+	// when zero, so failures can't be logged at Info.
+	ErrorLevel slog.Level
+	// MaxArgs is the maximum number of arguments logged, and MaxArgLen the
+	// maximum length of each of them; longer ones are truncated with "…".
+	// They default to 8 and 64.
+	MaxArgs, MaxArgLen int
+	// CallerFlag is the flag holding the identity of the caller, logged as
+	// the "caller" attribute when set.
+	CallerFlag string
+	// Redact lists the names of the flags whose values are logged as
+	// "[REDACTED]", such as "token" or "password".
+	Redact []string
+}
+
+// Log is a ThreadWrapper logging the executions of the thread once they
+// returned, with the "route", "duration", "args", "flags", "execution_id",
+// "correlation_id", "caller" and "error" attributes.
+func (l *Logger) Log(next Thread) Thread {
+	return func(in *Input, out *Output) error {
+		start := time.Now()
+		err := next(in, out)
+		logger := l.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		level := l.Level
+		if err != nil {
+			level = l.ErrorLevel
+			if level == 0 {
+				level = slog.LevelError
+			}
+		}
+		ctx := in.Context()
+		if !logger.Enabled(ctx, level) {
+			return err
+		}
+		attrs := []slog.Attr{
+			slog.String("route", strings.Join(Route(in), " ")),
+			slog.Duration("duration", time.Since(start)),
+			slog.Any("args", l.args(in.Args)),
+		}
+		if len(in.Flags) > 0 {
+			attrs = append(attrs, slog.Any("flags", l.flags(in.Flags)))
+		}
+		if id := ExecutionIDOf(in); id != "" {
+			attrs = append(attrs, slog.String("execution_id", id))
+		}
+		if id := CorrelationIDOf(in); id != "" {
+			attrs = append(attrs, slog.String("correlation_id", id))
+		}
+		if caller := in.Flags[l.CallerFlag]; l.CallerFlag != "" && caller != "" {
+			attrs = append(attrs, slog.String("caller", caller))
+		}
+		msg := "thread executed"
+		if err != nil {
+			msg = "thread failed"
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		logger.LogAttrs(context.WithoutCancel(ctx), level, msg, attrs...)
+		return err
+	}
+}
+
+// args returns the arguments to log, truncated.
+func (l *Logger) args(args []string) []string {
+	maxArgs, maxLen := l.MaxArgs, l.MaxArgLen
+	if maxArgs <= 0 {
+		maxArgs = 8
+	}
+	if maxLen <= 0 {
+		maxLen = 64
+	}
+	logged := make([]string, 0, min(len(args), maxArgs+1))
+	for i, arg := range args {
+		if i == maxArgs {
+			logged = append(logged, "…")
+			break
+		}
+		if r := []rune(arg); len(r) > maxLen {
+			arg = string(r[:maxLen]) + "…"
+		}
+		logged = append(logged, arg)
+	}
+	return logged
+}
+
+// flags returns the flags to log, with the sensitive values redacted.
+func (l *Logger) flags(flags map[string]string) map[string]string {
+	logged := make(map[string]string, len(flags))
+	for name, value := range flags {
+		if slices.Contains(l.Redact, name) {
+			value = "[REDACTED]"
+		}
+		logged[name] = value
+	}
+	return logged
+}
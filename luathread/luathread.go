@@ -0,0 +1,139 @@
+/*
+Package luathread compiles Lua scripts into threads, so that operators can add
+small handlers without recompiling the program.
+
+Scripts run in a fresh, sandboxed Lua state on every execution, with the base,
+string, table and math libraries but without access to files or processes.
+They read the input from the global input table, whose fields are key, args
+(an array), flags and params (tables keyed by name), and write to the output
+through the print and write functions:
+
+	print("hello " .. (input.args[1] or "world"))
+	if input.flags.loud then write("!\n") end
+
+A script fails by raising an error, such as with error("invalid user"), whose
+message is returned by the thread.
+*/
+package luathread
+
+import (
+	"errors"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+
+	"github.com/graphitects/chord"
+)
+
+// Script is a compiled Lua script.
+type Script struct {
+	proto *lua.FunctionProto
+}
+
+// Compile compiles the source of a script. The name is used in error messages.
+func Compile(name, source string) (*Script, error) {
+	chunk, err := parse.Parse(strings.NewReader(source), name)
+	if err != nil {
+		return nil, err
+	}
+	proto, err := lua.Compile(chunk, name)
+	if err != nil {
+		return nil, err
+	}
+	return &Script{proto: proto}, nil
+}
+
+// Thread returns a thread running the script, as documented by the package.
+// The execution of the script is interrupted when the context of the input
+// is done.
+func (s *Script) Thread() chord.Thread {
+	return func(in *chord.Input, out *chord.Output) error {
+		L := newState()
+		defer L.Close()
+		L.SetContext(in.Context())
+
+		L.SetGlobal("input", inputTable(L, in))
+		L.SetGlobal("print", L.NewFunction(func(L *lua.LState) int {
+			return write(L, out, "\t", "\n")
+		}))
+		L.SetGlobal("write", L.NewFunction(func(L *lua.LState) int {
+			return write(L, out, "", "")
+		}))
+
+		L.Push(L.NewFunctionFromProto(s.proto))
+		if err := L.PCall(0, lua.MultRet, nil); err != nil {
+			if ctxErr := in.Context().Err(); ctxErr != nil {
+				return ctxErr
+			}
+			var apiErr *lua.ApiError
+			if errors.As(err, &apiErr) && apiErr.Object != nil {
+				return errors.New(apiErr.Object.String())
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// newState returns a Lua state with the libraries available to scripts.
+func newState() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.StringLibName, lua.OpenString},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	// Remove the functions of the base library reaching the filesystem.
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring", "module", "require"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+	return L
+}
+
+// inputTable returns the input table of a script.
+func inputTable(L *lua.LState, in *chord.Input) *lua.LTable {
+	t := L.NewTable()
+	t.RawSetString("key", lua.LString(in.Key))
+	args := L.NewTable()
+	for _, arg := range in.Args {
+		args.Append(lua.LString(arg))
+	}
+	t.RawSetString("args", args)
+	t.RawSetString("flags", stringTable(L, in.Flags))
+	t.RawSetString("params", stringTable(L, in.Params))
+	return t
+}
+
+// stringTable returns a table holding the entries of the map.
+func stringTable(L *lua.LState, m map[string]string) *lua.LTable {
+	t := L.NewTable()
+	for k, v := range m {
+		t.RawSetString(k, lua.LString(v))
+	}
+	return t
+}
+
+// write writes the arguments of the Lua call to the output, separated by sep
+// and followed by end.
+func write(L *lua.LState, out *chord.Output, sep, end string) int {
+	if out == nil || out.Writer == nil {
+		return 0
+	}
+	for i := 1; i <= L.GetTop(); i++ {
+		if i > 1 {
+			out.WriteString(sep)
+		}
+		out.WriteString(L.ToStringMeta(L.Get(i)).String())
+	}
+	out.WriteString(end)
+	return 0
+}
@@ -0,0 +1,64 @@
+package luathread
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graphitects/chord"
+)
+
+// run runs the script with the input and returns its output.
+func run(t *testing.T, source string, in *chord.Input) (string, error) {
+	t.Helper()
+	s, err := Compile("test.lua", source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bw)}
+	err = s.Thread()(in, out)
+	bw.Flush()
+	return buf.String(), err
+}
+
+func TestThread(t *testing.T) {
+	in := &chord.Input{Key: "greet", Args: []string{"bob"}, Flags: map[string]string{"loud": "true"}}
+	got, err := run(t, `
+		print("hello", input.args[1])
+		if input.flags.loud then write(input.key, "!") end
+	`, in)
+	if err != nil || got != "hello\tbob\ngreet!" {
+		t.Fatalf("output = %q, %v, want %q", got, err, "hello\tbob\ngreet!")
+	}
+}
+
+func TestThreadError(t *testing.T) {
+	if _, err := run(t, `error("invalid user", 0)`, &chord.Input{}); err == nil || err.Error() != "invalid user" {
+		t.Fatalf("error = %v, want %q", err, "invalid user")
+	}
+	if _, err := Compile("bad.lua", "print("); err == nil {
+		t.Fatal("Compile of an invalid script succeeded")
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	got, err := run(t, `print(io == nil, os == nil, dofile == nil, require == nil)`, &chord.Input{})
+	if err != nil || got != "true\ttrue\ttrue\ttrue\n" {
+		t.Fatalf("output = %q, %v, want the io, os, dofile and require globals unset", got, err)
+	}
+}
+
+func TestThreadCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := run(t, `while true do end`, (&chord.Input{}).WithContext(ctx))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
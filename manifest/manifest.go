@@ -0,0 +1,207 @@
+/*
+Package manifest builds chord trees from YAML or JSON manifests.
+
+A manifest declares the threads of a tree by path, along with their metadata,
+and the middleware of the root and of the nested chords by name. Threads and
+middleware are referenced by name and looked up in a Registry, so that large
+trees can be described in configuration rather than wired in Go code:
+
+	middleware: [logging]
+	chords:
+	  - path: admin
+	    middleware: [auth]
+	    meta: {description: Administration commands}
+	threads:
+	  - path: admin/users/:id/ban
+	    handler: ban
+	    meta: {description: Ban a user, usage: "admin users <id> ban"}
+*/
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/graphitects/chord"
+)
+
+// Manifest describes a chord tree.
+type Manifest struct {
+	// Middleware are the names of the middleware of the root chord, in order.
+	Middleware []string `json:"middleware,omitempty"`
+	// Chords declare the middleware and metadata of nested chords.
+	Chords []Chord `json:"chords,omitempty"`
+	// Threads declare the threads of the tree.
+	Threads []Thread `json:"threads,omitempty"`
+}
+
+// Chord declares a nested chord.
+type Chord struct {
+	// Path is the slash-separated path of the chord, such as "admin/users".
+	Path string `json:"path"`
+	// Middleware are the names of the middleware of the chord, in order.
+	Middleware []string `json:"middleware,omitempty"`
+	// Meta is the metadata of the chord.
+	Meta chord.Meta `json:"meta"`
+}
+
+// Thread declares a thread.
+type Thread struct {
+	// Path is the slash-separated path of the thread, which may contain
+	// parameter and wildcard keys as accepted by chord.RegisterPattern.
+	Path string `json:"path"`
+	// Handler is the name of the thread in the registry.
+	Handler string `json:"handler"`
+	// Middleware are the names of the wrappers of the thread, in order.
+	Middleware []string `json:"middleware,omitempty"`
+	// Meta is the metadata of the thread.
+	Meta chord.Meta `json:"meta"`
+}
+
+// Registry holds the threads and middleware referenced by manifests.
+type Registry struct {
+	Threads    map[string]chord.Thread
+	Middleware map[string]chord.ThreadWrapper
+}
+
+// Parse parses a manifest from YAML or JSON. Unknown fields are rejected.
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Load parses the manifest file and builds a new chord tree from it.
+func Load(name string, reg Registry) (*chord.Chord, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	m, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	root := chord.NewChord()
+	if err := m.Apply(root, reg); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// Apply registers the threads, chords and middleware of the manifest on root,
+// mounting new chords for the missing intermediate keys. Middleware is
+// registered by name, through UseNamed. Declared chords which are already
+// mounted are mounted again with their declared metadata, without the
+// wrappers of their former mount, and declared threads replace the threads
+// already registered under their paths. All the references are resolved before
+// root is modified, so that root is left unchanged when one is unknown. A key
+// rejected by the validator of its chord, as set by SetKeyValidator, is only
+// detected while applying, leaving the manifest partially applied.
+func (m *Manifest) Apply(root *chord.Chord, reg Registry) error {
+	rootMW, err := reg.middleware(m.Middleware)
+	if err != nil {
+		return err
+	}
+	chordMW := make([][]chord.ThreadWrapper, len(m.Chords))
+	for i, c := range m.Chords {
+		if len(splitPath(c.Path)) == 0 {
+			return fmt.Errorf("manifest: chords[%d]: empty path", i)
+		}
+		if chordMW[i], err = reg.middleware(c.Middleware); err != nil {
+			return fmt.Errorf("manifest: chord %s: %w", c.Path, err)
+		}
+	}
+	threads := make([]chord.Thread, len(m.Threads))
+	threadMW := make([][]chord.ThreadWrapper, len(m.Threads))
+	for i, t := range m.Threads {
+		keys := splitPath(t.Path)
+		if len(keys) == 0 {
+			return fmt.Errorf("manifest: threads[%d]: empty path", i)
+		}
+		for _, key := range keys[:len(keys)-1] {
+			if strings.HasPrefix(key, "*") {
+				return fmt.Errorf("manifest: thread %s: wildcard must be the last key", t.Path)
+			}
+		}
+		thread, ok := reg.Threads[t.Handler]
+		if !ok {
+			return fmt.Errorf("manifest: thread %s: unknown handler %q", t.Path, t.Handler)
+		}
+		threads[i] = thread
+		if threadMW[i], err = reg.middleware(t.Middleware); err != nil {
+			return fmt.Errorf("manifest: thread %s: %w", t.Path, err)
+		}
+	}
+
+	for i, name := range m.Middleware {
+		root.UseNamed(name, rootMW[i])
+	}
+	for i, c := range m.Chords {
+		keys := splitPath(c.Path)
+		parent, err := descend(root, keys[:len(keys)-1])
+		if err != nil {
+			return fmt.Errorf("manifest: chord %s: %w", c.Path, err)
+		}
+		node, ok := parent.FetchChord(keys[len(keys)-1])
+		if !ok {
+			node = chord.NewChord()
+		}
+		if err := parent.ReplaceMountWithMeta(keys[len(keys)-1], node, c.Meta); err != nil {
+			return fmt.Errorf("manifest: chord %s: %w", c.Path, err)
+		}
+		for j, name := range c.Middleware {
+			node.UseNamed(name, chordMW[i][j])
+		}
+	}
+	for i, t := range m.Threads {
+		keys := splitPath(t.Path)
+		parent, err := descend(root, keys[:len(keys)-1])
+		if err != nil {
+			return fmt.Errorf("manifest: thread %s: %w", t.Path, err)
+		}
+		if _, err := parent.ReplaceWithMeta(keys[len(keys)-1], threads[i], t.Meta, threadMW[i]...); err != nil {
+			return fmt.Errorf("manifest: thread %s: %w", t.Path, err)
+		}
+	}
+	return nil
+}
+
+// middleware resolves the names of middleware.
+func (reg Registry) middleware(names []string) ([]chord.ThreadWrapper, error) {
+	tw := make([]chord.ThreadWrapper, len(names))
+	for i, name := range names {
+		w, ok := reg.Middleware[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %q", name)
+		}
+		tw[i] = w
+	}
+	return tw, nil
+}
+
+// descend returns the chord of the path from root, mounting new chords for
+// the missing keys.
+func descend(root *chord.Chord, keys []string) (*chord.Chord, error) {
+	node := root
+	for _, key := range keys {
+		next, ok := node.FetchChord(key)
+		if !ok {
+			next = chord.NewChord()
+			if err := node.Mount(key, next); err != nil {
+				return nil, err
+			}
+		}
+		node = next
+	}
+	return node, nil
+}
+
+// splitPath splits a slash-separated path into keys, ignoring empty keys.
+func splitPath(p string) []string {
+	return strings.FieldsFunc(p, func(r rune) bool { return r == '/' })
+}
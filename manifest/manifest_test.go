@@ -0,0 +1,86 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+const source = `
+middleware: [trace]
+chords:
+  - path: admin
+    meta: {description: Administration commands}
+threads:
+  - path: admin/users/:id/ban
+    handler: ban
+    meta: {description: Ban a user}
+`
+
+func TestApply(t *testing.T) {
+	var traced []string
+	reg := Registry{
+		Threads: map[string]chord.Thread{
+			"ban": func(in *chord.Input, out *chord.Output) error {
+				out.WriteString("banned " + in.Params["id"])
+				return out.Flush()
+			},
+		},
+		Middleware: map[string]chord.ThreadWrapper{
+			"trace": func(next chord.Thread) chord.Thread {
+				return func(in *chord.Input, out *chord.Output) error {
+					traced = append(traced, in.Key)
+					return next(in, out)
+				}
+			},
+		},
+	}
+	m, err := Parse([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := chord.NewChord()
+	if err := m.Apply(root, reg); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bw)}
+	if err := root.Execute([]string{"admin", "users", "42", "ban"}, &chord.Input{Key: "ban"}, out); err != nil {
+		t.Fatal(err)
+	}
+	bw.Flush()
+	if got := buf.String(); got != "banned 42" {
+		t.Fatalf("output = %q, want %q", got, "banned 42")
+	}
+	if len(traced) != 1 {
+		t.Fatalf("root middleware called %d times, want 1", len(traced))
+	}
+	if meta, _ := root.FetchChordMeta("admin"); meta.Description != "Administration commands" {
+		t.Fatalf("description of admin = %q, want the declared one", meta.Description)
+	}
+}
+
+func TestApplyUnknownReference(t *testing.T) {
+	m, err := Parse([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := chord.NewChord()
+	if err := m.Apply(root, Registry{}); err == nil || !strings.Contains(err.Error(), "unknown middleware") {
+		t.Fatalf("error = %v, want an unknown middleware error", err)
+	}
+	if _, ok := root.FetchChord("admin"); ok {
+		t.Fatal("root was modified by a failing Apply")
+	}
+}
+
+func TestParseUnknownField(t *testing.T) {
+	if _, err := Parse([]byte("threads:\n  - path: a\n    handlr: a\n")); err == nil {
+		t.Fatal("Parse of an unknown field succeeded")
+	}
+}
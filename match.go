@@ -0,0 +1,212 @@
+package chord
+
+import "strings"
+
+// matcher holds the state and the options of a traversal of the chord
+// structure performed by Match.
+type matcher struct {
+	params map[string]string // Keys captured by parameter and wildcard keys.
+	strict bool              // Whether the NotFound fallbacks are ignored.
+	route  []string          // Registered keys of the path matched, set on success.
+	// internal is set when the path matched traverses an internal thread or chord.
+	internal bool
+	// abbrev is set while matching from a chord with abbreviations enabled.
+	abbrev bool
+	// deprecated is the deprecation notice of the thread matched, or else of
+	// the innermost deprecated chord traversed.
+	deprecated string
+}
+
+// resolve matches the path from the node and wraps the thread found so that it
+// receives the captured params and the route matched, and its executions are
+// tracked and accounted in the statistics of the node. The thread of an
+// internal path fails with a *NotFoundError when executed externally. A path
+// longer than the maximum depth of the node is not traversed.
+func (m *matcher) resolve(node *Chord, path []string) (Thread, bool) {
+	if thread, ok := node.checkDepth(path); ok {
+		return thread, true
+	}
+	thread, ok := m.match(node, path)
+	if !ok {
+		return nil, false
+	}
+	if m.internal {
+		thread = internalThread(thread, path)
+	}
+	if m.deprecated != "" {
+		thread = withDeprecation(thread, path, m.deprecated)
+	}
+	if len(m.params) > 0 {
+		thread = withParams(thread, m.params)
+	}
+	return node.track(withRoute(thread, m.route), m.route), true
+}
+
+// match implements Match, recording the keys captured by parameter and wildcard
+// keys into params. When the path cannot be matched, the fallback thread of the
+// node is returned if set.
+func (m *matcher) match(node *Chord, path []string) (Thread, bool) {
+	if !m.abbrev && node.abbrev.Load() {
+		m.abbrev = true
+		defer func() { m.abbrev = false }()
+	}
+	var thread Thread
+	ok := false
+	// Limit case: no keys in path.
+	if len(path) > 0 {
+		thread, ok = m.matchStatic(node, path)
+		if !ok {
+			thread, ok = m.matchDynamic(node, path)
+		}
+		if !ok && m.abbrev {
+			if err := node.ambiguity(node.foldKey(path[0]), len(path) == 1); err != nil {
+				thread = func(*Input, *Output) error { return err }
+				m.route, ok = []string{path[0]}, true
+			}
+		}
+	}
+	if !ok && !m.strict {
+		thread, ok = node.FetchNotFound()
+		if ok {
+			thread = withUnmatched(thread, path)
+			m.route, m.internal, m.deprecated = nil, false, ""
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+	// Wrap the matched thread with the middleware of the current chord, so that
+	// outer chords wrap the middleware of the nested ones.
+	thread = WrapThreads(thread, node.FetchMiddlewares()...)
+	if fs := node.fetchPersistentFlags(); fs != nil {
+		thread = fs.Validate(thread)
+	}
+	if hook := node.fetchOnPanic(); hook != nil {
+		thread = recoverThread(thread, hook)
+	}
+	return thread, true
+}
+
+// matchStatic matches the first key of the path against the static keys of the
+// node, or against the key it is an alias or, when enabled, an abbreviation of.
+func (m *matcher) matchStatic(node *Chord, path []string) (Thread, bool) {
+	key := node.foldKey(path[0])
+	if k := node.unalias(key); k != key {
+		key = k
+	} else if m.abbrev {
+		key = node.expand(key, len(path) == 1)
+	}
+	// Leaf case: single key in path implies direct thread lookup.
+	if len(path) == 1 {
+		thread, ok := m.matchThread(node, key)
+		if ok {
+			m.route = []string{key}
+		}
+		return thread, ok
+	}
+	// Recursive case: traverse to the next chord in the path.
+	mt, ok := node.fetchMount(key)
+	if !ok {
+		return nil, false
+	}
+	thread, ok := m.match(mt.chord, path[1:])
+	if !ok {
+		return nil, false
+	}
+	m.route = append([]string{key}, m.route...)
+	m.internal = m.internal || mt.meta.Internal
+	if m.deprecated == "" {
+		m.deprecated = mt.meta.Deprecated
+	}
+	return node.enter(key, mt, thread), true
+}
+
+// matchThread returns the thread registered under key on the node for
+// matching, building it if registered by RegisterFactory. A disabled thread is
+// matched as a thread failing with a *DisabledError, unless the node has a
+// fallback to select instead.
+func (m *matcher) matchThread(node *Chord, key string) (Thread, bool) {
+	e, ok := node.fetchEntry(key)
+	if !ok {
+		return nil, false
+	}
+	m.internal, m.deprecated = e.meta.Internal, e.meta.Deprecated
+	if !e.disabled.Load() {
+		if e.factory != nil {
+			thread, err := e.factory.get()
+			if err != nil {
+				return func(*Input, *Output) error { return err }, true
+			}
+			return thread, true
+		}
+		return e.thread, true
+	}
+	if _, ok := node.FetchNotFound(); ok && !m.strict {
+		return nil, false
+	}
+	return func(*Input, *Output) error {
+		return &DisabledError{Key: key}
+	}, true
+}
+
+// matchDynamic matches the path against the parameter and wildcard keys of the
+// node, recording the captured keys into params. Parameter keys are preferred
+// over wildcard keys.
+func (m *matcher) matchDynamic(node *Chord, path []string) (Thread, bool) {
+	if len(path) == 1 {
+		if key, ok := dynamicKey(&node.threads, isParam); ok {
+			if thread, ok := m.matchThread(node, key); ok {
+				m.params[key[1:]] = path[0]
+				m.route = []string{key}
+				return thread, true
+			}
+		}
+	} else if key, ok := dynamicKey(&node.chords, isParam); ok {
+		if mt, ok := node.fetchMount(key); ok {
+			if thread, ok := m.match(mt.chord, path[1:]); ok {
+				m.params[key[1:]] = path[0]
+				m.route = append([]string{key}, m.route...)
+				m.internal = m.internal || mt.meta.Internal
+				if m.deprecated == "" {
+					m.deprecated = mt.meta.Deprecated
+				}
+				return node.enter(key, mt, thread), true
+			}
+		}
+	}
+	if key, ok := dynamicKey(&node.threads, isWildcard); ok {
+		if thread, ok := m.matchThread(node, key); ok {
+			m.params[wildcardName(key)] = strings.Join(path, "/")
+			m.route = []string{key}
+			return thread, true
+		}
+	}
+	return nil, false
+}
+
+// enter wraps the thread matched through the chord mounted under key with the
+// wrappers supplied at mount time, then with the chord wrappers of the node,
+// the first wrapper being the outermost.
+func (c *Chord) enter(key string, m *mount, thread Thread) Thread {
+	thread = WrapThreads(thread, m.wrappers...)
+	chord := m.chord
+	cw := c.FetchChordWrappers()
+	for i := len(cw) - 1; i >= 0; i-- {
+		if tw := cw[i](key, chord); tw != nil {
+			thread = tw(thread)
+		}
+	}
+	return thread
+}
+
+// withUnmatched wraps the thread so that it receives a copy of its input with
+// the unmatched keys prepended to Input.Args.
+func withUnmatched(thread Thread, path []string) Thread {
+	return func(in *Input, out *Output) error {
+		in2 := *in
+		in2.Args = make([]string, 0, len(path)+len(in.Args))
+		in2.Args = append(in2.Args, path...)
+		in2.Args = append(in2.Args, in.Args...)
+		return thread(&in2, out)
+	}
+}
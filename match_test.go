@@ -0,0 +1,90 @@
+package chord_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+func TestMatch(t *testing.T) {
+	root, sub := chord.NewChord(), chord.NewChord()
+	root.Register("status", echo("status"))
+	sub.Register("list", echo("list"))
+	root.Mount("users", sub)
+
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{[]string{"status"}, "status"},
+		{[]string{"users", "list"}, "list"},
+	}
+	for _, tt := range tests {
+		got, err := execute(t, root, tt.path, nil)
+		if err != nil || got != tt.want {
+			t.Errorf("Execute(%q) = %q, %v, want %q", tt.path, got, err, tt.want)
+		}
+	}
+	for _, path := range [][]string{{"missing"}, {"users", "missing"}, {"status", "extra"}, {}} {
+		_, err := execute(t, root, path, nil)
+		var nf *chord.NotFoundError
+		if !errors.As(err, &nf) || !errors.Is(err, chord.ErrNotFound) {
+			t.Errorf("Execute(%q) = %v, want a *NotFoundError", path, err)
+		}
+	}
+}
+
+func TestRegisterPattern(t *testing.T) {
+	c := chord.NewChord()
+	var params map[string]string
+	capture := func(in *chord.Input, out *chord.Output) error {
+		params = in.Params
+		return nil
+	}
+	if _, err := c.RegisterPattern("user/:id/show", capture); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.RegisterPattern("files/*rest", capture); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := execute(t, c, []string{"user", "42", "show"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if params["id"] != "42" {
+		t.Errorf("Params = %v, want id=42", params)
+	}
+	if _, err := execute(t, c, []string{"files", "a", "b"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if params["rest"] != "a/b" {
+		t.Errorf("Params = %v, want rest=a/b", params)
+	}
+}
+
+func TestRegisterPatternInvalid(t *testing.T) {
+	c := chord.NewChord()
+	for _, pattern := range []string{"", "/", "files/*/x"} {
+		if _, err := c.RegisterPattern(pattern, nop); !errors.Is(err, chord.ErrInvalidKey) {
+			t.Errorf("RegisterPattern(%q) = %v, want ErrInvalidKey", pattern, err)
+		}
+	}
+}
+
+func TestRoute(t *testing.T) {
+	root, sub := chord.NewChord(), chord.NewChord()
+	var route []string
+	sub.Register("list", func(in *chord.Input, out *chord.Output) error {
+		route = chord.Route(in)
+		return nil
+	})
+	root.Mount("users", sub)
+	if _, err := execute(t, root, []string{"users", "list"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(route, []string{"users", "list"}) {
+		t.Fatalf("Route = %q, want [users list]", route)
+	}
+}
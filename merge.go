@@ -0,0 +1,102 @@
+package chord
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ConflictPolicy selects how Merge handles a thread of the source tree whose
+// key is already registered at the same path of the destination tree.
+type ConflictPolicy int
+
+const (
+	// ConflictError fails the merge with a *DuplicateError.
+	ConflictError ConflictPolicy = iota
+	// ConflictSkip keeps the thread of the destination.
+	ConflictSkip
+	// ConflictOverwrite replaces the thread of the destination.
+	ConflictOverwrite
+	// ConflictRename registers the thread of the source under the first free
+	// key made of its key followed by a dash and a number, such as "status-2".
+	ConflictRename
+)
+
+// Merge registers the threads and mounts the chords of src on dst, such as to
+// compose the trees contributed by several modules or plugins. The chords
+// mounted under the same key in both trees are merged recursively, and the
+// other chords of src are mounted on dst as copies, made by Clone, so that dst
+// doesn't share chords with src. The threads registered under keys already
+// registered on dst are handled according to the policy. The threads merged
+// keep their metadata and wrappers, and are wrapped by the middleware of the
+// chords of dst; the middleware, aliases and settings of the chords of src
+// merged into existing chords are not merged. The keys are validated by the
+// chords of dst, as by Register: on failure, the threads and chords merged so
+// far are left in place.
+func Merge(dst, src *Chord, policy ConflictPolicy) error {
+	return merge(dst, src, nil, policy)
+}
+
+// merge implements Merge for the chords located at path in the trees.
+func merge(dst, src *Chord, path []string, policy ConflictPolicy) error {
+	dst.ensureLoaded()
+	src.ensureLoaded()
+	for _, key := range sortedKeys(&src.threads) {
+		v, ok := src.threads.Load(key)
+		if !ok {
+			continue
+		}
+		key, e := dst.foldKey(key), v.(*entry)
+		replace := false
+		if _, exists := dst.threads.Load(key); exists {
+			switch policy {
+			case ConflictSkip:
+				continue
+			case ConflictOverwrite:
+				replace = true
+			case ConflictRename:
+				key = dst.freeKey(key)
+			}
+		}
+		if err := dst.storeEntry(key, cloneEntry(dst, key, e), replace); err != nil {
+			return mergeError(path, err)
+		}
+	}
+	for _, key := range sortedKeys(&src.chords) {
+		v, ok := src.chords.Load(key)
+		if !ok {
+			continue
+		}
+		m := v.(*mount)
+		if sub, ok := dst.fetchMount(dst.foldKey(key)); ok {
+			if err := merge(sub.chord, m.chord, append(slices.Clip(path), key), policy); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := dst.MountWithMeta(key, m.chord.Clone(), m.meta, m.wrappers...); err != nil {
+			return mergeError(path, err)
+		}
+	}
+	return nil
+}
+
+// freeKey returns the first key made of key followed by a dash and a number,
+// from 2, under which no thread is registered on the chord.
+func (c *Chord) freeKey(key string) string {
+	for n := 2; ; n++ {
+		k := key + "-" + strconv.Itoa(n)
+		if _, ok := c.threads.Load(k); !ok {
+			return k
+		}
+	}
+}
+
+// mergeError reports the error of the merge of the chords located at path.
+func mergeError(path []string, err error) error {
+	if len(path) == 0 {
+		return err
+	}
+	return fmt.Errorf("chord: merging %q: %w", strings.Join(path, "/"), err)
+}
@@ -0,0 +1,167 @@
+package chord
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Meta describes a registered thread, for help generation, discovery and
+// filtering of the threads of a chord.
+type Meta struct {
+	Description string   `json:"description,omitempty"` // Short description of what the thread does.
+	Usage       string   `json:"usage,omitempty"`       // Usage line, such as "show <id> [--verbose]".
+	Examples    []string `json:"examples,omitempty"`    // Examples of invocation.
+	Tags        []string `json:"tags,omitempty"`        // Tags used to categorize and filter threads.
+	Flags       []Flag   `json:"flags,omitempty"`       // Flags accepted, such as the Flags of the FlagSet validating them.
+	Args        []Arg    `json:"args,omitempty"`        // Positional arguments accepted, in order.
+	Hidden      bool     `json:"hidden,omitempty"`      // Whether the thread is omitted from listings.
+	Internal    bool     `json:"internal,omitempty"`    // Whether the thread is only matched by programmatic dispatch, and omitted from listings.
+	Deprecated  string   `json:"deprecated,omitempty"`  // Deprecation notice, such as "use purge instead", marking the thread as deprecated.
+}
+
+// Arg describes a positional argument accepted by a thread.
+type Arg struct {
+	Name     string `json:"name"`
+	Usage    string `json:"usage,omitempty"`
+	Required bool   `json:"required,omitempty"`
+	Variadic bool   `json:"variadic,omitempty"` // Whether it takes all the remaining arguments.
+}
+
+// clone returns a copy of the metadata which doesn't share its slices.
+func (m Meta) clone() Meta {
+	m.Examples = slices.Clone(m.Examples)
+	m.Tags = slices.Clone(m.Tags)
+	m.Flags = slices.Clone(m.Flags)
+	m.Args = slices.Clone(m.Args)
+	return m
+}
+
+// HasTag reports whether the metadata holds the given tag.
+func (m Meta) HasTag(tag string) bool {
+	return slices.Contains(m.Tags, tag)
+}
+
+// RegisterWithMeta adds a thread to the threads map with the given key, storing
+// the metadata alongside it. Optionally, additional thread wrappers (middleware)
+// can be provided and are applied in FIFO order. The key is validated, and the
+// Registration returned, as by Register.
+func (c *Chord) RegisterWithMeta(key string, thread Thread, meta Meta, tw ...ThreadWrapper) (*Registration, error) {
+	if err := c.checkThread(key, thread == nil); err != nil {
+		return nil, err
+	}
+	return c.registration(key, WrapThreads(thread, tw...), meta, false)
+}
+
+// ReplaceWithMeta registers a thread under key with its metadata, as
+// RegisterWithMeta does, replacing the thread already registered under it, if
+// any.
+func (c *Chord) ReplaceWithMeta(key string, thread Thread, meta Meta, tw ...ThreadWrapper) (*Registration, error) {
+	if err := c.checkThread(key, thread == nil); err != nil {
+		return nil, err
+	}
+	return c.registration(key, WrapThreads(thread, tw...), meta, true)
+}
+
+// registration registers the thread under key and returns its Registration.
+func (c *Chord) registration(key string, thread Thread, meta Meta, replace bool) (*Registration, error) {
+	key = c.foldKey(key)
+	e, err := c.register(key, thread, meta, replace)
+	if err != nil {
+		return nil, err
+	}
+	return &Registration{c: c, key: key, e: e}, nil
+}
+
+// register validates the key and stores the entry of a thread under it,
+// replacing the entry already stored if replace is set, and returns the entry.
+func (c *Chord) register(key string, thread Thread, meta Meta, replace bool) (*entry, error) {
+	key = c.foldKey(key)
+	e := &entry{thread: thread, meta: meta.clone()}
+	if err := c.storeEntry(key, e, replace); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// storeEntry validates the key and stores the entry under it, replacing the
+// entry already stored if replace is set, or else failing with a
+// *DuplicateError, and notifies the registration. A strict chord also rejects
+// the keys of its mounted chords.
+func (c *Chord) storeEntry(key string, e *entry, replace bool) error {
+	if err := c.validateKey(key); err != nil {
+		return err
+	}
+	if err := c.checkShadow(key, false); err != nil {
+		return err
+	}
+	if replace {
+		c.threads.Store(key, e)
+	} else if _, loaded := c.threads.LoadOrStore(key, e); loaded {
+		return &DuplicateError{Key: key}
+	}
+	c.notify(EventRegister, key)
+	return nil
+}
+
+// FetchMeta retrieves the metadata of a thread using its key.
+// Returns the metadata and true if found, or a zero Meta and false otherwise.
+func (c *Chord) FetchMeta(key string) (Meta, bool) {
+	e, ok := c.fetchEntry(key)
+	if !ok {
+		return Meta{}, false
+	}
+
+	return e.meta.clone(), true
+}
+
+// MountWithMeta adds a composite chord (nested chord) to the chords map with the
+// given key, storing the metadata alongside it. Optionally, thread wrappers can
+// be provided and are applied as with Mount. The key is validated as by Mount.
+func (c *Chord) MountWithMeta(key string, chord *Chord, meta Meta, tw ...ThreadWrapper) error {
+	return c.mount(key, &mount{chord: chord, wrappers: tw, meta: meta.clone()}, false)
+}
+
+// ReplaceMountWithMeta mounts a chord under key with its metadata, as
+// MountWithMeta does, replacing the chord already mounted under it, if any.
+func (c *Chord) ReplaceMountWithMeta(key string, chord *Chord, meta Meta, tw ...ThreadWrapper) error {
+	return c.mount(key, &mount{chord: chord, wrappers: tw, meta: meta.clone()}, true)
+}
+
+// mount validates the key and stores the mount under it, replacing the mount
+// already stored if replace is set, or else failing with a *DuplicateError. A
+// nil chord is rejected with ErrNilChord, and a chord holding c with ErrCycle.
+// A strict chord also rejects the keys of its registered threads.
+func (c *Chord) mount(key string, m *mount, replace bool) error {
+	key = c.foldKey(key)
+	if err := c.validateKey(key); err != nil {
+		return err
+	}
+	if m.chord == nil {
+		return fmt.Errorf("%w mounted under %q", ErrNilChord, key)
+	}
+	if m.chord.reaches(c) {
+		return fmt.Errorf("%w: the chord mounted under %q holds the chord it is mounted on", ErrCycle, key)
+	}
+	if err := c.checkShadow(key, true); err != nil {
+		return err
+	}
+	if replace {
+		c.chords.Store(key, m)
+	} else if _, loaded := c.chords.LoadOrStore(key, m); loaded {
+		return &DuplicateError{Key: key, Mount: true}
+	}
+	m.chord.attach(c, key)
+	c.notify(EventMount, key)
+	return nil
+}
+
+// FetchChordMeta retrieves the metadata of a mounted chord using its key.
+// Returns the metadata and true if found, or a zero Meta and false otherwise.
+func (c *Chord) FetchChordMeta(key string) (Meta, bool) {
+	m, ok := c.fetchMount(key)
+	if !ok {
+		return Meta{}, false
+	}
+
+	return m.meta.clone(), true
+}
@@ -0,0 +1,132 @@
+/*
+Package metrics instruments the threads of a chord with Prometheus metrics.
+
+A single Use call on the root chord instruments all the threads matched
+through it, labeling the metrics with the route of the thread, such as
+"user/:id/show":
+
+	m := metrics.New(prometheus.DefaultRegisterer)
+	root.Use(m.Wrap)
+
+The metrics are:
+
+	chord_executions_total{path}            Executions of the threads.
+	chord_errors_total{path}                Executions which returned an error.
+	chord_timeouts_total{path}              Executions which exceeded their timeout.
+	chord_execution_duration_seconds{path}  Duration of the executions.
+	chord_executions_in_flight{path}        Executions running.
+	chord_deprecated_executions_total{path} Executions of deprecated threads.
+*/
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/graphitects/chord"
+)
+
+// Metrics holds the collectors instrumenting the threads of a chord.
+type Metrics struct {
+	executions *prometheus.CounterVec
+	errors     *prometheus.CounterVec
+	timeouts   *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+	inFlight   *prometheus.GaugeVec
+	deprecated *prometheus.CounterVec
+}
+
+// New returns metrics registered on reg, or unregistered if reg is nil, with
+// the default histogram buckets. It panics if the metrics are already
+// registered on reg.
+func New(reg prometheus.Registerer) *Metrics {
+	return NewWithBuckets(reg, prometheus.DefBuckets)
+}
+
+// NewWithBuckets returns metrics as New does, with the given buckets for the
+// histogram of the durations, in seconds.
+func NewWithBuckets(reg prometheus.Registerer, buckets []float64) *Metrics {
+	labels := []string{"path"}
+	m := &Metrics{
+		executions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chord_executions_total",
+			Help: "Executions of the threads of the chord.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chord_errors_total",
+			Help: "Executions of the threads of the chord which returned an error.",
+		}, labels),
+		timeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chord_timeouts_total",
+			Help: "Executions of the threads of the chord which exceeded their timeout.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "chord_execution_duration_seconds",
+			Help:    "Duration of the executions of the threads of the chord.",
+			Buckets: buckets,
+		}, labels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "chord_executions_in_flight",
+			Help: "Executions of the threads of the chord running.",
+		}, labels),
+		deprecated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chord_deprecated_executions_total",
+			Help: "Executions of the deprecated threads of the chord.",
+		}, labels),
+	}
+	if reg != nil {
+		reg.MustRegister(m)
+	}
+	return m
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.executions.Describe(ch)
+	m.errors.Describe(ch)
+	m.timeouts.Describe(ch)
+	m.duration.Describe(ch)
+	m.inFlight.Describe(ch)
+	m.deprecated.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.executions.Collect(ch)
+	m.errors.Collect(ch)
+	m.timeouts.Collect(ch)
+	m.duration.Collect(ch)
+	m.inFlight.Collect(ch)
+	m.deprecated.Collect(ch)
+}
+
+// Wrap is a ThreadWrapper recording the executions of the thread, labeled by
+// its route as reported by chord.Route, joined with "/". The executions of the
+// threads deprecated by their metadata, as reported by chord.DeprecationOf, are
+// counted apart, as are the executions which returned a *chord.TimeoutError.
+func (m *Metrics) Wrap(next chord.Thread) chord.Thread {
+	return func(in *chord.Input, out *chord.Output) error {
+		path := strings.Join(chord.Route(in), "/")
+		if _, ok := chord.DeprecationOf(in); ok {
+			m.deprecated.WithLabelValues(path).Inc()
+		}
+		inFlight := m.inFlight.WithLabelValues(path)
+		inFlight.Inc()
+		defer inFlight.Dec()
+		start := time.Now()
+		err := next(in, out)
+		m.duration.WithLabelValues(path).Observe(time.Since(start).Seconds())
+		m.executions.WithLabelValues(path).Inc()
+		if err != nil {
+			m.errors.WithLabelValues(path).Inc()
+		}
+		var te *chord.TimeoutError
+		if errors.As(err, &te) {
+			m.timeouts.WithLabelValues(path).Inc()
+		}
+		return err
+	}
+}
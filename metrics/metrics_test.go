@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/graphitects/chord"
+)
+
+func TestWrap(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := New(reg)
+	root, users := chord.NewChord(), chord.NewChord()
+	root.Use(m.Wrap)
+	users.Register("show", func(*chord.Input, *chord.Output) error { return nil })
+	users.Register("ban", func(*chord.Input, *chord.Output) error { return errors.New("failed") })
+	root.Mount("users", users)
+
+	root.Execute([]string{"users", "show"}, &chord.Input{}, nil)
+	root.Execute([]string{"users", "show"}, &chord.Input{}, nil)
+	root.Execute([]string{"users", "ban"}, &chord.Input{}, nil)
+
+	if n := testutil.ToFloat64(m.executions.WithLabelValues("users/show")); n != 2 {
+		t.Errorf("executions of users/show = %v, want 2", n)
+	}
+	if n := testutil.ToFloat64(m.errors.WithLabelValues("users/show")); n != 0 {
+		t.Errorf("errors of users/show = %v, want 0", n)
+	}
+	if n := testutil.ToFloat64(m.errors.WithLabelValues("users/ban")); n != 1 {
+		t.Errorf("errors of users/ban = %v, want 1", n)
+	}
+	if n := testutil.CollectAndCount(reg, "chord_execution_duration_seconds"); n != 2 {
+		t.Errorf("duration series = %d, want 2", n)
+	}
+}
+
+func TestWrapTimeout(t *testing.T) {
+	m := New(nil)
+	root := chord.NewChord()
+	root.Use(m.Wrap)
+	root.Register("slow", func(in *chord.Input, out *chord.Output) error {
+		<-in.Context().Done()
+		return in.Context().Err()
+	}, chord.Timeout(time.Millisecond))
+
+	root.Execute([]string{"slow"}, &chord.Input{}, nil)
+	if n := testutil.ToFloat64(m.timeouts.WithLabelValues("slow")); n != 1 {
+		t.Errorf("timeouts of slow = %v, want 1", n)
+	}
+}
@@ -0,0 +1,87 @@
+package chord
+
+// UseNamed registers a thread wrapper under a name at the end of the chord's
+// middleware chain. Named wrappers can later be removed, replaced or moved,
+// so that long-lived chords can modify their middleware chain at runtime.
+// Names are expected to be unique; the methods below act on the first wrapper
+// registered under a name, and never on the unnamed wrappers registered
+// through Use, which the empty name doesn't select.
+func (c *Chord) UseNamed(name string, tw ThreadWrapper) {
+	c.mu.Lock()
+	c.middlewares = append(c.middlewares, middleware{name: name, wrapper: tw})
+	c.mu.Unlock()
+	c.notify(EventUse, "")
+}
+
+// FetchMiddlewareNames returns the names of the wrappers of the middleware
+// chain, in order. Wrappers registered through Use have an empty name.
+func (c *Chord) FetchMiddlewareNames() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, len(c.middlewares))
+	for i, m := range c.middlewares {
+		names[i] = m.name
+	}
+	return names
+}
+
+// RemoveMiddleware removes the wrapper registered under the name from the
+// middleware chain. It reports whether such a wrapper was found.
+func (c *Chord) RemoveMiddleware(name string) bool {
+	return c.editMiddleware(name, func(i int) {
+		c.middlewares = append(c.middlewares[:i:i], c.middlewares[i+1:]...)
+	})
+}
+
+// ReplaceMiddleware replaces the wrapper registered under the name, keeping its
+// position in the middleware chain. It reports whether such a wrapper was found.
+func (c *Chord) ReplaceMiddleware(name string, tw ThreadWrapper) bool {
+	return c.editMiddleware(name, func(i int) {
+		c.middlewares[i].wrapper = tw
+	})
+}
+
+// MoveMiddleware moves the wrapper registered under the name to the given index
+// of the middleware chain, the index being clamped to the bounds of the chain.
+// It reports whether such a wrapper was found.
+func (c *Chord) MoveMiddleware(name string, index int) bool {
+	return c.editMiddleware(name, func(i int) {
+		m := c.middlewares[i]
+		md := append(c.middlewares[:i:i], c.middlewares[i+1:]...)
+		index = max(0, min(index, len(md)))
+		md = append(md[:index:index], append([]middleware{m}, md[index:]...)...)
+		c.middlewares = md
+	})
+}
+
+// editMiddleware calls edit, under the lock of the chord, with the index of
+// the first wrapper registered under the name, then notifies the observers.
+// It reports whether such a wrapper was found.
+func (c *Chord) editMiddleware(name string, edit func(i int)) bool {
+	c.mu.Lock()
+	i := c.middlewareIndex(name)
+	if i >= 0 {
+		edit(i)
+	}
+	c.mu.Unlock()
+	if i < 0 {
+		return false
+	}
+	c.notify(EventUse, "")
+	return true
+}
+
+// middlewareIndex returns the index of the first wrapper registered under the
+// name, or -1 if none or if the name is empty. The caller must hold the lock
+// of the chord.
+func (c *Chord) middlewareIndex(name string) int {
+	if name == "" {
+		return -1
+	}
+	for i, m := range c.middlewares {
+		if m.name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,80 @@
+package chord_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+// trace returns a wrapper appending its name to the log before invoking the
+// thread.
+func trace(log *[]string, name string) chord.ThreadWrapper {
+	return func(next chord.Thread) chord.Thread {
+		return func(in *chord.Input, out *chord.Output) error {
+			*log = append(*log, name)
+			return next(in, out)
+		}
+	}
+}
+
+func TestWrapThreadsOrder(t *testing.T) {
+	var log []string
+	thread := chord.WrapThreads(func(*chord.Input, *chord.Output) error {
+		log = append(log, "thread")
+		return nil
+	}, trace(&log, "first"), trace(&log, "second"))
+	thread(&chord.Input{}, nil)
+	if want := []string{"first", "second", "thread"}; !slices.Equal(log, want) {
+		t.Fatalf("order = %q, want %q", log, want)
+	}
+}
+
+func TestMiddlewareOrderAcrossChords(t *testing.T) {
+	var log []string
+	root, sub := chord.NewChord(), chord.NewChord()
+	root.Use(trace(&log, "root"))
+	sub.Use(trace(&log, "sub"))
+	sub.Register("x", func(*chord.Input, *chord.Output) error {
+		log = append(log, "thread")
+		return nil
+	}, trace(&log, "thread wrapper"))
+	root.Mount("sub", sub, trace(&log, "mount"))
+
+	if _, err := execute(t, root, []string{"sub", "x"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(log) == 0 || log[0] != "root" || log[len(log)-1] != "thread" {
+		t.Fatalf("order = %q, want the root middleware first and the thread last", log)
+	}
+	if i, j := slices.Index(log, "root"), slices.Index(log, "sub"); i > j {
+		t.Fatalf("order = %q, want the root middleware before the nested one", log)
+	}
+	for _, name := range []string{"sub", "mount", "thread wrapper"} {
+		if !slices.Contains(log, name) {
+			t.Errorf("order = %q, missing %q", log, name)
+		}
+	}
+}
+
+func TestNamedMiddleware(t *testing.T) {
+	var log []string
+	c := chord.NewChord()
+	c.Use(trace(&log, "anonymous"))
+	c.UseNamed("a", trace(&log, "a"))
+	c.UseNamed("b", trace(&log, "b"))
+	c.Register("x", nop)
+
+	if c.RemoveMiddleware("") || c.ReplaceMiddleware("", trace(&log, "z")) || c.MoveMiddleware("", 0) {
+		t.Fatal("the empty name selected an unnamed wrapper")
+	}
+	if !c.MoveMiddleware("b", 0) || !c.RemoveMiddleware("a") {
+		t.Fatal("named wrappers not found")
+	}
+	if _, err := execute(t, c, []string{"x"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b", "anonymous"}; !slices.Equal(log, want) {
+		t.Fatalf("order = %q, want %q", log, want)
+	}
+}
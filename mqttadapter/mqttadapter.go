@@ -0,0 +1,179 @@
+/*
+Package mqttadapter dispatches MQTT messages through a chord tree.
+
+The topic of a message is mapped to the path of the thread by removing the
+topic prefix of the adapter and splitting it on slashes, so that a message
+published to "chord/in/devices/42/reboot" executes the thread registered under
+["devices", "42", "reboot"]. The payload of the message is parsed as a command
+line with chord.ParseLine, its tokens becoming the arguments and flags of the
+input, and is also available, raw, to the reader side of the output. The output
+of the thread is published to the same path under the response prefix, and
+errors under the error prefix.
+*/
+package mqttadapter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/graphitects/chord"
+)
+
+// Adapter subscribes to the topics under a prefix and dispatches their
+// messages through a root chord.
+type Adapter struct {
+	// Prefix is the topic prefix of the commands. It defaults to "chord/in".
+	Prefix string
+	// ResponsePrefix is the topic prefix of the outputs. It defaults to "chord/out".
+	ResponsePrefix string
+	// ErrorPrefix is the topic prefix of the errors. It defaults to "chord/err".
+	ErrorPrefix string
+	// QoS is the quality of service of the subscription and of the publications.
+	QoS byte
+	// Retained sets the retained flag of the publications.
+	Retained bool
+	// OnError, when set, is called with the errors of the publications.
+	OnError func(error)
+
+	client mqtt.Client
+	root   *chord.Chord
+
+	// topics are the thread wrappers applied to the threads dispatched from
+	// the messages of the topics matching their filters.
+	topics []topicWrappers
+	mu     sync.RWMutex
+}
+
+// topicWrappers are thread wrappers registered for a topic filter.
+type topicWrappers struct {
+	filter   []string
+	wrappers []chord.ThreadWrapper
+}
+
+// New returns an Adapter dispatching the messages received by the connected
+// client through root.
+func New(client mqtt.Client, root *chord.Chord) *Adapter {
+	return &Adapter{
+		Prefix:         "chord/in",
+		ResponsePrefix: "chord/out",
+		ErrorPrefix:    "chord/err",
+		client:         client,
+		root:           root,
+	}
+}
+
+// UseTopic registers thread wrappers (middleware) applied, in FIFO order, to the
+// threads dispatched from the messages of the topics matching the filter. The
+// filter is relative to the prefix and may contain the "+" and "#" wildcards
+// of MQTT, such as "devices/+/reboot". The wrappers of the filters registered
+// first are the outermost.
+func (a *Adapter) UseTopic(filter string, tw ...chord.ThreadWrapper) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.topics = append(a.topics, topicWrappers{filter: strings.Split(filter, "/"), wrappers: tw})
+}
+
+// wrappers returns the thread wrappers of the filters matching the path.
+func (a *Adapter) wrappers(path []string) []chord.ThreadWrapper {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var tw []chord.ThreadWrapper
+	for _, t := range a.topics {
+		if matchFilter(t.filter, path) {
+			tw = append(tw, t.wrappers...)
+		}
+	}
+	return tw
+}
+
+// matchFilter reports whether the levels of a topic match those of a filter.
+func matchFilter(filter, topic []string) bool {
+	for i, f := range filter {
+		if f == "#" {
+			return true
+		}
+		if i >= len(topic) || (f != "+" && f != topic[i]) {
+			return false
+		}
+	}
+	return len(filter) == len(topic)
+}
+
+// Run subscribes to the topics under the prefix and dispatches their messages
+// until the context is done, then unsubscribes. Messages are handled
+// concurrently, with contexts derived from ctx.
+func (a *Adapter) Run(ctx context.Context) error {
+	topic := a.Prefix + "/#"
+	token := a.client.Subscribe(topic, a.QoS, func(_ mqtt.Client, msg mqtt.Message) {
+		go a.Handle(ctx, msg.Topic(), msg.Payload())
+	})
+	if err := wait(ctx, token); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	a.client.Unsubscribe(topic)
+	return ctx.Err()
+}
+
+// Handle dispatches a message of the topic through the root chord and publishes
+// the output, or the error, of the thread. Messages of topics outside of the
+// prefix are ignored.
+func (a *Adapter) Handle(ctx context.Context, topic string, payload []byte) {
+	rest, ok := strings.CutPrefix(topic, a.Prefix+"/")
+	if !ok || rest == "" {
+		return
+	}
+	path := strings.Split(rest, "/")
+	out, err := a.dispatch(ctx, path, payload)
+	if len(out) > 0 {
+		a.publish(ctx, a.ResponsePrefix+"/"+rest, out)
+	}
+	if err != nil {
+		a.publish(ctx, a.ErrorPrefix+"/"+rest, []byte(err.Error()))
+	}
+}
+
+// dispatch executes the thread of the path with the payload and returns its output.
+func (a *Adapter) dispatch(ctx context.Context, path []string, payload []byte) ([]byte, error) {
+	args, in, err := chord.ParseLine(string(payload))
+	if err != nil {
+		return nil, err
+	}
+	thread, ok := chord.Match(a.root, path)
+	if !ok {
+		return nil, &chord.NotFoundError{Path: path, Suggestions: a.root.Suggest(path)}
+	}
+	thread = chord.WrapThreads(thread, a.wrappers(path)...)
+	in.Key = strings.Join(path, " ")
+	in.Args = slices.Concat(args, in.Args)
+
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(payload)), bw)}
+	err = thread(in.WithContext(chord.WithExternal(ctx)), out)
+	bw.Flush()
+	return buf.Bytes(), err
+}
+
+// publish publishes the payload to the topic, reporting errors to OnError.
+func (a *Adapter) publish(ctx context.Context, topic string, payload []byte) {
+	err := wait(ctx, a.client.Publish(topic, a.QoS, a.Retained, payload))
+	if err != nil && a.OnError != nil {
+		a.OnError(err)
+	}
+}
+
+// wait waits for the completion of the token, or for the context to be done.
+func wait(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,90 @@
+package mqttadapter
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/graphitects/chord"
+)
+
+// client is an mqtt.Client recording its publications. Its other methods
+// aren't implemented.
+type client struct {
+	mqtt.Client
+	published map[string]string
+	mu        sync.Mutex
+}
+
+func (c *client) Publish(topic string, qos byte, retained bool, payload any) mqtt.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.published[topic] = string(payload.([]byte))
+	return doneToken{}
+}
+
+// doneToken is a completed mqtt.Token.
+type doneToken struct{}
+
+func (doneToken) Wait() bool                     { return true }
+func (doneToken) WaitTimeout(time.Duration) bool { return true }
+func (doneToken) Done() <-chan struct{}          { ch := make(chan struct{}); close(ch); return ch }
+func (doneToken) Error() error                   { return nil }
+
+func newAdapter() (*Adapter, *client) {
+	root, devices := chord.NewChord(), chord.NewChord()
+	devices.RegisterPattern(":id/reboot", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString("rebooting " + in.Params["id"] + " in " + in.Flags["delay"] + " " + strings.Join(in.Args, " "))
+		return out.Flush()
+	})
+	root.Mount("devices", devices)
+	c := &client{published: make(map[string]string)}
+	return New(c, root), c
+}
+
+func TestHandle(t *testing.T) {
+	a, c := newAdapter()
+	a.Handle(context.Background(), "chord/in/devices/42/reboot", []byte("now --delay=5s"))
+	a.Handle(context.Background(), "chord/in/missing", nil)
+	a.Handle(context.Background(), "other/devices/42/reboot", nil)
+
+	want := map[string]string{
+		"chord/out/devices/42/reboot": "rebooting 42 in 5s now",
+		"chord/err/missing":           `chord: no thread found for path "missing"`,
+	}
+	if len(c.published) != len(want) {
+		t.Fatalf("published = %q, want %q", c.published, want)
+	}
+	for topic, payload := range want {
+		if got := c.published[topic]; got != payload {
+			t.Errorf("payload of %s = %q, want %q", topic, got, payload)
+		}
+	}
+}
+
+func TestUseTopic(t *testing.T) {
+	a, c := newAdapter()
+	var wrapped []string
+	trace := func(name string) chord.ThreadWrapper {
+		return func(next chord.Thread) chord.Thread {
+			return func(in *chord.Input, out *chord.Output) error {
+				wrapped = append(wrapped, name)
+				return next(in, out)
+			}
+		}
+	}
+	a.UseTopic("devices/#", trace("all"))
+	a.UseTopic("devices/+/reboot", trace("reboot"))
+	a.UseTopic("devices/7/+", trace("other"))
+	a.Handle(context.Background(), "chord/in/devices/42/reboot", nil)
+	if got := strings.Join(wrapped, " "); got != "all reboot" {
+		t.Fatalf("wrappers = %q, want %q", got, "all reboot")
+	}
+	if _, ok := c.published["chord/out/devices/42/reboot"]; !ok {
+		t.Fatalf("published = %q, want the output of the thread", c.published)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Package natsadapter serves a chord tree over NATS.
+
+The subject of a message is mapped to the path of the thread by removing the
+subject prefix of the adapter and splitting it into tokens, so that a request
+on "chord.user.42.show" executes the thread registered under
+["user", "42", "show"]. The payload of the message is parsed as a command line
+with chord.ParseLine, its tokens becoming the arguments and flags of the input,
+and is also available, raw, to the reader side of the output. The output of the
+thread is sent as the reply of the request; errors are reported in the
+ErrorHeader and ExitCodeHeader headers of the reply. The chord.CorrelationIDHeader
+header of a request, when set, is passed as the chord.CorrelationIDFlag flag.
+*/
+package natsadapter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/graphitects/chord"
+	"github.com/nats-io/nats.go"
+)
+
+// Headers of the replies of failed threads.
+const (
+	ErrorHeader    = "Chord-Error"     // Message of the error.
+	ExitCodeHeader = "Chord-Exit-Code" // Exit code of the error, as reported by chord.ExitCode.
+)
+
+// Adapter subscribes to the subjects under a prefix and dispatches their
+// messages through a root chord.
+type Adapter struct {
+	// Prefix is the subject prefix of the requests. It defaults to "chord".
+	Prefix string
+	// Queue, when set, is the queue group of the subscription, so that the
+	// requests are balanced between the instances of a service.
+	Queue string
+	// OnError, when set, is called with the errors of the replies.
+	OnError func(error)
+
+	conn *nats.Conn
+	root *chord.Chord
+}
+
+// New returns an Adapter dispatching the messages received by the connection
+// through root.
+func New(conn *nats.Conn, root *chord.Chord) *Adapter {
+	return &Adapter{Prefix: "chord", conn: conn, root: root}
+}
+
+// Run subscribes to the subjects under the prefix and dispatches their messages
+// until the context is done, then drains the subscription. Messages are
+// handled concurrently, with contexts derived from ctx.
+func (a *Adapter) Run(ctx context.Context) error {
+	handler := func(msg *nats.Msg) {
+		go a.Handle(ctx, msg)
+	}
+	var sub *nats.Subscription
+	var err error
+	if a.Queue != "" {
+		sub, err = a.conn.QueueSubscribe(a.Prefix+".>", a.Queue, handler)
+	} else {
+		sub, err = a.conn.Subscribe(a.Prefix+".>", handler)
+	}
+	if err != nil {
+		return err
+	}
+	<-ctx.Done()
+	sub.Drain()
+	return ctx.Err()
+}
+
+// Handle dispatches the message through the root chord and replies with the
+// output of the thread, if the message has a reply subject. Messages of
+// subjects outside of the prefix are ignored.
+func (a *Adapter) Handle(ctx context.Context, msg *nats.Msg) {
+	rest, ok := strings.CutPrefix(msg.Subject, a.Prefix+".")
+	if !ok || rest == "" {
+		return
+	}
+	out, err := a.dispatch(ctx, strings.Split(rest, "."), msg.Data, msg.Header.Get(chord.CorrelationIDHeader))
+	if msg.Reply == "" {
+		return
+	}
+	reply := &nats.Msg{Subject: msg.Reply, Data: out, Header: nats.Header{}}
+	if err != nil {
+		reply.Header.Set(ErrorHeader, err.Error())
+		reply.Header.Set(ExitCodeHeader, strconv.Itoa(chord.ExitCode(err)))
+	}
+	if err := a.conn.PublishMsg(reply); err != nil && a.OnError != nil {
+		a.OnError(err)
+	}
+}
+
+// dispatch executes the thread of the path with the payload and returns its
+// output. The correlation ID, if not empty, is passed as the
+// chord.CorrelationIDFlag flag unless the payload sets it.
+func (a *Adapter) dispatch(ctx context.Context, path []string, payload []byte, correlationID string) ([]byte, error) {
+	args, in, err := chord.ParseLine(string(payload))
+	if err != nil {
+		return nil, err
+	}
+	thread, ok := chord.Match(a.root, path)
+	if !ok {
+		return nil, &chord.NotFoundError{Path: path, Suggestions: a.root.Suggest(path)}
+	}
+	in.Key = strings.Join(path, " ")
+	in.Args = slices.Concat(args, in.Args)
+	if correlationID != "" && in.Flags[chord.CorrelationIDFlag] == "" {
+		if in.Flags == nil {
+			in.Flags = make(map[string]string)
+		}
+		in.Flags[chord.CorrelationIDFlag] = correlationID
+	}
+
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(payload)), bw)}
+	err = thread(in.WithContext(chord.WithExternal(ctx)), out)
+	bw.Flush()
+	return buf.Bytes(), err
+}
+
+// ReplyError returns the error reported in the headers of a reply, or nil if
+// the thread succeeded. The error implements chord.ExitCoder.
+func ReplyError(msg *nats.Msg) error {
+	text := msg.Header.Get(ErrorHeader)
+	if text == "" {
+		return nil
+	}
+	code, err := strconv.Atoi(msg.Header.Get(ExitCodeHeader))
+	if err != nil {
+		code = chord.ExitError
+	}
+	return &replyError{msg: text, code: code}
+}
+
+// replyError is an error reported in the headers of a reply.
+type replyError struct {
+	msg  string
+	code int
+}
+
+// Error implements the error interface.
+func (e *replyError) Error() string { return e.msg }
+
+// ExitCode implements chord.ExitCoder.
+func (e *replyError) ExitCode() int { return e.code }
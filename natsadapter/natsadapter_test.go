@@ -0,0 +1,49 @@
+package natsadapter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/graphitects/chord"
+)
+
+func newAdapter() *Adapter {
+	root, user := chord.NewChord(), chord.NewChord()
+	user.RegisterPattern(":id/show", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString(in.Params["id"] + " " + strings.Join(in.Args, " ") + " " + in.Flags[chord.CorrelationIDFlag])
+		return out.Flush()
+	})
+	root.Mount("user", user)
+	return New(nil, root)
+}
+
+func TestDispatch(t *testing.T) {
+	a := newAdapter()
+	out, err := a.dispatch(context.Background(), []string{"user", "42", "show"}, []byte("full"), "abc")
+	if err != nil || string(out) != "42 full abc" {
+		t.Fatalf("output = %q, %v, want %q", out, err, "42 full abc")
+	}
+	out, err = a.dispatch(context.Background(), []string{"user", "42", "show"}, []byte("--"+chord.CorrelationIDFlag+"=def"), "abc")
+	if err != nil || string(out) != "42  def" {
+		t.Fatalf("output = %q, %v, want the correlation ID of the payload", out, err)
+	}
+	if _, err := a.dispatch(context.Background(), []string{"missing"}, nil, ""); chord.ExitCode(err) != chord.ExitUsage {
+		t.Fatalf("error = %v, want a not found error", err)
+	}
+}
+
+func TestReplyError(t *testing.T) {
+	msg := &nats.Msg{Header: nats.Header{}}
+	if err := ReplyError(msg); err != nil {
+		t.Fatalf("ReplyError of a success = %v, want nil", err)
+	}
+	msg.Header.Set(ErrorHeader, "forbidden")
+	msg.Header.Set(ExitCodeHeader, "77")
+	err := ReplyError(msg)
+	if err == nil || err.Error() != "forbidden" || chord.ExitCode(err) != chord.ExitForbidden {
+		t.Fatalf("ReplyError = %v with exit code %d, want forbidden with %d", err, chord.ExitCode(err), chord.ExitForbidden)
+	}
+}
@@ -0,0 +1,40 @@
+package chord
+
+import "sync"
+
+// RegisterOnce registers a thread under key, as Register does, which is
+// unregistered after its first successful execution, such as for one-shot
+// setup or confirmation flows. The executions are serialized, so that the
+// thread succeeds at most once even under concurrent dispatch: the executions
+// failing are retried by the next ones, and those starting after the success,
+// matched before the unregistration, fail with a *NotFoundError without
+// invoking the thread. The key is validated, and duplicates rejected, as by
+// Register, which the returned Registration also mirrors: it reports the
+// thread inactive once unregistered by its success.
+func (c *Chord) RegisterOnce(key string, thread Thread, tw ...ThreadWrapper) (*Registration, error) {
+	key = c.foldKey(key)
+	if err := c.checkThread(key, thread == nil); err != nil {
+		return nil, err
+	}
+	thread = WrapThreads(thread, tw...)
+	var done bool
+	var mu sync.Mutex
+	e := &entry{}
+	e.thread = func(in *Input, out *Output) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			return &NotFoundError{Path: []string{key}}
+		}
+		if err := thread(in, out); err != nil {
+			return err
+		}
+		done = true
+		c.unregister(key, e)
+		return nil
+	}
+	if err := c.storeEntry(key, e, false); err != nil {
+		return nil, err
+	}
+	return &Registration{c: c, key: key, e: e}, nil
+}
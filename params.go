@@ -0,0 +1,99 @@
+package chord
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// isParam reports whether the key is a parameter key, such as ":id", which
+// matches any single key of a path.
+func isParam(key string) bool {
+	return len(key) > 1 && key[0] == ':'
+}
+
+// isWildcard reports whether the key is a wildcard key, such as "*" or "*rest",
+// which matches all the remaining keys of a path.
+func isWildcard(key string) bool {
+	return len(key) > 0 && key[0] == '*'
+}
+
+// wildcardName returns the name under which a wildcard key is captured into
+// Input.Params. An anonymous wildcard is captured as "*".
+func wildcardName(key string) string {
+	if len(key) == 1 {
+		return key
+	}
+	return key[1:]
+}
+
+// RegisterPattern registers a thread under a slash-separated pattern such as
+// "user/:id/show" or "files/*", mounting a new chord for every intermediate key
+// not mounted yet. Keys starting with ':' are parameter keys matching any single
+// key of a path, and a final key starting with '*' is a wildcard matching all
+// the remaining keys of a path. The captured values are made available to the
+// thread through Input.Params, and the Registration of the thread is returned.
+// The chords mounted inherit the KeyFold, the strict mode and the key validator
+// of their parent. The keys are validated as by Register: the chords mounted
+// before a key is rejected are left in place. An empty pattern, or a wildcard
+// before the last key, is rejected with a *KeyError.
+func (c *Chord) RegisterPattern(pattern string, thread Thread, tw ...ThreadWrapper) (*Registration, error) {
+	keys := strings.FieldsFunc(pattern, func(r rune) bool { return r == '/' })
+	if len(keys) == 0 {
+		return nil, &KeyError{Key: pattern, Reason: "empty pattern"}
+	}
+	node := c
+	for _, key := range keys[:len(keys)-1] {
+		if isWildcard(key) {
+			return nil, &KeyError{Key: key, Reason: "wildcard must be the last key of pattern " + strconv.Quote(pattern)}
+		}
+		key = node.foldKey(key)
+		if err := node.validateKey(key); err != nil {
+			return nil, err
+		}
+		sub := NewChord()
+		sub.keyFold.Store(node.keyFold.Load())
+		sub.strict.Store(node.strict.Load())
+		node.mu.RLock()
+		sub.keyValidator = node.keyValidator
+		node.mu.RUnlock()
+		m, loaded := node.chords.LoadOrStore(key, &mount{chord: sub})
+		if !loaded {
+			sub.attach(node, key)
+			node.notify(EventMount, key)
+		}
+		node = m.(*mount).chord
+	}
+	return node.Register(keys[len(keys)-1], thread, tw...)
+}
+
+// dynamicKey returns the lowest key of the map satisfying the predicate, so
+// that the choice among several parameter or wildcard keys is deterministic.
+func dynamicKey(m *sync.Map, pred func(string) bool) (string, bool) {
+	var found string
+	var ok bool
+	m.Range(func(k, _ any) bool {
+		key := k.(string)
+		if pred(key) && (!ok || key < found) {
+			found, ok = key, true
+		}
+		return true
+	})
+	return found, ok
+}
+
+// withParams wraps the thread so that it receives a copy of its input with the
+// captured params merged into Input.Params.
+func withParams(thread Thread, params map[string]string) Thread {
+	return func(in *Input, out *Output) error {
+		in2 := *in
+		in2.Params = make(map[string]string, len(in.Params)+len(params))
+		for k, v := range in.Params {
+			in2.Params[k] = v
+		}
+		for k, v := range params {
+			in2.Params[k] = v
+		}
+		return thread(&in2, out)
+	}
+}
@@ -0,0 +1,132 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrSyntax is the error matched by errors.Is when a line can't be tokenized.
+var ErrSyntax = errors.New("chord: syntax error")
+
+// ParseLine splits a raw command line into a path for Match and an Input.
+// Tokens are separated by whitespace, with shell-style quoting: single quotes
+// preserve their content literally, double quotes allow backslash escapes of
+// '"' and '\' only, keeping the backslash before any other character, as in
+// "C:\Users", and a backslash outside quotes escapes the next character.
+//
+// Unquoted tokens of the form --name=value, --name, -n=value or -n are flags;
+// a flag without a value gets an empty value, which boolean flags read as true.
+// Other tokens form the path, up to a bare "--" token after which all tokens
+// are positional arguments stored in Input.Args. The Key of the input is the
+// path joined by spaces.
+func ParseLine(line string) ([]string, *Input, error) {
+	tokens, err := tokenize(line)
+	if err != nil {
+		return nil, nil, err
+	}
+	path, in := parseTokens(tokens)
+	return path, in, nil
+}
+
+// parseTokens separates the flags, the path and the positional arguments of
+// the tokens of a command line, as documented by ParseLine.
+func parseTokens(tokens []token) ([]string, *Input) {
+	var path []string
+	in := &Input{Flags: make(map[string]string)}
+	for i, tok := range tokens {
+		if !tok.quoted && tok.text == "--" {
+			for _, arg := range tokens[i+1:] {
+				in.Args = append(in.Args, arg.text)
+			}
+			break
+		}
+		if name, value, ok := parseFlagToken(tok); ok {
+			in.Flags[name] = value
+			continue
+		}
+		path = append(path, tok.text)
+	}
+	in.Key = strings.Join(path, " ")
+	return path, in
+}
+
+// token is a word of a command line.
+type token struct {
+	text   string
+	quoted bool // Whether any part of the token was quoted or escaped.
+}
+
+// tokenize splits a command line into words, removing quotes and escapes.
+func tokenize(line string) ([]token, error) {
+	var tokens []token
+	var b strings.Builder
+	var inToken, quoted bool
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' {
+				// Within double quotes, the backslash only escapes the quote
+				// and itself, as in POSIX shells.
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				b.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				b.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inToken, quoted = r, true, true
+		case r == '\\':
+			escaped, inToken, quoted = true, true, true
+		case unicode.IsSpace(r):
+			if inToken {
+				tokens = append(tokens, token{text: b.String(), quoted: quoted})
+				b.Reset()
+				inToken, quoted = false, false
+			}
+		default:
+			b.WriteRune(r)
+			inToken = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("%w: unterminated %c quote", ErrSyntax, quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("%w: trailing backslash", ErrSyntax)
+	}
+	if inToken {
+		tokens = append(tokens, token{text: b.String(), quoted: quoted})
+	}
+	return tokens, nil
+}
+
+// parseFlagToken parses an unquoted flag token into its name and value.
+// Tokens such as "-" or negative numbers are not flags.
+func parseFlagToken(tok token) (name, value string, ok bool) {
+	if tok.quoted || len(tok.text) < 2 || tok.text[0] != '-' {
+		return "", "", false
+	}
+	name = strings.TrimPrefix(tok.text[1:], "-")
+	if name == "" || name[0] == '-' || name[0] == '=' || unicode.IsDigit(rune(name[0])) {
+		return "", "", false
+	}
+	name, value, _ = strings.Cut(name, "=")
+	return name, value, true
+}
@@ -0,0 +1,46 @@
+package chord_test
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line  string
+		path  []string
+		args  []string
+		flags map[string]string
+	}{
+		{"users list", []string{"users", "list"}, nil, map[string]string{}},
+		{"greet --name=bob -v", []string{"greet"}, nil, map[string]string{"name": "bob", "v": ""}},
+		{`say "hello world"`, []string{"say", "hello world"}, nil, map[string]string{}},
+		{`say 'a\b'`, []string{"say", `a\b`}, nil, map[string]string{}},
+		{`say "a\"b" "c\\d"`, []string{"say", `a"b`, `c\d`}, nil, map[string]string{}},
+		{`open "C:\Users\x"`, []string{"open", `C:\Users\x`}, nil, map[string]string{}},
+		{`say a\ b`, []string{"say", "a b"}, nil, map[string]string{}},
+		{"run -- --not-a-flag", []string{"run"}, []string{"--not-a-flag"}, map[string]string{}},
+	}
+	for _, tt := range tests {
+		path, in, err := chord.ParseLine(tt.line)
+		if err != nil {
+			t.Errorf("ParseLine(%q) = %v", tt.line, err)
+			continue
+		}
+		if !slices.Equal(path, tt.path) || !slices.Equal(in.Args, tt.args) || !maps.Equal(in.Flags, tt.flags) {
+			t.Errorf("ParseLine(%q) = %q, %q, %v, want %q, %q, %v", tt.line, path, in.Args, in.Flags, tt.path, tt.args, tt.flags)
+		}
+	}
+}
+
+func TestParseLineSyntaxError(t *testing.T) {
+	for _, line := range []string{`say "open`, `say 'open`, `say trailing\`} {
+		if _, _, err := chord.ParseLine(line); !errors.Is(err, chord.ErrSyntax) {
+			t.Errorf("ParseLine(%q) = %v, want ErrSyntax", line, err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package chord
+
+import "strings"
+
+// DefaultDelimiter is the delimiter of the path strings matched by MatchString
+// from the chords without a delimiter set by SetDelimiter.
+const DefaultDelimiter = "/"
+
+// SetDelimiter sets the delimiter separating the keys of the path strings
+// matched from the chord by MatchString, such as "." or " ". It panics if the
+// delimiter is empty.
+func (c *Chord) SetDelimiter(delim string) {
+	if delim == "" {
+		panic("chord: empty delimiter")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delimiter = delim
+}
+
+// Delimiter returns the delimiter of the path strings matched from the chord,
+// DefaultDelimiter unless set by SetDelimiter.
+func (c *Chord) Delimiter() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.delimiter == "" {
+		return DefaultDelimiter
+	}
+	return c.delimiter
+}
+
+// MatchString matches the path string from the root as Match does, such as
+// "net/dns/flush", after splitting it into keys with SplitPath and the
+// delimiter of the root. The delimiters of the nested chords don't apply.
+func MatchString(root *Chord, path string) (Thread, bool) {
+	return Match(root, SplitPath(path, root.Delimiter()))
+}
+
+// SplitPath splits the path string into keys separated by the delimiter,
+// ignoring the empty keys, so that leading, trailing and repeated delimiters
+// are allowed. A backslash escapes the delimiter or a backslash following it,
+// so that keys may hold them, such as "a\/b" for the key "a/b".
+func SplitPath(path, delim string) []string {
+	if delim == "" {
+		panic("chord: empty delimiter")
+	}
+	var keys []string
+	var key strings.Builder
+	for i := 0; i < len(path); {
+		switch {
+		case path[i] == '\\' && strings.HasPrefix(path[i+1:], delim):
+			key.WriteString(delim)
+			i += 1 + len(delim)
+		case path[i] == '\\' && strings.HasPrefix(path[i+1:], `\`):
+			key.WriteByte('\\')
+			i += 2
+		case strings.HasPrefix(path[i:], delim):
+			if key.Len() > 0 {
+				keys = append(keys, key.String())
+				key.Reset()
+			}
+			i += len(delim)
+		default:
+			key.WriteByte(path[i])
+			i++
+		}
+	}
+	if key.Len() > 0 {
+		keys = append(keys, key.String())
+	}
+	return keys
+}
+
+// JoinPath joins the keys into a path string separated by the delimiter,
+// escaping the delimiters and backslashes they hold as SplitPath expects.
+func JoinPath(keys []string, delim string) string {
+	if delim == "" {
+		panic("chord: empty delimiter")
+	}
+	r := strings.NewReplacer(`\`, `\\`, delim, `\`+delim)
+	escaped := make([]string, len(keys))
+	for i, key := range keys {
+		escaped[i] = r.Replace(key)
+	}
+	return strings.Join(escaped, delim)
+}
@@ -0,0 +1,73 @@
+package chord
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Pipe returns a thread composing the threads into a pipeline, as a Unix shell
+// does: the threads run concurrently, each one reading from the reader side of
+// its Output what the previous one wrote to its writer side. The first thread
+// reads from the Output of the pipeline and the last one writes to it, while
+// all of them receive the input of the pipeline:
+//
+//	root.Register("errors", chord.Pipe(readLog, grep, count))
+//
+// A thread reading once the previous one has returned reaches EOF, and a
+// thread writing once the next one has returned fails with io.ErrClosedPipe.
+// The pipeline returns once all the threads have returned, with the first
+// error in the order of the threads, ignoring io.ErrClosedPipe as a thread
+// which stops reading early is not a failure of the pipeline.
+func Pipe(threads ...Thread) Thread {
+	return func(in *Input, out *Output) error {
+		if len(threads) == 0 {
+			return nil
+		}
+		if out == nil {
+			out = discardOutput()
+		}
+		errs := make([]error, len(threads))
+		var wg sync.WaitGroup
+		rd := out.Reader
+		var upstream *io.PipeReader // Pipe read by the current thread.
+		for i, thread := range threads {
+			w := out.Writer
+			var pr *io.PipeReader
+			var pw *io.PipeWriter
+			if i < len(threads)-1 {
+				pr, pw = io.Pipe()
+				w = bufio.NewWriter(pw)
+			}
+			stage := &Output{ReadWriter: *bufio.NewReadWriter(rd, w)}
+			wg.Add(1)
+			go func(up *io.PipeReader) {
+				defer wg.Done()
+				err := thread(in, stage)
+				if pw != nil {
+					if ferr := stage.Flush(); err == nil {
+						err = ferr
+					}
+					pw.CloseWithError(err)
+				}
+				if up != nil {
+					// Unblock the previous thread if it is still writing.
+					up.Close()
+				}
+				errs[i] = err
+			}(upstream)
+			if pr != nil {
+				rd = bufio.NewReader(pr)
+				upstream = pr
+			}
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil && !errors.Is(err, io.ErrClosedPipe) {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,210 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"strings"
+)
+
+// LoadPlugin opens the Go plugin at path, built with -buildmode=plugin against
+// the same version of this package, and registers its contributions on the
+// chord. The plugin either exports a Register function of type
+// func(*chord.Chord), called with a new chord whose threads and nested chords
+// are then moved to this chord, or a Thread function of type
+// func() chord.Thread, whose thread is registered under the name of the
+// plugin.
+//
+// The name of the plugin is the value of its exported PluginName string
+// variable, or else the base name of its file without extension. Loading a
+// plugin with the name of a plugin already loaded on the chord replaces it:
+// the contributions of the former plugin are removed once those of the new
+// one are checked, and left in place if the load fails. Since Go plugins can't
+// be closed, and the same file is only opened once per process, new versions
+// of a plugin must be built to new files, such as "greet.v2.so".
+//
+// The contributions are registered as by Register and Mount: a key already
+// registered on the chord, other than by the former plugin, or rejected by its
+// key validator or strict mode, fails the load, and the contributions
+// registered before it are removed.
+func (c *Chord) LoadPlugin(path string) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if sym, err := p.Lookup("PluginName"); err == nil {
+		s, ok := sym.(*string)
+		if !ok {
+			return fmt.Errorf("chord: plugin %s: PluginName is %T, not string", path, sym)
+		}
+		name = *s
+	}
+
+	tmp := NewChord()
+	if sym, err := p.Lookup("Register"); err == nil {
+		register, ok := sym.(func(*Chord))
+		if !ok {
+			return fmt.Errorf("chord: plugin %s: Register is %T, not func(*chord.Chord)", path, sym)
+		}
+		register(tmp)
+	} else if sym, err := p.Lookup("Thread"); err == nil {
+		factory, ok := sym.(func() Thread)
+		if !ok {
+			return fmt.Errorf("chord: plugin %s: Thread is %T, not func() chord.Thread", path, sym)
+		}
+		if _, err := tmp.Register(name, factory()); err != nil {
+			return fmt.Errorf("chord: plugin %s: %w", path, err)
+		}
+	} else {
+		return errors.New("chord: plugin " + path + " exports neither Register nor Thread")
+	}
+
+	c.mu.RLock()
+	former := c.plugins[name]
+	c.mu.RUnlock()
+	if err := c.checkContribution(tmp, former); err != nil {
+		return fmt.Errorf("chord: plugin %s: %w", path, err)
+	}
+	c.UnloadPlugin(name)
+	contrib := &pluginContribution{chords: make(map[string]*mount)}
+	if err := c.contribute(tmp, contrib); err != nil {
+		c.withdraw(contrib)
+		return fmt.Errorf("chord: plugin %s: %w", path, err)
+	}
+	c.mu.Lock()
+	if c.plugins == nil {
+		c.plugins = make(map[string]*pluginContribution)
+	}
+	c.plugins[name] = contrib
+	c.mu.Unlock()
+	return nil
+}
+
+// UnloadPlugin removes the threads and chords contributed by the plugin loaded
+// under the name, and reports whether such a plugin was loaded on the chord.
+// The threads registered, and chords mounted, under the keys of the plugin
+// since it was loaded are kept.
+func (c *Chord) UnloadPlugin(name string) bool {
+	c.mu.Lock()
+	contrib, ok := c.plugins[name]
+	delete(c.plugins, name)
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+	c.withdraw(contrib)
+	return true
+}
+
+// contribute registers the threads and mounts the chords of tmp on the chord,
+// as Register and Mount do, recording them into contrib.
+func (c *Chord) contribute(tmp *Chord, contrib *pluginContribution) error {
+	for _, key := range sortedKeys(&tmp.threads) {
+		v, _ := tmp.threads.Load(key)
+		key, e := c.foldKey(key), v.(*entry)
+		if err := c.storeEntry(key, e, false); err != nil {
+			return err
+		}
+		contrib.threads = append(contrib.threads, &Registration{c: c, key: key, e: e})
+	}
+	for _, key := range sortedKeys(&tmp.chords) {
+		v, _ := tmp.chords.Load(key)
+		key, m := c.foldKey(key), v.(*mount)
+		if err := c.mount(key, m, false); err != nil {
+			return err
+		}
+		contrib.chords[key] = m
+	}
+	return nil
+}
+
+// checkContribution returns the error registering the threads and mounting the
+// chords of tmp on the chord fails with, as contribute does, if any. The keys
+// under which former, if not nil, still holds its contributions are free.
+func (c *Chord) checkContribution(tmp *Chord, former *pluginContribution) error {
+	threadFree := func(key string) bool {
+		v, ok := c.threads.Load(key)
+		return !ok || former.holdsThread(key, v.(*entry))
+	}
+	chordFree := func(key string) bool {
+		v, ok := c.chords.Load(key)
+		return !ok || former != nil && former.chords[key] == v.(*mount)
+	}
+	strict := c.strict.Load()
+	for _, key := range sortedKeys(&tmp.threads) {
+		key = c.foldKey(key)
+		if err := c.validateKey(key); err != nil {
+			return err
+		}
+		if !threadFree(key) {
+			return &DuplicateError{Key: key}
+		}
+		if strict && !chordFree(key) {
+			return &DuplicateError{Key: key, Mount: true}
+		}
+	}
+	for _, key := range sortedKeys(&tmp.chords) {
+		v, _ := tmp.chords.Load(key)
+		key, m := c.foldKey(key), v.(*mount)
+		if err := c.validateKey(key); err != nil {
+			return err
+		}
+		if m.chord.reaches(c) {
+			return fmt.Errorf("%w: the chord mounted under %q holds the chord it is mounted on", ErrCycle, key)
+		}
+		if !chordFree(key) {
+			return &DuplicateError{Key: key, Mount: true}
+		}
+		if strict && !threadFree(key) {
+			return &DuplicateError{Key: key}
+		}
+	}
+	return nil
+}
+
+// withdraw removes the threads and chords recorded into contrib which are
+// still registered on the chord.
+func (c *Chord) withdraw(contrib *pluginContribution) {
+	for _, r := range contrib.threads {
+		c.Unregister(r.key, r)
+	}
+	for key, m := range contrib.chords {
+		c.unmount(key, m)
+	}
+}
+
+// FetchPlugins returns the names of the plugins loaded on the chord.
+func (c *Chord) FetchPlugins() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.plugins))
+	for name := range c.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pluginContribution records the registrations of the threads and the mounts
+// of the chords, by key, registered on a chord by a plugin.
+type pluginContribution struct {
+	threads []*Registration
+	chords  map[string]*mount
+}
+
+// holdsThread reports whether e is the entry the contribution registered under
+// key.
+func (p *pluginContribution) holdsThread(key string, e *entry) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.threads {
+		if r.key == key && r.e == e {
+			return true
+		}
+	}
+	return false
+}
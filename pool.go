@@ -0,0 +1,113 @@
+package chord
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ErrPoolClosed is the error returned when submitting executions to a closed Pool.
+var ErrPoolClosed = errors.New("chord: pool closed")
+
+// ErrPoolFull is the error returned by TrySubmit when the queue of a Pool is full.
+var ErrPoolFull = errors.New("chord: pool queue full")
+
+// Pool executes the threads of a chord on a bounded set of workers, so that
+// bursty callers such as message queues and bots don't spawn an unbounded
+// number of goroutines. Executions are submitted to a queue and return a
+// Future reporting their completion.
+type Pool struct {
+	root  *Chord
+	queue chan poolJob
+	wg    sync.WaitGroup
+
+	closed bool
+	mu     sync.RWMutex // Guards closed and the sends to queue.
+}
+
+// poolJob is an execution queued in a Pool.
+type poolJob struct {
+	path   []string
+	in     *Input
+	out    *Output
+	future *Future
+}
+
+// NewPool returns a pool executing the threads matched through root on the
+// given number of workers, at least one, with up to queueSize executions
+// waiting for a worker.
+func NewPool(root *Chord, workers, queueSize int) *Pool {
+	p := &Pool{root: root, queue: make(chan poolJob, max(queueSize, 0))}
+	for range max(workers, 1) {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues the execution of the thread of the path, as by Execute, waiting
+// for room in the queue until ctx is done. The execution observes the context
+// of the input, not ctx. When the Output is nil, the output of the thread is
+// discarded.
+func (p *Pool) Submit(ctx context.Context, path []string, in *Input, out *Output) (*Future, error) {
+	job := poolJob{path: path, in: in, out: out, future: newFuture()}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	select {
+	case p.queue <- job:
+		return job.future, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// TrySubmit queues the execution of the thread of the path as Submit does, but
+// returns ErrPoolFull instead of waiting when the queue is full.
+func (p *Pool) TrySubmit(path []string, in *Input, out *Output) (*Future, error) {
+	job := poolJob{path: path, in: in, out: out, future: newFuture()}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	select {
+	case p.queue <- job:
+		return job.future, nil
+	default:
+		return nil, ErrPoolFull
+	}
+}
+
+// Close stops accepting executions and waits for the queued and running ones
+// to complete.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// discardOutput returns an Output reading nothing and discarding writes.
+func discardOutput() *Output {
+	return &Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bufio.NewWriter(io.Discard))}
+}
+
+// work executes the queued jobs until the queue is closed.
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for job := range p.queue {
+		if job.out == nil {
+			job.out = discardOutput()
+		}
+		job.future.complete(p.root.Execute(job.path, job.in, job.out))
+	}
+}
@@ -0,0 +1,33 @@
+package chord
+
+import (
+	"context"
+	"slices"
+)
+
+// Principal is the identity of the caller of an execution.
+type Principal struct {
+	Name  string   // Name of the caller, such as a user name.
+	Roles []string // Roles granted to the caller.
+}
+
+// HasRole reports whether the principal was granted the role.
+func (p *Principal) HasRole(role string) bool {
+	return p != nil && slices.Contains(p.Roles, role)
+}
+
+// principalKey is the context key of the principal of an execution.
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx holding the principal, as read by
+// PrincipalOf.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalOf returns the principal of the execution of the input, as set in
+// its context by WithPrincipal, or nil if unknown.
+func PrincipalOf(in *Input) *Principal {
+	p, _ := in.Context().Value(principalKey{}).(*Principal)
+	return p
+}
@@ -0,0 +1,255 @@
+package chord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is the error matched by errors.Is when a caller exceeded
+// its quota.
+var ErrQuotaExceeded = errors.New("chord: quota exceeded")
+
+// QuotaError is returned by the threads wrapped by a Quota when the caller
+// exceeded its quota.
+type QuotaError struct {
+	Caller     string        // Identity of the caller.
+	Limit      int           // Executions allowed per window.
+	Window     time.Duration // Duration of the rolling window.
+	RetryAfter time.Duration // Estimated delay until an execution is allowed.
+}
+
+// Error implements the error interface.
+func (e *QuotaError) Error() string {
+	retry := e.RetryAfter.Round(time.Millisecond)
+	if retry >= time.Second {
+		retry = retry.Round(time.Second)
+	}
+	return fmt.Sprintf("chord: quota of %d executions per %s exceeded by %q, retry after %s",
+		e.Limit, e.Window, e.Caller, retry)
+}
+
+// Unwrap returns ErrQuotaExceeded, so that errors.Is(err, ErrQuotaExceeded)
+// reports true.
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// QuotaStore stores the counters of a Quota, such as in memory with
+// NewMemoryQuotaStore, or in a database shared by several processes.
+type QuotaStore interface {
+	// Incr adds n, which may be negative, to the counter of the key and
+	// returns its new value. A new counter starts at zero and expires after
+	// the ttl.
+	Incr(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error)
+	// Get returns the value of the counter of the key, zero if none.
+	Get(ctx context.Context, key string) (int64, error)
+}
+
+// Quota limits the number of executions of the threads it wraps per caller
+// over a rolling window, such as 1000 executions per day per tenant. The
+// executions are counted per window of fixed duration, the count of the
+// rolling window being estimated from the counts of the current and the
+// previous windows, weighted by their overlap.
+//
+// The fields are meant to be set before the quota is used.
+type Quota struct {
+	// Limit is the number of executions allowed per window and caller.
+	Limit int
+	// Window is the duration of the rolling window.
+	Window time.Duration
+	// KeyFunc identifies the caller of an execution. It defaults to
+	// ByPrincipal.
+	KeyFunc func(*Input) string
+	// Store stores the counters. It defaults to an in-memory store.
+	Store QuotaStore
+	// Prefix is prepended to the keys of the counters in the store, such as
+	// to share it between quotas.
+	Prefix string
+
+	once sync.Once
+}
+
+// NewQuota returns a quota of limit executions per window and caller.
+func NewQuota(limit int, window time.Duration) *Quota {
+	return &Quota{Limit: limit, Window: window}
+}
+
+// ByPrincipal selects the name of the principal of the input, as returned by
+// PrincipalOf, for use as Quota.KeyFunc or RateLimiter.KeyFunc. Anonymous
+// callers share the empty name.
+func ByPrincipal(in *Input) string {
+	if p := PrincipalOf(in); p != nil {
+		return p.Name
+	}
+	return ""
+}
+
+// Enforce is a ThreadWrapper counting the executions of the thread against
+// the quota of their caller. An execution exceeding the quota isn't invoked:
+// a *QuotaError is written to the Output and returned. Errors of the store
+// are returned likewise, failing closed.
+func (q *Quota) Enforce(next Thread) Thread {
+	return func(in *Input, out *Output) error {
+		if err := q.take(in); err != nil {
+			if out != nil && out.Writer != nil {
+				fmt.Fprintln(out, err)
+				out.Flush()
+			}
+			return err
+		}
+		return next(in, out)
+	}
+}
+
+// Usage returns the estimated number of executions of the caller over the
+// rolling window ending now.
+func (q *Quota) Usage(ctx context.Context, caller string) (int, error) {
+	q.init()
+	now := time.Now()
+	prev, cur, err := q.counts(ctx, caller, now)
+	if err != nil {
+		return 0, err
+	}
+	return int(q.estimate(now, prev, cur)), nil
+}
+
+// take counts an execution of the caller of the input, unless it exceeds the
+// quota.
+func (q *Quota) take(in *Input) error {
+	q.init()
+	ctx := in.Context()
+	keyFunc := q.KeyFunc
+	if keyFunc == nil {
+		keyFunc = ByPrincipal
+	}
+	caller := keyFunc(in)
+	now := time.Now()
+	key := q.key(caller, now, 0)
+	cur, err := q.Store.Incr(ctx, key, 1, 2*q.Window)
+	if err != nil {
+		return err
+	}
+	prev, err := q.Store.Get(ctx, q.key(caller, now, -1))
+	if err != nil {
+		return err
+	}
+	if q.estimate(now, prev, cur) <= float64(q.Limit) {
+		return nil
+	}
+	if _, err := q.Store.Incr(ctx, key, -1, 2*q.Window); err != nil {
+		return err
+	}
+	return &QuotaError{Caller: caller, Limit: q.Limit, Window: q.Window, RetryAfter: q.retryAfter(now, prev, cur-1)}
+}
+
+// counts returns the counts of the previous and current windows of the caller.
+func (q *Quota) counts(ctx context.Context, caller string, now time.Time) (prev, cur int64, err error) {
+	if prev, err = q.Store.Get(ctx, q.key(caller, now, -1)); err != nil {
+		return 0, 0, err
+	}
+	cur, err = q.Store.Get(ctx, q.key(caller, now, 0))
+	return prev, cur, err
+}
+
+// estimate returns the count of the rolling window ending now, the previous
+// window being weighted by the part of it which the rolling window overlaps.
+func (q *Quota) estimate(now time.Time, prev, cur int64) float64 {
+	return float64(prev)*(1-q.elapsed(now)) + float64(cur)
+}
+
+// retryAfter estimates the delay until the count of the rolling window drops
+// below the limit, as the previous window slides out of it.
+func (q *Quota) retryAfter(now time.Time, prev, cur int64) time.Duration {
+	rest := q.Window - time.Duration(q.elapsed(now)*float64(q.Window))
+	if prev == 0 || cur >= int64(q.Limit) {
+		return rest
+	}
+	// Solve prev*(1-f) + cur + 1 <= limit for the fraction f of the window.
+	f := 1 - float64(int64(q.Limit)-cur-1)/float64(prev)
+	return max(time.Duration(f*float64(q.Window))-(q.Window-rest), 0)
+}
+
+// elapsed returns the fraction of the current window elapsed at now.
+func (q *Quota) elapsed(now time.Time) float64 {
+	return float64(now.UnixNano()%int64(q.Window)) / float64(q.Window)
+}
+
+// key returns the key of the counter of the caller for the window at offset
+// from the window of now.
+func (q *Quota) key(caller string, now time.Time, offset int64) string {
+	return q.Prefix + caller + "@" + strconv.FormatInt(now.UnixNano()/int64(q.Window)+offset, 10)
+}
+
+// init sets the default store.
+func (q *Quota) init() {
+	q.once.Do(func() {
+		if q.Window <= 0 {
+			panic("chord: non-positive quota window")
+		}
+		if q.Store == nil {
+			q.Store = NewMemoryQuotaStore()
+		}
+	})
+}
+
+// NewMemoryQuotaStore returns a QuotaStore keeping the counters in memory,
+// removing the expired ones as new ones are created.
+func NewMemoryQuotaStore() QuotaStore {
+	return &memoryQuotaStore{counters: make(map[string]*quotaCounter)}
+}
+
+// memoryQuotaStore is the QuotaStore returned by NewMemoryQuotaStore.
+type memoryQuotaStore struct {
+	counters map[string]*quotaCounter
+	sweepAt  int // Number of counters from which the expired ones are removed.
+	mu       sync.Mutex
+}
+
+// quotaCounter is a counter of a memoryQuotaStore.
+type quotaCounter struct {
+	n       int64
+	expires time.Time
+}
+
+// Incr implements QuotaStore.
+func (s *memoryQuotaStore) Incr(_ context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.counters[key]
+	if !ok || now.After(c.expires) {
+		s.sweep(now)
+		c = &quotaCounter{expires: now.Add(ttl)}
+		s.counters[key] = c
+	}
+	c.n += n
+	return c.n, nil
+}
+
+// Get implements QuotaStore.
+func (s *memoryQuotaStore) Get(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.counters[key]; ok && !time.Now().After(c.expires) {
+		return c.n, nil
+	}
+	return 0, nil
+}
+
+// sweep removes the expired counters, once their number reaches the sweep
+// threshold.
+func (s *memoryQuotaStore) sweep(now time.Time) {
+	if len(s.counters) < s.sweepAt {
+		return
+	}
+	for key, c := range s.counters {
+		if now.After(c.expires) {
+			delete(s.counters, key)
+		}
+	}
+	s.sweepAt = max(2*len(s.counters), 64)
+}
@@ -0,0 +1,158 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is the error returned by the threads wrapped by a RateLimiter
+// when their executions exceed the rate.
+var ErrRateLimited = errors.New("chord: rate limit exceeded")
+
+// RateLimiter limits the rate of the executions of the threads it wraps with
+// token buckets: every execution takes a token from a bucket holding up to
+// Burst tokens, refilled at Rate tokens per second. Executions are accounted
+// in one global bucket, or in one bucket per key computed by KeyFunc, such as
+// per thread or per caller.
+//
+// The fields are meant to be set before the limiter is used.
+type RateLimiter struct {
+	// Rate is the number of tokens added to a bucket per second.
+	Rate float64
+	// Burst is the capacity of a bucket. It is at least one.
+	Burst int
+	// KeyFunc, when set, selects the bucket of an execution, such as ByKey or
+	// ByFlag("user"). Otherwise all the executions share one bucket.
+	KeyFunc func(*Input) string
+	// Wait makes the executions exceeding the rate wait for a token until the
+	// context of their input is done, instead of being rejected.
+	Wait bool
+
+	buckets map[string]*bucket
+	sweepAt int // Number of buckets from which idle ones are removed.
+	mu      sync.Mutex
+}
+
+// NewRateLimiter returns a limiter allowing rate executions per second, with
+// bursts of up to burst executions, sharing one bucket.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{Rate: rate, Burst: burst}
+}
+
+// ByKey selects a bucket per Input.Key, for use as RateLimiter.KeyFunc.
+func ByKey(in *Input) string {
+	return in.Key
+}
+
+// ByRoute selects a bucket, or circuit, per route matched, as returned by
+// Route, such as "users/:id/show", for use as RateLimiter.KeyFunc or
+// CircuitBreaker.KeyFunc.
+func ByRoute(in *Input) string {
+	return strings.Join(Route(in), "/")
+}
+
+// ByFlag returns a function selecting a bucket per value of the named flag,
+// such as a caller ID, for use as RateLimiter.KeyFunc.
+func ByFlag(name string) func(*Input) string {
+	return func(in *Input) string {
+		return in.Flags[name]
+	}
+}
+
+// Limit is a ThreadWrapper limiting the rate of the executions of the thread.
+// An execution exceeding the rate, or whose context is done while waiting for
+// a token, isn't invoked: ErrRateLimited, or the error of the context, is
+// written to the Output and returned.
+func (rl *RateLimiter) Limit(next Thread) Thread {
+	return func(in *Input, out *Output) error {
+		if err := rl.take(in); err != nil {
+			if out != nil && out.Writer != nil {
+				fmt.Fprintln(out, err)
+				out.Flush()
+			}
+			return err
+		}
+		return next(in, out)
+	}
+}
+
+// take takes a token from the bucket of the input, waiting for it if enabled.
+func (rl *RateLimiter) take(in *Input) error {
+	key := ""
+	if rl.KeyFunc != nil {
+		key = rl.KeyFunc(in)
+	}
+	for {
+		delay, ok := rl.reserve(key)
+		if ok {
+			return nil
+		}
+		if !rl.Wait {
+			return ErrRateLimited
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-in.Context().Done():
+			t.Stop()
+			return in.Context().Err()
+		case <-t.C:
+		}
+	}
+}
+
+// reserve takes a token from the bucket of the key if one is available, or
+// returns the delay until one is.
+func (rl *RateLimiter) reserve(key string) (time.Duration, bool) {
+	now := time.Now()
+	burst := float64(max(rl.Burst, 1))
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	b, ok := rl.buckets[key]
+	if !ok {
+		rl.sweep(now, burst)
+		b = &bucket{tokens: burst, last: now}
+		rl.buckets[key] = b
+	}
+	b.refill(now, rl.Rate, burst)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	if rl.Rate <= 0 {
+		return time.Hour, false
+	}
+	return time.Duration((1 - b.tokens) / rl.Rate * float64(time.Second)), false
+}
+
+// sweep removes the buckets which are full again, once their number reaches
+// the sweep threshold, so that idle keys don't accumulate.
+func (rl *RateLimiter) sweep(now time.Time, burst float64) {
+	if rl.buckets == nil {
+		rl.buckets = make(map[string]*bucket)
+	}
+	if len(rl.buckets) < rl.sweepAt {
+		return
+	}
+	for key, b := range rl.buckets {
+		b.refill(now, rl.Rate, burst)
+		if b.tokens >= burst {
+			delete(rl.buckets, key)
+		}
+	}
+	rl.sweepAt = max(2*len(rl.buckets), 64)
+}
+
+// bucket is a token bucket of a RateLimiter.
+type bucket struct {
+	tokens float64
+	last   time.Time // Time of the last refill.
+}
+
+// refill adds the tokens accumulated since the last refill, up to burst.
+func (b *bucket) refill(now time.Time, rate, burst float64) {
+	b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+}
@@ -0,0 +1,263 @@
+/*
+Package rbac enforces role-based access control policies on chord trees.
+
+A policy maps the routes of the threads, as reported by chord.Route, to the
+roles or permissions required to execute them, and permissions to the roles
+granting them. Policies are usually loaded from YAML or JSON configuration:
+
+	roles:
+	  admin: [users.read, users.write]
+	  support: [users.read]
+	rules:
+	  - path: admin/**
+	    roles: [admin]
+	  - path: users/:id/show
+	    permissions: [users.read]
+	defaultDeny: true
+
+and enforced as middleware of the root chord:
+
+	policy, err := rbac.Load("policy.yaml")
+	...
+	root.Use(policy.Enforce)
+
+The caller is identified by the chord.Principal of the context of the input,
+as set by the authenticators of the adapters, or else by the UserFlag and
+RolesFlag flags of the input when set.
+*/
+package rbac
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/graphitects/chord"
+)
+
+// Rule requires roles or permissions to execute the threads whose route
+// matches its path.
+type Rule struct {
+	// Path is the slash-separated pattern of the routes matched, such as
+	// "users/:id/show". The "*" key matches any key of the route, and a last
+	// "**" key matches any number of keys, including none.
+	Path string `json:"path"`
+	// Roles, when not empty, are the roles of which the caller must hold one.
+	Roles []string `json:"roles,omitempty"`
+	// Permissions are the permissions which the caller must all be granted
+	// by its roles.
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// Policy is a role-based access control policy. The first rule matching the
+// route of an execution decides whether the caller is allowed; an execution
+// matched by no rule is allowed unless DefaultDeny is set.
+type Policy struct {
+	// Roles maps the roles to the permissions they grant.
+	Roles map[string][]string `json:"roles,omitempty"`
+	// Rules are the rules of the policy, in order of precedence.
+	Rules []Rule `json:"rules,omitempty"`
+	// DefaultDeny denies the executions matched by no rule.
+	DefaultDeny bool `json:"defaultDeny,omitempty"`
+
+	// UserFlag and RolesFlag are the flags identifying the caller, by name
+	// and by comma-separated roles, when the context of the input holds no
+	// principal. Flags are set by the caller itself, so they should only be
+	// trusted behind an adapter validating them.
+	UserFlag, RolesFlag string `json:"-"`
+	// Identify, when set, identifies the caller of an execution instead.
+	Identify func(in *chord.Input) *chord.Principal `json:"-"`
+	// OnDeny, when set, is called instead of the thread when an execution is
+	// denied, and its error returned. Otherwise, the error is written to the
+	// output and returned.
+	OnDeny func(in *chord.Input, out *chord.Output, err *DeniedError) error `json:"-"`
+}
+
+// DeniedError is returned when a policy denies an execution.
+type DeniedError struct {
+	Principal string   // Name of the caller, empty if anonymous.
+	Route     []string // Route of the thread.
+	Reason    string   // Why the execution was denied.
+}
+
+// Error implements the error interface.
+func (e *DeniedError) Error() string {
+	who := "anonymous caller"
+	if e.Principal != "" {
+		who = strconv.Quote(e.Principal)
+	}
+	return fmt.Sprintf("rbac: %s denied %q: %s", who, strings.Join(e.Route, " "), e.Reason)
+}
+
+// Unwrap returns chord.ErrForbidden, so that errors.Is(err, chord.ErrForbidden)
+// reports true.
+func (e *DeniedError) Unwrap() error {
+	return chord.ErrForbidden
+}
+
+// ExitCode returns chord.ExitForbidden.
+func (e *DeniedError) ExitCode() int {
+	return chord.ExitForbidden
+}
+
+// Parse parses a policy from YAML or JSON. Unknown fields are rejected.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return nil, fmt.Errorf("rbac: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Load parses the policy file.
+func Load(name string) (*Policy, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Validate checks that the rules have paths, with "**" as last key only, and
+// that their permissions are granted by some role.
+func (p *Policy) Validate() error {
+	for i, r := range p.Rules {
+		keys := splitPath(r.Path)
+		if len(keys) == 0 {
+			return fmt.Errorf("rbac: rule %d: empty path", i)
+		}
+		if j := slices.Index(keys, "**"); j >= 0 && j != len(keys)-1 {
+			return fmt.Errorf("rbac: rule %d: \"**\" must be the last key of %q", i, r.Path)
+		}
+		for _, perm := range r.Permissions {
+			if !p.granted(perm) {
+				return fmt.Errorf("rbac: rule %d: permission %q is granted by no role", i, perm)
+			}
+		}
+	}
+	return nil
+}
+
+// Authorize returns a *DeniedError if the policy denies the principal, which
+// may be nil for an anonymous caller, the execution of the route.
+func (p *Policy) Authorize(principal *chord.Principal, route []string) error {
+	deny := func(reason string) error {
+		e := &DeniedError{Route: route, Reason: reason}
+		if principal != nil {
+			e.Principal = principal.Name
+		}
+		return e
+	}
+	rule, ok := p.rule(route)
+	if !ok {
+		if p.DefaultDeny {
+			return deny("no rule allows it")
+		}
+		return nil
+	}
+	if len(rule.Roles) > 0 && !slices.ContainsFunc(rule.Roles, principal.HasRole) {
+		return deny("requires one of the roles " + strings.Join(rule.Roles, ", "))
+	}
+	for _, perm := range rule.Permissions {
+		if !p.grants(principal, perm) {
+			return deny("requires the permission " + perm)
+		}
+	}
+	return nil
+}
+
+// Enforce is a ThreadWrapper authorizing every execution of the thread
+// before invoking it.
+func (p *Policy) Enforce(next chord.Thread) chord.Thread {
+	return func(in *chord.Input, out *chord.Output) error {
+		err := p.Authorize(p.principal(in), chord.Route(in))
+		if err == nil {
+			return next(in, out)
+		}
+		if p.OnDeny != nil {
+			return p.OnDeny(in, out, err.(*DeniedError))
+		}
+		if out != nil && out.Writer != nil {
+			fmt.Fprintln(out, err)
+			out.Flush()
+		}
+		return err
+	}
+}
+
+// principal identifies the caller of the execution of the input.
+func (p *Policy) principal(in *chord.Input) *chord.Principal {
+	if p.Identify != nil {
+		return p.Identify(in)
+	}
+	if principal := chord.PrincipalOf(in); principal != nil {
+		return principal
+	}
+	name, roles := in.Flags[p.UserFlag], in.Flags[p.RolesFlag]
+	if (p.UserFlag == "" || name == "") && (p.RolesFlag == "" || roles == "") {
+		return nil
+	}
+	principal := &chord.Principal{Name: name}
+	if p.RolesFlag != "" && roles != "" {
+		principal.Roles = strings.Split(roles, ",")
+	}
+	return principal
+}
+
+// rule returns the first rule matching the route.
+func (p *Policy) rule(route []string) (*Rule, bool) {
+	for i := range p.Rules {
+		if match(splitPath(p.Rules[i].Path), route) {
+			return &p.Rules[i], true
+		}
+	}
+	return nil, false
+}
+
+// grants reports whether one of the roles of the principal grants the permission.
+func (p *Policy) grants(principal *chord.Principal, perm string) bool {
+	if principal == nil {
+		return false
+	}
+	for _, role := range principal.Roles {
+		if slices.Contains(p.Roles[role], perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// granted reports whether some role grants the permission.
+func (p *Policy) granted(perm string) bool {
+	for _, perms := range p.Roles {
+		if slices.Contains(perms, perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether the keys of a rule match the route.
+func match(pattern, route []string) bool {
+	for i, key := range pattern {
+		if key == "**" {
+			return true
+		}
+		if i == len(route) || (key != "*" && key != route[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(route)
+}
+
+// splitPath splits a slash-separated path into keys, ignoring empty keys.
+func splitPath(p string) []string {
+	return strings.FieldsFunc(p, func(r rune) bool { return r == '/' })
+}
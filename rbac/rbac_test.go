@@ -0,0 +1,85 @@
+package rbac
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+const source = `
+roles:
+  admin: [users.read, users.write]
+  support: [users.read]
+rules:
+  - path: admin/**
+    roles: [admin]
+  - path: users/*/show
+    permissions: [users.read]
+defaultDeny: true
+`
+
+func TestAuthorize(t *testing.T) {
+	p, err := Parse([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := &chord.Principal{Name: "alice", Roles: []string{"admin"}}
+	support := &chord.Principal{Name: "bob", Roles: []string{"support"}}
+	tests := []struct {
+		principal *chord.Principal
+		route     string
+		allowed   bool
+	}{
+		{admin, "admin", true},
+		{admin, "admin/users/ban", true},
+		{support, "admin/users/ban", false},
+		{support, "users/42/show", true},
+		{nil, "users/42/show", false},
+		{admin, "status", false},
+	}
+	for _, tt := range tests {
+		err := p.Authorize(tt.principal, strings.Split(tt.route, "/"))
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("Authorize(%v, %s) = %v, want allowed %t", tt.principal, tt.route, err, tt.allowed)
+		}
+		if err != nil && !errors.Is(err, chord.ErrForbidden) {
+			t.Errorf("Authorize(%v, %s) = %v, want an error matching chord.ErrForbidden", tt.principal, tt.route, err)
+		}
+	}
+}
+
+func TestEnforce(t *testing.T) {
+	p, err := Parse([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.UserFlag, p.RolesFlag = "user", "roles"
+	root, admin := chord.NewChord(), chord.NewChord()
+	root.Use(p.Enforce)
+	admin.Register("ban", func(*chord.Input, *chord.Output) error { return nil })
+	root.Mount("admin", admin)
+
+	in := &chord.Input{Flags: map[string]string{"user": "alice", "roles": "admin"}}
+	if err := root.Execute([]string{"admin", "ban"}, in, nil); err != nil {
+		t.Fatalf("error of an admin = %v, want nil", err)
+	}
+	in = &chord.Input{Flags: map[string]string{"user": "bob", "roles": "support"}}
+	var denied *DeniedError
+	if err := root.Execute([]string{"admin", "ban"}, in, nil); !errors.As(err, &denied) || denied.Principal != "bob" {
+		t.Fatalf("error of a support = %v, want a *DeniedError of bob", err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, src := range []string{
+		"rules:\n  - path: a/**/b\n",
+		"rules:\n  - path: a\n    permissions: [unknown]\n",
+		"rules:\n  - path: ''\n",
+	} {
+		if _, err := Parse([]byte(src)); err == nil {
+			t.Errorf("Parse(%q) succeeded", src)
+		}
+	}
+}
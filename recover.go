@@ -0,0 +1,63 @@
+package chord
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error returned in place of a panic recovered from a thread.
+type PanicError struct {
+	Value any    // Value passed to panic.
+	Stack []byte // Stack trace of the panicking goroutine.
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("chord: thread panicked: %v", e.Value)
+}
+
+// Recover is a ThreadWrapper which recovers from a panicking thread so that it
+// doesn't take down the whole process. A diagnostic line is written to the
+// Output and the panic is returned as a *PanicError holding the stack trace.
+func Recover(next Thread) Thread {
+	return recoverThread(next, nil)
+}
+
+// OnPanic sets a callback reporting the panics of the threads matched through
+// this chord. Setting it makes the chord recover such panics as Recover does,
+// before passing them to the callback. Passing nil removes the callback.
+func (c *Chord) OnPanic(fn func(*Input, *PanicError)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onPanic = fn
+}
+
+// fetchOnPanic returns the panic callback of the chord, or nil if unset.
+func (c *Chord) fetchOnPanic() func(*Input, *PanicError) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.onPanic
+}
+
+// recoverThread wraps the thread so that its panics are recovered, written to
+// the Output, reported to the hook if not nil, and returned as a *PanicError.
+func recoverThread(next Thread, hook func(*Input, *PanicError)) Thread {
+	return func(in *Input, out *Output) (err error) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			pe := &PanicError{Value: v, Stack: debug.Stack()}
+			if out != nil && out.Writer != nil {
+				fmt.Fprintln(out, pe.Error())
+				out.Flush()
+			}
+			if hook != nil {
+				hook(in, pe)
+			}
+			err = pe
+		}()
+		return next(in, out)
+	}
+}
@@ -0,0 +1,142 @@
+package chord
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+)
+
+// SecretPatterns match common credentials: AWS access key IDs, GitHub, GitLab
+// and Slack tokens, JSON web tokens, bearer authorizations and the values
+// assigned to password, secret, token and API key settings.
+var SecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`),
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]+=*)`),
+	regexp.MustCompile(`(?i)\b(?:password|passwd|secret|token|api[_-]?key)["']?\s*[:=]\s*["']?([^\s"']+)`),
+}
+
+// Redactor redacts secrets from the output of the threads it wraps, such as
+// threads running external tools which echo credentials.
+type Redactor struct {
+	// Patterns match the secrets to redact. When a pattern has capturing
+	// groups, only the text of its first group is redacted, such as the value
+	// of a "password=..." setting.
+	Patterns []*regexp.Regexp
+	// Secrets are literal values to redact, such as the tokens the threads
+	// are configured with.
+	Secrets []string
+	// Replacement replaces the secrets. It defaults to "[REDACTED]".
+	Replacement string
+	// MaxLine is the maximum number of bytes buffered while waiting for the
+	// end of a line, as secrets are matched within lines. It defaults to 64KiB.
+	MaxLine int
+}
+
+// NewRedactor returns a Redactor of the SecretPatterns and the secrets.
+func NewRedactor(secrets ...string) *Redactor {
+	return &Redactor{Patterns: SecretPatterns, Secrets: secrets}
+}
+
+// Redact is a ThreadWrapper redacting the secrets written by the thread to
+// its output. The output is redacted line by line: flushing it writes the
+// complete lines, while an incomplete line is held until it is completed or
+// the thread returns.
+func (r *Redactor) Redact(next Thread) Thread {
+	return func(in *Input, out *Output) error {
+		if out == nil || out.Writer == nil {
+			return next(in, out)
+		}
+		rw := &redactWriter{r: r, out: out}
+		bw := bufio.NewWriter(rw)
+		err := next(in, &Output{ReadWriter: *bufio.NewReadWriter(out.Reader, bw)})
+		ferr := bw.Flush()
+		if ferr == nil {
+			ferr = rw.emit(len(rw.buf))
+		}
+		if err == nil {
+			err = ferr
+		}
+		return err
+	}
+}
+
+// Replace returns a copy of b with the secrets redacted.
+func (r *Redactor) Replace(b []byte) []byte {
+	repl := []byte(r.Replacement)
+	if r.Replacement == "" {
+		repl = []byte("[REDACTED]")
+	}
+	for _, s := range r.Secrets {
+		if s != "" {
+			b = bytes.ReplaceAll(b, []byte(s), repl)
+		}
+	}
+	for _, re := range r.Patterns {
+		if re.NumSubexp() == 0 {
+			b = re.ReplaceAllLiteral(b, repl)
+			continue
+		}
+		b = replaceGroup(re, b, repl)
+	}
+	return b
+}
+
+// replaceGroup replaces the first group of the matches of the pattern in b.
+func replaceGroup(re *regexp.Regexp, b, repl []byte) []byte {
+	var res []byte
+	last := 0
+	for _, m := range re.FindAllSubmatchIndex(b, -1) {
+		if m[2] < 0 {
+			continue
+		}
+		res = append(res, b[last:m[2]]...)
+		res = append(res, repl...)
+		last = m[3]
+	}
+	if res == nil {
+		return b
+	}
+	return append(res, b[last:]...)
+}
+
+// redactWriter buffers the writes of a thread and writes them redacted to its
+// output, line by line.
+type redactWriter struct {
+	r   *Redactor
+	out *Output
+	buf []byte
+}
+
+// Write implements io.Writer.
+func (w *redactWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	n := bytes.LastIndexByte(w.buf, '\n') + 1
+	maxLine := w.r.MaxLine
+	if maxLine <= 0 {
+		maxLine = 64 << 10
+	}
+	if n == 0 && len(w.buf) >= maxLine {
+		n = len(w.buf)
+	}
+	if err := w.emit(n); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// emit writes the first n bytes of the buffer redacted to the output and
+// flushes it.
+func (w *redactWriter) emit(n int) error {
+	if n == 0 {
+		return nil
+	}
+	if _, err := w.out.Write(w.r.Replace(w.buf[:n])); err != nil {
+		return err
+	}
+	w.buf = w.buf[:copy(w.buf, w.buf[n:])]
+	return w.out.Flush()
+}
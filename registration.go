@@ -0,0 +1,81 @@
+package chord
+
+import "slices"
+
+// Registration is the registration of a thread, as returned by Register. It
+// locates the thread in the tree of chords, following the chord it is
+// registered on when mounted elsewhere.
+type Registration struct {
+	c   *Chord
+	key string
+	e   *entry
+}
+
+// Key returns the key the thread is registered under, normalized as set by
+// SetKeyFold.
+func (r *Registration) Key() string {
+	return r.key
+}
+
+// Chord returns the chord the thread is registered on.
+func (r *Registration) Chord() *Chord {
+	return r.c
+}
+
+// Path returns the keys of the path of the thread from the root of the tree
+// the chord it is registered on is currently mounted in, such as "net", "dns"
+// and "flush", as PathOf does.
+func (r *Registration) Path() []string {
+	return append(r.c.path(), r.key)
+}
+
+// Active reports whether the thread is still the one registered under its key.
+func (r *Registration) Active() bool {
+	e, ok := r.c.threads.Load(r.key)
+	return ok && e == r.e
+}
+
+// PathOf returns the keys of the path of the thread or chord registered under
+// key on the chord, from the root of the tree the chord is currently mounted
+// in. A chord mounted several times is located at its latest mount. It reports
+// false when nothing is registered under key.
+func (c *Chord) PathOf(key string) ([]string, bool) {
+	key = c.foldKey(key)
+	_, isThread := c.threads.Load(key)
+	_, isChord := c.chords.Load(key)
+	if !isThread && !isChord {
+		return nil, false
+	}
+	return append(c.path(), key), true
+}
+
+// attach records that the chord is mounted under key on parent.
+func (c *Chord) attach(parent *Chord, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.parent, c.parentKey = parent, key
+}
+
+// path returns the keys of the path of the chord from the root of its tree,
+// following its latest mounts while they are in place. The walk stops at a
+// chord already visited, so that mount cycles don't loop forever.
+func (c *Chord) path() []string {
+	var keys []string
+	seen := map[*Chord]bool{}
+	for node := c; !seen[node]; {
+		seen[node] = true
+		node.mu.RLock()
+		parent, key := node.parent, node.parentKey
+		node.mu.RUnlock()
+		if parent == nil {
+			break
+		}
+		if m, ok := parent.chords.Load(key); !ok || m.(*mount).chord != node {
+			break
+		}
+		keys = append(keys, key)
+		node = parent
+	}
+	slices.Reverse(keys)
+	return keys
+}
@@ -0,0 +1,112 @@
+package chord
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// RemoteThread returns a thread forwarding its input to the thread of the path
+// on a chord served by Serve in another process, and streaming the remote
+// output to its own. The endpoint is a URL whose scheme is the network, such
+// as "unix:///run/app.sock" or "tcp://10.0.0.1:7070". Every execution opens
+// its own connection, and errors of the remote thread are returned as a
+// *RemoteError, so remote threads can be registered in a tree like local ones:
+//
+//	root.Register("billing", chord.RemoteThread("unix:///run/billing.sock", []string{"invoice"}))
+//
+// The key, arguments and flags of the input are forwarded, and its context
+// cancels the remote execution. The reader side of the output isn't. Parameter
+// and wildcard keys of the path, such as ":id", are replaced by the values
+// captured into Input.Params.
+func RemoteThread(endpoint string, path []string) Thread {
+	network, address, err := parseEndpoint(endpoint)
+	if err != nil {
+		return func(*Input, *Output) error { return err }
+	}
+	return remoteThread(network, address, path)
+}
+
+// remoteThread implements RemoteThread for a parsed endpoint.
+func remoteThread(network, address string, path []string) Thread {
+	return func(in *Input, out *Output) error {
+		cl, err := dialContext(in.Context(), network, address)
+		if err != nil {
+			return err
+		}
+		defer cl.Close()
+		var w io.Writer = io.Discard
+		if out != nil && out.Writer != nil {
+			w = &flushWriter{out: out}
+		}
+		return cl.Call(in.Context(), remotePath(path, in.Params), in, w)
+	}
+}
+
+// remotePath returns the path with its parameter and wildcard keys replaced by
+// the values captured into the params, when captured.
+func remotePath(path []string, params map[string]string) []string {
+	if len(params) == 0 {
+		return path
+	}
+	resolved := make([]string, 0, len(path))
+	for _, key := range path {
+		switch {
+		case isParam(key) && params[key[1:]] != "":
+			resolved = append(resolved, params[key[1:]])
+		case isWildcard(key) && params[wildcardName(key)] != "":
+			resolved = append(resolved, strings.Split(params[wildcardName(key)], "/")...)
+		default:
+			resolved = append(resolved, key)
+		}
+	}
+	return resolved
+}
+
+// dialContext connects a client to the address of the network.
+func dialContext(ctx context.Context, network, address string) (*Client, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// parseEndpoint returns the network and address of an endpoint URL.
+func parseEndpoint(endpoint string) (network, address string, err error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("chord: invalid endpoint: %w", err)
+	}
+	switch u.Scheme {
+	case "unix", "unixpacket":
+		address = u.Path
+		if address == "" {
+			address = u.Opaque
+		}
+	case "tcp", "tcp4", "tcp6":
+		address = u.Host
+	default:
+		return "", "", fmt.Errorf("chord: unsupported endpoint %q", endpoint)
+	}
+	return u.Scheme, address, nil
+}
+
+// flushWriter writes to an output, flushing it after every write so that the
+// remote output is streamed.
+type flushWriter struct {
+	out *Output
+}
+
+// Write implements io.Writer.
+func (w *flushWriter) Write(p []byte) (int, error) {
+	n, err := w.out.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, w.out.Flush()
+}
@@ -0,0 +1,167 @@
+package chord
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// REPL is an interactive shell reading command lines from a reader,
+// dispatching them through a root chord and writing the output of the threads,
+// or their errors, to a writer.
+//
+// Lines are tokenized with ParseLine. The longest prefix of the path which
+// resolves to a thread selects it, and the remaining keys are passed to the
+// thread as leading arguments, so that "greet World" invokes the "greet" thread
+// with the "World" argument.
+type REPL struct {
+	// Prompt is written before reading each line. It defaults to "> ".
+	Prompt string
+	// PromptFunc, when set, computes the prompt before each line instead of Prompt.
+	PromptFunc func() string
+	// HistorySize is the maximum number of lines kept in the history.
+	// Zero keeps all of them.
+	HistorySize int
+
+	root *Chord
+	r    io.Reader
+	w    io.Writer
+
+	// history holds the non-empty lines read, oldest first.
+	history []string
+	mu      sync.Mutex
+}
+
+// NewREPL returns a REPL dispatching the lines read from r through root and
+// writing to w.
+func NewREPL(root *Chord, r io.Reader, w io.Writer) *REPL {
+	return &REPL{Prompt: "> ", root: root, r: r, w: w}
+}
+
+// Run reads and dispatches lines until the reader is exhausted or the context
+// is done. Errors of the threads are written to the writer and don't stop the
+// REPL. It returns the error of the reader or of the context, or nil at EOF.
+func (r *REPL) Run(ctx context.Context) error {
+	sc := bufio.NewScanner(r.r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(r.w, r.prompt()); err != nil {
+			return err
+		}
+		if !sc.Scan() {
+			return sc.Err()
+		}
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		r.record(line)
+		if err := r.Exec(ctx, line); err != nil {
+			fmt.Fprintln(r.w, err)
+		}
+	}
+}
+
+// Exec dispatches a single line through the root chord, as Run does, and
+// returns the error of the tokenizer, of the match or of the thread.
+func (r *REPL) Exec(ctx context.Context, line string) error {
+	return r.root.ExecuteLine(ctx, line, strings.NewReader(""), r.w)
+}
+
+// History returns a copy of the lines read by the REPL, oldest first.
+func (r *REPL) History() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.history)
+}
+
+// prompt returns the prompt to write before reading a line.
+func (r *REPL) prompt() string {
+	if r.PromptFunc != nil {
+		return r.PromptFunc()
+	}
+	return r.Prompt
+}
+
+// record appends the line to the history, dropping the oldest lines beyond
+// the size of the history.
+func (r *REPL) record(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.history = append(r.history, line)
+	if r.HistorySize > 0 && len(r.history) > r.HistorySize {
+		r.history = slices.Delete(r.history, 0, len(r.history)-r.HistorySize)
+	}
+}
+
+// MatchLine tokenizes a command line with ParseLine and matches the thread
+// selected by the longest prefix of its path. The input returned holds the
+// flags of the line, and the remaining keys of the path followed by the
+// positional arguments of the line as arguments. A *NotFoundError is returned
+// when no prefix of the path matches.
+func (c *Chord) MatchLine(line string) (Thread, *Input, error) {
+	path, in, err := ParseLine(line)
+	if err != nil {
+		return nil, nil, err
+	}
+	thread, err := matchInput(c, path, in)
+	if err != nil {
+		return nil, nil, err
+	}
+	return thread, in, nil
+}
+
+// ExecuteLine tokenizes a command line and executes the thread matched as by
+// MatchLine, as a REPL does. The Output of the thread reads from r, which may
+// be nil, and writes to w, and is flushed once the thread returns. The context
+// of the input is ctx, once done the writes of the thread are discarded as by
+// Execute.
+func (c *Chord) ExecuteLine(ctx context.Context, line string, r io.Reader, w io.Writer) error {
+	path, in, err := ParseLine(line)
+	if err != nil {
+		return err
+	}
+	return dispatch(ctx, c, path, in, r, w)
+}
+
+// matchInput matches the thread selected by the longest prefix of the path,
+// prepending the rest of the path to the arguments of the input.
+func matchInput(root *Chord, path []string, in *Input) (Thread, error) {
+	thread, rest, ok := MatchPrefix(root, path)
+	if !ok {
+		return nil, root.notFoundError(path)
+	}
+	in.Args = slices.Concat(rest, in.Args)
+	return thread, nil
+}
+
+// dispatch executes the thread selected by the longest prefix of the path,
+// passing the rest of the path as leading arguments. The Output of the thread
+// reads from rd, or from nothing if nil, and writes to w until ctx is done, and
+// is flushed once the thread returns. The thread isn't invoked when ctx is
+// already done.
+func dispatch(ctx context.Context, root *Chord, path []string, in *Input, rd io.Reader, w io.Writer) error {
+	thread, err := matchInput(root, path, in)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if rd == nil {
+		rd = strings.NewReader("")
+	}
+	bw := bufio.NewWriter(&cancelWriter{ctx: ctx, w: w})
+	out := &Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(rd), bw)}
+	err = thread(in.WithContext(ctx), out)
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package chord
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy retries the threads it wraps when they fail, waiting with an
+// exponential backoff between the attempts. The zero policy makes 3 attempts,
+// waiting 100ms then 200ms.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts, including the first one.
+	// It defaults to 3.
+	Attempts int
+	// Backoff is the delay before the second attempt, doubled before every
+	// following one. It defaults to 100ms.
+	Backoff time.Duration
+	// MaxBackoff, when positive, bounds the delay between two attempts.
+	MaxBackoff time.Duration
+	// Jitter, between 0 and 1, is the fraction of every delay which is
+	// randomized, to spread the attempts of concurrent executions.
+	Jitter float64
+	// RetryIf, when set, reports whether a failed attempt is retried.
+	// Otherwise all the errors are retried.
+	RetryIf func(error) bool
+	// Replay reads the reader side of the Output before the first attempt, so
+	// that every attempt reads the same data. Otherwise the attempts read the
+	// rest of the data left by the previous ones.
+	Replay bool
+}
+
+// Retry is a ThreadWrapper retrying the failed executions of the thread
+// according to the policy. Every attempt writes to a buffer, and only the
+// output of the last attempt is copied to the Output, so that retried attempts
+// don't duplicate it. The attempts stop once the context of the input is done,
+// and the error of the last attempt is returned.
+func (p *RetryPolicy) Retry(next Thread) Thread {
+	return func(in *Input, out *Output) error {
+		attempts := p.Attempts
+		if attempts <= 0 {
+			attempts = 3
+		}
+		backoff := p.Backoff
+		if backoff <= 0 {
+			backoff = 100 * time.Millisecond
+		}
+		var replay []byte
+		if p.Replay && out != nil && out.Reader != nil {
+			var err error
+			if replay, err = io.ReadAll(out.Reader); err != nil {
+				return err
+			}
+		}
+
+		var data []byte
+		var err error
+		for attempt := 1; ; attempt++ {
+			attemptOut := out
+			if p.Replay {
+				attemptOut = &Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(replay)), nil)}
+			}
+			data, err = captureOutput(next, in, attemptOut)
+			if err == nil || attempt >= attempts || in.Context().Err() != nil || (p.RetryIf != nil && !p.RetryIf(err)) {
+				break
+			}
+			if !sleepContext(in, p.delay(backoff)) {
+				break
+			}
+			backoff *= 2
+			if p.MaxBackoff > 0 {
+				backoff = min(backoff, p.MaxBackoff)
+			}
+		}
+		if werr := writeOutput(out, data); err == nil {
+			err = werr
+		}
+		return err
+	}
+}
+
+// delay returns the backoff with its jitter applied.
+func (p *RetryPolicy) delay(backoff time.Duration) time.Duration {
+	if p.MaxBackoff > 0 {
+		backoff = min(backoff, p.MaxBackoff)
+	}
+	jitter := min(max(p.Jitter, 0), 1)
+	if jitter == 0 {
+		return backoff
+	}
+	return time.Duration(float64(backoff) * (1 - jitter*rand.Float64()))
+}
+
+// sleepContext waits for the duration, and reports whether it elapsed before
+// the context of the input was done.
+func sleepContext(in *Input, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-in.Context().Done():
+		return false
+	}
+}
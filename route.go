@@ -0,0 +1,31 @@
+package chord
+
+import (
+	"context"
+	"slices"
+)
+
+// routeKey is the context key of the route matched for an execution.
+type routeKey struct{}
+
+// Route returns the registered keys of the path matched for the execution of
+// the input, such as ["user", ":id", "show"] for the path "user 42 show", or
+// nil when the input wasn't dispatched through Match. Unlike the path, the
+// route identifies the registration independently of the values captured by
+// parameter and wildcard keys, such as to label metrics. When a NotFound
+// fallback is matched, the route is the one of the chord of the fallback.
+func Route(in *Input) []string {
+	route, _ := in.Context().Value(routeKey{}).([]string)
+	return slices.Clip(route)
+}
+
+// withRoute wraps the thread so that it receives a copy of its input whose
+// context holds the route matched.
+func withRoute(thread Thread, route []string) Thread {
+	if route == nil {
+		route = []string{}
+	}
+	return func(in *Input, out *Output) error {
+		return thread(in.WithContext(context.WithValue(in.Context(), routeKey{}, route)), out)
+	}
+}
@@ -0,0 +1,49 @@
+package chord
+
+import "sync/atomic"
+
+// Router dispatches to the active tree of chords, which is replaced atomically
+// by Swap, such as when a configuration reload builds a new tree. The
+// dispatches in flight when the tree is swapped keep using the tree they
+// matched from.
+type Router struct {
+	root atomic.Pointer[Chord]
+}
+
+// NewRouter returns a Router dispatching to the tree of root.
+func NewRouter(root *Chord) *Router {
+	r := &Router{}
+	r.Swap(root)
+	return r
+}
+
+// Root returns the root of the active tree.
+func (r *Router) Root() *Chord {
+	return r.root.Load()
+}
+
+// Swap makes the tree of root the active tree, and returns the root of the
+// tree it replaces, nil for the first one. The executions still in flight on
+// the replaced tree can be waited for with its Shutdown method:
+//
+//	old := router.Swap(next)
+//	err := old.Shutdown(ctx)
+func (r *Router) Swap(root *Chord) *Chord {
+	if root == nil {
+		panic("chord: nil root")
+	}
+	return r.root.Swap(root)
+}
+
+// Match returns the thread matched by the path on the active tree, as Match
+// does.
+func (r *Router) Match(path []string) (Thread, bool) {
+	return Match(r.Root(), path)
+}
+
+// Execute matches the thread of the path on the active tree and invokes it
+// with the input and output, as Chord.Execute does. The tree is selected once,
+// so that the execution isn't affected by a concurrent Swap.
+func (r *Router) Execute(path []string, in *Input, out *Output) error {
+	return r.Root().Execute(path, in, out)
+}
@@ -0,0 +1,73 @@
+package chord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK    = 0 // The thread succeeded.
+	ExitError = 1 // The thread returned an error.
+	ExitUsage = 2 // No thread matched, or the command line or flags were invalid.
+
+	ExitForbidden = 77  // The caller isn't allowed to execute the thread, as EX_NOPERM of sysexits.h.
+	ExitTimeout   = 124 // The thread exceeded its timeout, as reported by timeout(1).
+)
+
+// ExitCoder is implemented by errors which select the exit code returned by Run.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// Run is the entrypoint of a command-line program built on a chord, meant to be
+// called as:
+//
+//	os.Exit(chord.Run(root, os.Args))
+//
+// The first argument, the program name, is skipped. The remaining arguments
+// are separated into flags, path and arguments as by ParseLine, and the thread
+// selected by the longest prefix of the path is executed as by REPL, with its
+// Output bound to the standard input and output. The context of the input is
+// canceled on interrupt.
+//
+// Errors are written to the standard error, and the exit code is returned:
+// the one of an error implementing ExitCoder, ExitUsage when the path doesn't
+// match or the flags are invalid, ExitError for other errors, or ExitOK.
+func Run(root *Chord, args []string) int {
+	ctx, stop := signal.NotifyContext(WithExternal(context.Background()), os.Interrupt)
+	defer stop()
+
+	tokens := make([]token, 0, len(args))
+	for _, arg := range args[min(1, len(args)):] {
+		tokens = append(tokens, token{text: arg})
+	}
+	path, in := parseTokens(tokens)
+	err := dispatch(ctx, root, path, in, os.Stdin, os.Stdout)
+	if err == nil {
+		return ExitOK
+	}
+	fmt.Fprintln(os.Stderr, err)
+	return ExitCode(err)
+}
+
+// ExitCode returns the exit code reporting an error returned by a thread, as
+// documented by Run. It returns ExitOK for a nil error.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var ec ExitCoder
+	var fe *FlagError
+	var ae *ArgError
+	switch {
+	case errors.As(err, &ec):
+		return ec.ExitCode()
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrSyntax), errors.As(err, &fe), errors.As(err, &ae):
+		return ExitUsage
+	}
+	return ExitError
+}
@@ -0,0 +1,307 @@
+package chord
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OverlapPolicy selects what a Scheduler does when a job is due while its
+// previous execution is still running.
+type OverlapPolicy int
+
+const (
+	// OverlapSkip skips the execution which is due.
+	OverlapSkip OverlapPolicy = iota
+	// OverlapAllow starts the execution which is due alongside the running one.
+	OverlapAllow
+	// OverlapWait starts the execution which is due once the running one
+	// returns. Executions due meanwhile are skipped.
+	OverlapWait
+)
+
+// Job is a thread executed periodically by a Scheduler.
+type Job struct {
+	// Path is the path of the thread, matched from the root of the scheduler
+	// on every execution.
+	Path []string
+	// Input is the template of the input of the executions. In its arguments
+	// and the values of its flags, "${time}" expands to the time the execution
+	// was due, formatted as RFC 3339, and "${unix}" to it as a Unix timestamp.
+	Input Input
+	// Overlap is the policy applied when the job is due while running.
+	Overlap OverlapPolicy
+	// Output receives the output of the executions. They are discarded when nil.
+	Output io.Writer
+}
+
+// Scheduler executes the threads of a chord periodically, on cron expressions
+// or intervals, so that periodic tasks reuse the tree of threads.
+type Scheduler struct {
+	// OnError, when set, is called with the ID of a job and the error of one
+	// of its executions.
+	OnError func(id string, err error)
+
+	root *Chord
+	jobs map[string]*scheduledJob
+	ctx  context.Context // Context of Run, nil when not running.
+	wg   sync.WaitGroup  // Goroutines of the jobs and of their executions.
+	mu   sync.Mutex
+}
+
+// scheduledJob is a job added to a Scheduler.
+type scheduledJob struct {
+	Job
+	sched   schedule
+	paused  bool
+	running int                // Number of running executions.
+	idle    chan struct{}      // Closed, and replaced, when running drops to zero.
+	cancel  context.CancelFunc // Stops the goroutine of the job, nil when not started.
+	next    time.Time          // Next activation time, zero when not started.
+}
+
+// NewScheduler returns a scheduler executing threads matched through root.
+func NewScheduler(root *Chord) *Scheduler {
+	return &Scheduler{root: root, jobs: make(map[string]*scheduledJob)}
+}
+
+// Add adds a job under an ID, replacing the job of the same ID. The spec is a
+// standard five-field cron expression, such as "*/5 * * * *" or
+// "0 3 * * mon-fri", a predefined schedule among "@yearly", "@monthly",
+// "@weekly", "@daily" and "@hourly", or an interval such as "@every 30s".
+// Cron expressions are evaluated in the local time zone.
+func (s *Scheduler) Add(id, spec string, job Job) error {
+	sched, err := parseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	j := &scheduledJob{Job: job, sched: sched, idle: make(chan struct{})}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(id)
+	s.jobs[id] = j
+	if s.ctx != nil {
+		s.start(id, j)
+	}
+	return nil
+}
+
+// Remove removes the job of the ID and reports whether it existed. Its running
+// executions aren't interrupted: they observe the context of Run only.
+func (s *Scheduler) Remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.removeLocked(id)
+}
+
+// Pause suspends the executions of the job of the ID, until resumed, and
+// reports whether it exists.
+func (s *Scheduler) Pause(id string) bool {
+	return s.setPaused(id, true)
+}
+
+// Resume resumes the executions of the job of the ID suspended by Pause, and
+// reports whether it exists.
+func (s *Scheduler) Resume(id string) bool {
+	return s.setPaused(id, false)
+}
+
+// Jobs returns the IDs of the jobs of the scheduler, in lexical order.
+func (s *Scheduler) Jobs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := slices.Collect(maps.Keys(s.jobs))
+	sort.Strings(ids)
+	return ids
+}
+
+// Next returns the next time the job of the ID is due, and whether it exists
+// and is scheduled. Jobs are only scheduled while Run is running.
+func (s *Scheduler) Next(id string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok || j.next.IsZero() {
+		return time.Time{}, false
+	}
+	return j.next, true
+}
+
+// Run schedules the jobs until ctx is done, then waits for their running
+// executions to return, and returns the error of the context. The context of
+// the executions is derived from ctx.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.ctx != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("chord: scheduler already running")
+	}
+	s.ctx = ctx
+	for id, j := range s.jobs {
+		s.start(id, j)
+	}
+	s.mu.Unlock()
+
+	<-ctx.Done()
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		j.cancel = nil
+		j.next = time.Time{}
+	}
+	s.ctx = nil
+	s.mu.Unlock()
+	s.wg.Wait()
+	return ctx.Err()
+}
+
+// removeLocked removes the job of the ID, stopping its goroutine.
+func (s *Scheduler) removeLocked(id string) bool {
+	j, ok := s.jobs[id]
+	if !ok {
+		return false
+	}
+	if j.cancel != nil {
+		j.cancel()
+	}
+	delete(s.jobs, id)
+	return true
+}
+
+// setPaused sets the paused state of the job of the ID.
+func (s *Scheduler) setPaused(id string, paused bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if ok {
+		j.paused = paused
+	}
+	return ok
+}
+
+// start starts the goroutine activating the job while the scheduler runs.
+func (s *Scheduler) start(id string, j *scheduledJob) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	j.cancel = cancel
+	j.next = j.sched.next(time.Now())
+	s.wg.Add(1)
+	go s.loop(ctx, s.ctx, id, j)
+}
+
+// loop activates the job at its activation times until ctx is done. The
+// executions observe runCtx, the context of Run, rather than ctx, so that
+// removing the job doesn't interrupt them.
+func (s *Scheduler) loop(ctx, runCtx context.Context, id string, j *scheduledJob) {
+	defer s.wg.Done()
+	for {
+		s.mu.Lock()
+		next := j.next
+		s.mu.Unlock()
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.mu.Lock()
+		j.next = j.sched.next(time.Now())
+		s.mu.Unlock()
+		s.activate(ctx, runCtx, id, j, next)
+		s.mu.Lock()
+		if now := time.Now(); j.next.Before(now) {
+			// Skip the activation times passed while waiting for OverlapWait.
+			j.next = j.sched.next(now)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// activate executes the job due at the given time, according to its pause
+// state and overlap policy, with the context runCtx. Waiting for the running
+// execution stops once ctx is done.
+func (s *Scheduler) activate(ctx, runCtx context.Context, id string, j *scheduledJob, due time.Time) {
+	s.mu.Lock()
+	if j.paused || (j.running > 0 && j.Overlap == OverlapSkip) {
+		s.mu.Unlock()
+		return
+	}
+	if j.running > 0 && j.Overlap == OverlapWait {
+		idle := j.idle
+		s.mu.Unlock()
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return
+		}
+		s.mu.Lock()
+	}
+	j.running++
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := s.execute(runCtx, j, due)
+		s.mu.Lock()
+		j.running--
+		if j.running == 0 {
+			close(j.idle)
+			j.idle = make(chan struct{})
+		}
+		s.mu.Unlock()
+		if err != nil && s.OnError != nil {
+			s.OnError(id, err)
+		}
+	}()
+}
+
+// execute executes the thread of the job with an input built from its template.
+func (s *Scheduler) execute(ctx context.Context, j *scheduledJob, due time.Time) error {
+	expand := func(v string) string {
+		return os.Expand(v, func(name string) string {
+			switch name {
+			case "time":
+				return due.Format(time.RFC3339)
+			case "unix":
+				return fmt.Sprint(due.Unix())
+			}
+			return "${" + name + "}"
+		})
+	}
+	in := j.Input.WithContext(ctx)
+	in.Args = make([]string, len(j.Input.Args))
+	for i, arg := range j.Input.Args {
+		in.Args[i] = expand(arg)
+	}
+	if j.Input.Flags != nil {
+		in.Flags = make(map[string]string, len(j.Input.Flags))
+		for k, v := range j.Input.Flags {
+			in.Flags[k] = expand(v)
+		}
+	}
+	if in.Key == "" {
+		in.Key = strings.Join(j.Path, " ")
+	}
+	w := j.Output
+	if w == nil {
+		w = io.Discard
+	}
+	bw := bufio.NewWriter(w)
+	out := &Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bw)}
+	err := s.root.Execute(j.Path, in, out)
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
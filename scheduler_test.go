@@ -0,0 +1,92 @@
+package chord_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/graphitects/chord"
+)
+
+func TestSchedulerEvery(t *testing.T) {
+	root := chord.NewChord()
+	var runs atomic.Int32
+	root.Register("tick", func(*chord.Input, *chord.Output) error {
+		runs.Add(1)
+		return nil
+	})
+	s := chord.NewScheduler(root)
+	if err := s.Add("tick", "@every 10ms", chord.Job{Path: []string{"tick"}}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+	if n := runs.Load(); n < 3 {
+		t.Fatalf("job ran %d times in 100ms, want at least 3", n)
+	}
+}
+
+func TestSchedulerInvalidSpec(t *testing.T) {
+	s := chord.NewScheduler(chord.NewChord())
+	for _, spec := range []string{"", "@every -1s", "* * *", "61 * * * *"} {
+		if err := s.Add("x", spec, chord.Job{}); err == nil {
+			t.Errorf("Add(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestSchedulerRemoveKeepsRunning(t *testing.T) {
+	root := chord.NewChord()
+	started := make(chan struct{}, 1)
+	var result atomic.Value
+	root.Register("slow", func(in *chord.Input, out *chord.Output) error {
+		select {
+		case started <- struct{}{}:
+		default:
+			return nil
+		}
+		select {
+		case <-time.After(50 * time.Millisecond):
+			result.Store("completed")
+		case <-in.Context().Done():
+			result.Store("canceled")
+		}
+		return nil
+	})
+	s := chord.NewScheduler(root)
+	s.Add("slow", "@every 10ms", chord.Job{Path: []string{"slow"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	<-started
+	if !s.Remove("slow") {
+		t.Fatal("Remove reported no job")
+	}
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+	if got := result.Load(); got != "completed" {
+		t.Fatalf("running execution %v after Remove, want completed", got)
+	}
+}
+
+func TestSchedulerPause(t *testing.T) {
+	root := chord.NewChord()
+	var runs atomic.Int32
+	root.Register("tick", func(*chord.Input, *chord.Output) error {
+		runs.Add(1)
+		return nil
+	})
+	s := chord.NewScheduler(root)
+	s.Add("tick", "@every 10ms", chord.Job{Path: []string{"tick"}})
+	s.Pause("tick")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+	if n := runs.Load(); n != 0 {
+		t.Fatalf("paused job ran %d times", n)
+	}
+}
@@ -0,0 +1,467 @@
+package chord
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The protocol of Serve exchanges frames made of a 4-byte big-endian length,
+// a kind byte and a payload of that length. A client sends a command line in
+// a request frame, or a path and an input encoded as a JSON call object in a
+// call frame; the server streams the output of the thread in output frames and
+// ends the response with an exit frame, whose payload is the 4-byte big-endian
+// exit code followed by the error message, if any. Several requests may be
+// sent, in turn, over a connection.
+//
+// A tree frame requests the description of the chord, sent as a JSON Tree in
+// a single output frame, and a watch frame requests a new description in an
+// output frame whenever the tree changes, until the connection is closed. The
+// descriptions omit the hidden and internal threads and chords, as help does.
+//
+// The payload of a frame may not exceed maxFrameSize bytes: larger outputs
+// are split over several output frames, while larger requests, and watched
+// descriptions, fail.
+const (
+	frameRequest = 'q'
+	frameCall    = 'c'
+	frameTree    = 't'
+	frameWatch   = 'w'
+	frameOutput  = 'o'
+	frameExit    = 'x'
+
+	// maxFrameSize is the maximum size of the payload of a frame.
+	maxFrameSize = 1 << 20
+)
+
+// Serve accepts connections on the listener, such as a Unix socket of a
+// daemon, and executes the command lines they send as by ExecuteLine, with the
+// protocol spoken by Client. It returns when accepting fails, such as when the
+// listener is closed. The contexts of the threads are canceled when their
+// connection is closed.
+func (c *Chord) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go c.serveConn(conn)
+	}
+}
+
+// serveConn executes the requests of a connection until it is closed. Frames
+// are read ahead, so that the context of the thread is canceled as soon as the
+// connection is closed.
+func (c *Chord) serveConn(conn net.Conn) {
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(WithExternal(context.Background()))
+	defer cancel()
+
+	type frame struct {
+		kind    byte
+		payload []byte
+	}
+	frames := make(chan frame)
+	go func() {
+		defer cancel()
+		for {
+			kind, payload, err := readFrame(conn, maxFrameSize)
+			if err != nil {
+				return
+			}
+			select {
+			case frames <- frame{kind, payload}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		var f frame
+		select {
+		case f = <-frames:
+		case <-ctx.Done():
+			return
+		}
+		w := &frameWriter{w: conn, kind: frameOutput}
+		var err error
+		switch f.kind {
+		case frameRequest:
+			err = c.ExecuteLine(ctx, string(f.payload), strings.NewReader(""), w)
+		case frameCall:
+			var cl call
+			if err = json.Unmarshal(f.payload, &cl); err == nil {
+				err = dispatch(ctx, c, cl.Path, cl.input(), strings.NewReader(""), w)
+			}
+		case frameTree:
+			var tree []byte
+			if tree, err = json.Marshal(c.Tree().visible()); err == nil {
+				_, err = w.Write(tree)
+			}
+		case frameWatch:
+			c.watchTree(ctx, w)
+			return
+		default:
+			writeExit(conn, fmt.Errorf("chord: unexpected frame %q", f.kind))
+			return
+		}
+		if writeExit(conn, err) != nil {
+			return
+		}
+	}
+}
+
+// watchTree writes the description of the tree to w, then again whenever it
+// changes, as reported by OnChange, until the context is done or writing
+// fails.
+func (c *Chord) watchTree(ctx context.Context, w io.Writer) {
+	watch := &treeWatch{root: c}
+	defer watch.stop()
+	var last []byte
+	for {
+		changed := watch.changes()
+		tree, err := json.Marshal(c.Tree().visible())
+		if err != nil {
+			return
+		}
+		// The chords loaded by Tree, such as lazily, are observed as well.
+		watch.observe()
+		if !bytes.Equal(tree, last) {
+			if _, err := w.Write(tree); err != nil {
+				return
+			}
+			last = tree
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// treeWatch signals the changes of the tree of a chord to a connection
+// watching it, observed through the OnChange hooks of its chords until the
+// watch is stopped.
+type treeWatch struct {
+	root     *Chord
+	changed  chan struct{}     // Closed, and replaced, when the tree changes.
+	observed map[*Chord]func() // Observers of the chords of the tree, by chord.
+	stopped  bool
+	mu       sync.Mutex
+}
+
+// changes returns a channel closed on the next change of the tree, observing
+// its chords first.
+func (w *treeWatch) changes() <-chan struct{} {
+	w.observe()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.changed == nil {
+		w.changed = make(chan struct{})
+	}
+	return w.changed
+}
+
+// observe registers the observer of the changes on the chords of the tree not
+// observed yet. The chords are traversed without loading them, as the observer
+// may be called while a chord is loaded.
+func (w *treeWatch) observe() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return
+	}
+	if w.observed == nil {
+		w.observed = make(map[*Chord]func())
+	}
+	var walk func(c *Chord)
+	walk = func(c *Chord) {
+		if c == nil {
+			return
+		}
+		if _, ok := w.observed[c]; ok {
+			return
+		}
+		w.observed[c] = c.addObserver(func(Event) {
+			w.observe()
+			w.signal()
+		})
+		c.chords.Range(func(_, v any) bool {
+			walk(v.(*mount).chord)
+			return true
+		})
+	}
+	walk(w.root)
+}
+
+// signal wakes up the connection watching the tree.
+func (w *treeWatch) signal() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.changed != nil {
+		close(w.changed)
+		w.changed = nil
+	}
+}
+
+// stop removes the observers of the watch.
+func (w *treeWatch) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopped = true
+	for _, remove := range w.observed {
+		remove()
+	}
+	w.observed = nil
+}
+
+// visible returns the tree without its hidden and internal threads and
+// chords.
+func (t Tree) visible() Tree {
+	v := Tree{Middlewares: t.Middlewares, ChordWrappers: t.ChordWrappers}
+	for _, n := range t.Threads {
+		if !n.Meta.Hidden && !n.Meta.Internal {
+			v.Threads = append(v.Threads, n)
+		}
+	}
+	for _, n := range t.Chords {
+		if !n.Meta.Hidden && !n.Meta.Internal {
+			n.Tree = n.Tree.visible()
+			v.Chords = append(v.Chords, n)
+		}
+	}
+	return v
+}
+
+// call is the payload of a call frame.
+type call struct {
+	Path  []string          `json:"path"`
+	Key   string            `json:"key,omitempty"`
+	Args  []string          `json:"args,omitempty"`
+	Flags map[string]string `json:"flags,omitempty"`
+}
+
+// input returns the input of the call.
+func (cl *call) input() *Input {
+	in := &Input{Key: cl.Key, Args: cl.Args, Flags: cl.Flags}
+	if in.Key == "" {
+		in.Key = strings.Join(cl.Path, " ")
+	}
+	if in.Flags == nil {
+		in.Flags = make(map[string]string)
+	}
+	return in
+}
+
+// writeExit writes the exit frame reporting the error.
+func writeExit(w io.Writer, err error) error {
+	payload := binary.BigEndian.AppendUint32(nil, uint32(ExitCode(err)))
+	if err != nil {
+		payload = append(payload, err.Error()...)
+	}
+	return writeFrame(w, frameExit, payload)
+}
+
+// writeFrame writes a frame of the kind with the payload.
+func writeFrame(w io.Writer, kind byte, payload []byte) error {
+	header := binary.BigEndian.AppendUint32(make([]byte, 0, 5), uint32(len(payload)))
+	header = append(header, kind)
+	_, err := w.Write(append(header, payload...))
+	return err
+}
+
+// readFrame reads a frame, whose payload may not exceed limit bytes.
+func readFrame(r io.Reader, limit int) (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:4])
+	if limit > 0 && n > uint32(limit) {
+		return 0, nil, fmt.Errorf("chord: frame of %d bytes exceeds the limit of %d bytes", n, limit)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[4], payload, nil
+}
+
+// frameWriter writes every Write as frames of its kind, of maxFrameSize bytes
+// at most.
+type frameWriter struct {
+	w    io.Writer
+	kind byte
+}
+
+// Write implements io.Writer.
+func (fw *frameWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), maxFrameSize)]
+		if err := writeFrame(fw.w, fw.kind, chunk); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+// Client executes command lines on a chord served by Serve, such as from the
+// companion command-line program of a daemon. Requests are sent in turn; a
+// Client is safe for concurrent use.
+type Client struct {
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+// Dial connects to the chord served on the address of the network, such as
+// "unix" and "/run/app.sock".
+func Dial(network, address string) (*Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient returns a Client sending its requests over the connection.
+func NewClient(conn net.Conn) *Client {
+	return &Client{conn: conn}
+}
+
+// Execute executes the command line on the remote chord, copying its output
+// to w as it is streamed. Errors of the remote thread are returned as a
+// *RemoteError. When the context is done, the connection is interrupted and
+// the client can no longer be used.
+func (cl *Client) Execute(ctx context.Context, line string, w io.Writer) error {
+	return cl.do(ctx, frameRequest, []byte(line), w)
+}
+
+// Call executes the thread of the path on the remote chord with the key,
+// arguments and flags of the input, as Execute does for a command line. The
+// reader side of the output and the context values aren't forwarded.
+func (cl *Client) Call(ctx context.Context, path []string, in *Input, w io.Writer) error {
+	payload, err := json.Marshal(&call{Path: path, Key: in.Key, Args: in.Args, Flags: in.Flags})
+	if err != nil {
+		return err
+	}
+	return cl.do(ctx, frameCall, payload, w)
+}
+
+// Tree returns the description of the remote chord, without its hidden and
+// internal threads and chords.
+func (cl *Client) Tree(ctx context.Context) (Tree, error) {
+	var buf bytes.Buffer
+	var tree Tree
+	if err := cl.do(ctx, frameTree, nil, &buf); err != nil {
+		return tree, err
+	}
+	err := json.Unmarshal(buf.Bytes(), &tree)
+	return tree, err
+}
+
+// Watch calls fn with the description of the remote chord, then again
+// whenever it changes, until the context is done or the connection fails.
+// The client can no longer be used once Watch returns.
+func (cl *Client) Watch(ctx context.Context, fn func(Tree)) error {
+	return cl.do(ctx, frameWatch, nil, &treeWriter{fn: fn})
+}
+
+// treeWriter decodes every write, an output frame of a watch, as a tree.
+type treeWriter struct {
+	fn func(Tree)
+}
+
+// Write implements io.Writer.
+func (w *treeWriter) Write(p []byte) (int, error) {
+	var tree Tree
+	if err := json.Unmarshal(p, &tree); err != nil {
+		return 0, err
+	}
+	w.fn(tree)
+	return len(p), nil
+}
+
+// do sends a request and reads its response, interrupting the connection when
+// the context is done.
+func (cl *Client) do(ctx context.Context, kind byte, payload []byte, w io.Writer) error {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	stop := context.AfterFunc(ctx, func() {
+		cl.conn.SetDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+
+	err := cl.roundTrip(kind, payload, w)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// roundTrip sends a request frame and reads the response.
+func (cl *Client) roundTrip(kind byte, payload []byte, w io.Writer) error {
+	if err := writeFrame(cl.conn, kind, payload); err != nil {
+		return err
+	}
+	for {
+		kind, payload, err := readFrame(cl.conn, maxFrameSize)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		switch kind {
+		case frameOutput:
+			if _, err := w.Write(payload); err != nil {
+				return err
+			}
+		case frameExit:
+			if len(payload) < 4 {
+				return errors.New("chord: invalid exit frame")
+			}
+			code := int(binary.BigEndian.Uint32(payload))
+			if code == ExitOK {
+				return nil
+			}
+			return &RemoteError{Code: code, Message: string(payload[4:])}
+		default:
+			return fmt.Errorf("chord: unexpected frame %q", kind)
+		}
+	}
+}
+
+// Close closes the connection of the client.
+func (cl *Client) Close() error {
+	return cl.conn.Close()
+}
+
+// RemoteError is the error of a thread executed remotely.
+type RemoteError struct {
+	Code    int    // Exit code of the error, as reported by ExitCode.
+	Message string // Message of the error.
+}
+
+// Error implements the error interface.
+func (e *RemoteError) Error() string {
+	return e.Message
+}
+
+// ExitCode implements ExitCoder, so that Run exits with the remote exit code.
+func (e *RemoteError) ExitCode() int {
+	return e.Code
+}
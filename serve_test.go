@@ -0,0 +1,98 @@
+package chord_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graphitects/chord"
+)
+
+// serve serves c on a local listener and returns a client connected to it.
+func serve(t *testing.T, c *chord.Chord) *chord.Client {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go c.Serve(l)
+	cl, err := chord.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cl.Close() })
+	return cl
+}
+
+func TestServeExecute(t *testing.T) {
+	c := chord.NewChord()
+	c.Register("greet", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString("hello " + in.Flags["name"])
+		return out.Flush()
+	})
+	cl := serve(t, c)
+	var buf bytes.Buffer
+	if err := cl.Execute(context.Background(), "greet --name=bob", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "hello bob" {
+		t.Fatalf("output = %q, want %q", got, "hello bob")
+	}
+}
+
+func TestServeLargeOutput(t *testing.T) {
+	large := strings.Repeat("x", 3<<20)
+	c := chord.NewChord()
+	c.Register("large", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString(large)
+		return out.Flush()
+	})
+	cl := serve(t, c)
+	var buf bytes.Buffer
+	if err := cl.Execute(context.Background(), "large", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != large {
+		t.Fatalf("output of %d bytes, want %d", buf.Len(), len(large))
+	}
+}
+
+func TestServeTreeOmitsHidden(t *testing.T) {
+	c := chord.NewChord()
+	c.Register("visible", nop)
+	c.RegisterWithMeta("hidden", nop, chord.Meta{Hidden: true})
+	c.RegisterWithMeta("internal", nop, chord.Meta{Internal: true})
+	c.MountWithMeta("secret", chord.NewChord(), chord.Meta{Hidden: true})
+	tree, err := serve(t, c).Tree(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Threads) != 1 || tree.Threads[0].Key != "visible" || len(tree.Chords) != 0 {
+		t.Fatalf("tree = %+v, want the visible thread only", tree)
+	}
+}
+
+func TestServeWatch(t *testing.T) {
+	c, sub := chord.NewChord(), chord.NewChord()
+	c.Mount("sub", sub)
+	cl := serve(t, c)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	trees := make(chan chord.Tree)
+	go cl.Watch(ctx, func(tree chord.Tree) { trees <- tree })
+	<-trees
+	sub.Register("added", nop)
+	select {
+	case tree := <-trees:
+		if threads := tree.Chords[0].Tree.Threads; len(threads) != 1 || threads[0].Key != "added" {
+			t.Fatalf("tree = %+v, want the thread added to the nested chord", tree)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("no description sent after a change of a nested chord")
+	}
+}
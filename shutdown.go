@@ -0,0 +1,77 @@
+package chord
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrShutdown is the error returned by the threads matched through a chord
+// after its Shutdown.
+var ErrShutdown = errors.New("chord: shut down")
+
+// inflight tracks the executions of the threads matched through a chord.
+type inflight struct {
+	active   int
+	shutdown bool
+	idle     chan struct{} // Closed once shut down with no active execution.
+	mu       sync.Mutex
+}
+
+// Shutdown stops the chord from accepting new executions and waits for the
+// executions in flight to return, such as to drain a server embedding the
+// chord before it exits. The executions in flight are the ones of the threads
+// matched from this chord, by Match or the dispatch functions; the threads
+// matched afterwards fail with ErrShutdown without being invoked. It returns
+// nil once the executions returned, or the error of ctx if it is done first.
+func (c *Chord) Shutdown(ctx context.Context) error {
+	f := &c.inflight
+	f.mu.Lock()
+	if !f.shutdown {
+		f.shutdown = true
+		f.idle = make(chan struct{})
+		if f.active == 0 {
+			close(f.idle)
+		}
+	}
+	idle := f.idle
+	f.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// track wraps the thread matched through the chord under the route so that its
+// executions are tracked for Shutdown, or rejected once shut down, and
+// accounted in the statistics of the chord.
+func (c *Chord) track(thread Thread, route []string) Thread {
+	if route == nil {
+		route = []string{}
+	}
+	return func(in *Input, out *Output) error {
+		f := &c.inflight
+		f.mu.Lock()
+		if f.shutdown {
+			f.mu.Unlock()
+			return ErrShutdown
+		}
+		f.active++
+		f.mu.Unlock()
+		start := time.Now()
+		defer func() {
+			f.mu.Lock()
+			f.active--
+			if f.active == 0 && f.shutdown {
+				close(f.idle)
+			}
+			f.mu.Unlock()
+		}()
+		err := thread(in, out)
+		c.recordStats(route, time.Since(start), err)
+		return err
+	}
+}
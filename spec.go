@@ -0,0 +1,180 @@
+package chord
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+)
+
+// SpecVersion is the version of the format of the Spec documents.
+const SpecVersion = "chord/v1"
+
+// Spec is a machine-readable description of the commands of a tree, as
+// returned by Chord.Spec, for external tooling to generate clients, user
+// interfaces or validation, in the spirit of OpenAPI.
+type Spec struct {
+	Spec     string        `json:"spec"` // SpecVersion.
+	Program  string        `json:"program,omitempty"`
+	Commands []CommandSpec `json:"commands"`
+}
+
+// CommandSpec describes a thread of a tree and how to invoke it.
+type CommandSpec struct {
+	Path        []string `json:"path"`             // Keys of the path, such as "users", ":id" and "show".
+	Params      []string `json:"params,omitempty"` // Names of the parameter and wildcard keys of the path.
+	Description string   `json:"description,omitempty"`
+	Usage       string   `json:"usage,omitempty"`
+	Examples    []string `json:"examples,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Deprecated  string   `json:"deprecated,omitempty"` // Deprecation notice of the thread or of a chord traversed.
+	// Flags are the flags of the metadata of the thread, followed by the
+	// persistent flags of the chords traversed, innermost first.
+	Flags []Flag `json:"flags,omitempty"`
+	Args  []Arg  `json:"args,omitempty"`
+}
+
+// Spec returns the description of the visible threads of the chord and of its
+// nested chords, in the order of Routes, with their metadata and the flags
+// they inherit from the chords traversed.
+func (c *Chord) Spec(program string) Spec {
+	s := Spec{Spec: SpecVersion, Program: program, Commands: []CommandSpec{}}
+	c.walkSpec(nil, nil, "", &s)
+	return s
+}
+
+// walkSpec implements Spec for the subtree mounted under path, whose chords
+// declare the persistent flags inherited, outermost first, and the deprecation
+// notice of the innermost deprecated chord.
+func (c *Chord) walkSpec(path []string, inherited [][]Flag, deprecated string, s *Spec) {
+	c.ensureLoaded()
+	if fs := c.fetchPersistentFlags(); fs != nil {
+		inherited = append(slices.Clip(inherited), fs.Flags())
+	}
+	for _, key := range sortedKeys(&c.threads) {
+		e, ok := c.fetchEntry(key)
+		if !ok || e.meta.Hidden || e.meta.Internal {
+			continue
+		}
+		cmd := CommandSpec{
+			Path:        append(slices.Clip(path), key),
+			Description: e.meta.Description,
+			Usage:       e.meta.Usage,
+			Examples:    slices.Clone(e.meta.Examples),
+			Tags:        slices.Clone(e.meta.Tags),
+			Deprecated:  e.meta.Deprecated,
+			Flags:       slices.Clone(e.meta.Flags),
+			Args:        slices.Clone(e.meta.Args),
+		}
+		if cmd.Deprecated == "" {
+			cmd.Deprecated = deprecated
+		}
+		for _, k := range cmd.Path {
+			switch {
+			case isParam(k):
+				cmd.Params = append(cmd.Params, k[1:])
+			case isWildcard(k):
+				cmd.Params = append(cmd.Params, wildcardName(k))
+			}
+		}
+		for i := len(inherited) - 1; i >= 0; i-- {
+			for _, f := range inherited[i] {
+				if !slices.ContainsFunc(cmd.Flags, func(g Flag) bool { return g.Name == f.Name }) {
+					cmd.Flags = append(cmd.Flags, f)
+				}
+			}
+		}
+		s.Commands = append(s.Commands, cmd)
+	}
+	for _, key := range sortedKeys(&c.chords) {
+		if m, ok := c.fetchMount(key); ok && !m.meta.Hidden && !m.meta.Internal {
+			notice := m.meta.Deprecated
+			if notice == "" {
+				notice = deprecated
+			}
+			m.chord.walkSpec(append(slices.Clip(path), key), inherited, notice, s)
+		}
+	}
+}
+
+// ExportSpec writes the Spec of the chord to w as an indented JSON document.
+func (c *Chord) ExportSpec(w io.Writer, program string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c.Spec(program))
+}
+
+// BindSpec returns the flags and the positional arguments declared by the
+// `chord` tags of the fields of the struct pointed to by v, as read by
+// BindFlags, for use as Meta.Flags and Meta.Args, so that the metadata of a
+// thread describes the struct it binds its input to. Floats are described as
+// string flags.
+func BindSpec(v any) ([]Flag, []Arg) {
+	rt := reflect.TypeOf(v)
+	for rt != nil && rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	var flags []Flag
+	var args []Arg
+	var indexes []int // Indexes of the positional arguments, the variadic ones last.
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup("chord")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		b := parseBindTag(tag)
+		switch {
+		case b.args:
+			name := b.name
+			if name == "" {
+				name = field.Name
+			}
+			args = append(args, Arg{Name: name, Variadic: true})
+			indexes = append(indexes, math.MaxInt)
+		case b.arg >= 0:
+			args = append(args, Arg{Name: b.name, Required: b.required})
+			indexes = append(indexes, b.arg)
+		default:
+			flags = append(flags, Flag{Name: b.name, Kind: bindKind(field.Type), Default: b.def, Required: b.required})
+		}
+	}
+	sort.Stable(argsByIndex{args, indexes})
+	return flags, args
+}
+
+// argsByIndex sorts positional arguments by their indexes.
+type argsByIndex struct {
+	args    []Arg
+	indexes []int
+}
+
+func (a argsByIndex) Len() int           { return len(a.args) }
+func (a argsByIndex) Less(i, j int) bool { return a.indexes[i] < a.indexes[j] }
+func (a argsByIndex) Swap(i, j int) {
+	a.args[i], a.args[j] = a.args[j], a.args[i]
+	a.indexes[i], a.indexes[j] = a.indexes[j], a.indexes[i]
+}
+
+// bindKind returns the kind of the flag bound to a field of the type.
+func bindKind(t reflect.Type) FlagKind {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return DurationFlag
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return BoolFlag
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return IntFlag
+	case reflect.Slice:
+		return StringSliceFlag
+	}
+	return StringFlag
+}
@@ -0,0 +1,193 @@
+/*
+Package sshadapter serves a chord tree over SSH.
+
+Each exec request of a session is a command line dispatched through the root
+chord with Chord.ExecuteLine, with the output of the thread wired to the SSH
+channel and its exit code reported as the exit status of the session. Shell
+requests start an interactive session reading command lines from the channel,
+with line editing and history when a pseudo-terminal was requested.
+*/
+package sshadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+
+	"github.com/graphitects/chord"
+)
+
+// Server serves the sessions of SSH connections by dispatching their commands
+// through a root chord.
+type Server struct {
+	// Config is the configuration of the SSH server, holding its host keys and
+	// authentication callbacks. A nil Config is an empty configuration.
+	Config *ssh.ServerConfig
+	// Prompt is written before reading each line of interactive sessions.
+	Prompt string
+	// Authenticator, when set, authenticates the connections during the
+	// handshake, with the password or the public key of the user, replacing
+	// the PasswordCallback and PublicKeyCallback of Config. The principal is
+	// placed in the context of every input of the connection.
+	Authenticator chord.Authenticator
+
+	root *chord.Chord
+}
+
+// New returns a Server dispatching the commands of its sessions through root.
+func New(root *chord.Chord, config *ssh.ServerConfig) *Server {
+	return &Server{Config: config, Prompt: "> ", root: root}
+}
+
+// Serve accepts connections on the listener and serves each of them in its
+// own goroutine. It returns the error of the listener.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeConn(context.Background(), nc)
+	}
+}
+
+// ServeConn performs the SSH handshake on the connection and serves its session
+// channels until the connection is closed. The context of every input is
+// derived from ctx, and canceled when the connection is closed.
+func (s *Server) ServeConn(ctx context.Context, nc net.Conn) error {
+	config := s.Config
+	if config == nil {
+		config = &ssh.ServerConfig{}
+	}
+	if s.Authenticator != nil {
+		config = s.authConfig(ctx, config)
+	}
+	conn, chans, reqs, err := ssh.NewServerConn(nc, config)
+	if err != nil {
+		nc.Close()
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(chord.WithExternal(ctx))
+	defer cancel()
+	// Connections accepted without authentication have no permissions.
+	if conn.Permissions != nil {
+		if p, ok := conn.Permissions.ExtraData[principalKey{}].(*chord.Principal); ok {
+			ctx = chord.WithPrincipal(ctx, p)
+		}
+	}
+
+	go ssh.DiscardRequests(reqs)
+	for nch := range chans {
+		if nch.ChannelType() != "session" {
+			nch.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		ch, reqs, err := nch.Accept()
+		if err != nil {
+			continue
+		}
+		go s.session(ctx, ch, reqs)
+	}
+	return nil
+}
+
+// principalKey is the key of the principal in the extra data of the
+// permissions of an authenticated connection.
+type principalKey struct{}
+
+// authConfig returns a copy of base authenticating the users with the
+// Authenticator.
+func (s *Server) authConfig(ctx context.Context, base *ssh.ServerConfig) *ssh.ServerConfig {
+	config := *base
+	authenticate := func(meta ssh.ConnMetadata, cred *chord.Credentials) (*ssh.Permissions, error) {
+		cred.RemoteAddr = meta.RemoteAddr()
+		cred.User = meta.User()
+		p, err := chord.Authenticate(ctx, s.Authenticator, cred)
+		if err != nil {
+			return nil, err
+		}
+		return &ssh.Permissions{ExtraData: map[any]any{principalKey{}: p}}, nil
+	}
+	config.PasswordCallback = func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+		return authenticate(meta, &chord.Credentials{Password: string(password)})
+	}
+	config.PublicKeyCallback = func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		return authenticate(meta, &chord.Credentials{PublicKey: key.Marshal()})
+	}
+	return &config
+}
+
+// session serves the requests of a session channel.
+func (s *Server) session(ctx context.Context, ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+	pty := false
+	for req := range reqs {
+		switch req.Type {
+		case "pty-req":
+			pty = true
+			req.Reply(true, nil)
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			err := s.root.ExecuteLine(ctx, payload.Command, ch, ch)
+			if err != nil {
+				fmt.Fprintln(ch.Stderr(), err)
+			}
+			exit(ch, chord.ExitCode(err))
+			return
+		case "shell":
+			req.Reply(true, nil)
+			go func() {
+				s.shell(ctx, ch, pty)
+				exit(ch, chord.ExitOK)
+				ch.Close()
+			}()
+		default:
+			if req.WantReply {
+				req.Reply(false, nil)
+			}
+		}
+	}
+}
+
+// shell runs an interactive session on the channel until it is closed.
+func (s *Server) shell(ctx context.Context, ch ssh.Channel, pty bool) {
+	if !pty {
+		repl := chord.NewREPL(s.root, ch, ch)
+		repl.Prompt = s.Prompt
+		repl.Run(ctx)
+		return
+	}
+	t := term.NewTerminal(ch, s.Prompt)
+	for ctx.Err() == nil {
+		line, err := t.ReadLine()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				fmt.Fprintln(t, err)
+			}
+			return
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := s.root.ExecuteLine(ctx, line, strings.NewReader(""), t); err != nil {
+			fmt.Fprintln(t, err)
+		}
+	}
+}
+
+// exit sends the exit status of the session.
+func exit(ch ssh.Channel, code int) {
+	ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(code)}))
+}
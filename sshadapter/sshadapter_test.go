@@ -0,0 +1,121 @@
+package sshadapter
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/graphitects/chord"
+)
+
+// serverConfig returns a configuration with a new host key and without
+// authentication.
+func serverConfig(t *testing.T) *ssh.ServerConfig {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+	return config
+}
+
+// dial serves s on a local listener and returns a client connected to it, or
+// the error of the handshake.
+func dial(t *testing.T, s *Server, config *ssh.ClientConfig) (*ssh.Client, error) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go s.Serve(l)
+	config.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+	c, err := ssh.Dial("tcp", l.Addr().String(), config)
+	if err != nil {
+		return nil, err
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, nil
+}
+
+func TestExec(t *testing.T) {
+	root := chord.NewChord()
+	root.Register("greet", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString("hello " + strings.Join(in.Args, " "))
+		return out.Flush()
+	})
+	c, err := dial(t, New(root, serverConfig(t)), &ssh.ClientConfig{User: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := c.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := session.Output("greet bob"); err != nil || string(got) != "hello bob" {
+		t.Fatalf("output = %q, %v, want %q", got, err, "hello bob")
+	}
+	session, err = c.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exit *ssh.ExitError
+	if _, err := session.Output("missing"); !errors.As(err, &exit) || exit.ExitStatus() != chord.ExitUsage {
+		t.Fatalf("error = %v, want the exit status %d", err, chord.ExitUsage)
+	}
+}
+
+func TestAuthenticator(t *testing.T) {
+	root := chord.NewChord()
+	root.Register("whoami", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString(chord.PrincipalOf(in).Name)
+		return out.Flush()
+	})
+	config := serverConfig(t)
+	config.NoClientAuth = false
+	s := New(root, config)
+	s.Authenticator = chord.AuthenticatorFunc(func(ctx context.Context, cred *chord.Credentials) (*chord.Principal, error) {
+		if cred.Password != "secret" {
+			return nil, errors.New("invalid password")
+		}
+		return &chord.Principal{Name: cred.User}, nil
+	})
+
+	if _, err := dial(t, s, &ssh.ClientConfig{User: "bob", Auth: []ssh.AuthMethod{ssh.Password("wrong")}}); err == nil {
+		t.Fatal("handshake with a wrong password succeeded")
+	}
+	c, err := dial(t, s, &ssh.ClientConfig{User: "bob", Auth: []ssh.AuthMethod{ssh.Password("secret")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := c.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := session.Output("whoami"); err != nil || string(got) != "bob" {
+		t.Fatalf("output = %q, %v, want %q", got, err, "bob")
+	}
+}
+
+func TestNilConfig(t *testing.T) {
+	s := New(chord.NewChord(), nil)
+	s.Authenticator = chord.AuthenticatorFunc(func(ctx context.Context, cred *chord.Credentials) (*chord.Principal, error) {
+		return &chord.Principal{Name: cred.User}, nil
+	})
+	if _, err := dial(t, s, &ssh.ClientConfig{User: "bob", Auth: []ssh.AuthMethod{ssh.Password("secret")}}); err == nil {
+		t.Fatal("handshake with a server without host keys succeeded")
+	}
+}
@@ -0,0 +1,89 @@
+package chord
+
+import (
+	"expvar"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ThreadStats is a snapshot of the statistics of the executions of a thread
+// matched through a chord.
+type ThreadStats struct {
+	Route           []string      `json:"route"`                  // Route of the thread, as reported by Route.
+	Executions      uint64        `json:"executions"`             // Executions which returned.
+	Errors          uint64        `json:"errors"`                 // Executions which returned an error.
+	LastError       string        `json:"last_error,omitempty"`   // Message of the last error.
+	LastErrorAt     time.Time     `json:"last_error_at,omitzero"` // Time of the last error.
+	AverageDuration time.Duration `json:"average_duration_ns"`    // Average duration of the executions.
+}
+
+// threadStats accumulates the statistics of the executions of a route.
+type threadStats struct {
+	route       []string
+	executions  uint64
+	errors      uint64
+	total       time.Duration
+	lastError   string
+	lastErrorAt time.Time
+	mu          sync.Mutex
+}
+
+// Stats returns a snapshot of the statistics of the threads executed through
+// the chord, by Match or the dispatch functions, in lexical order of their
+// routes, for lightweight observability without a metrics system.
+func (c *Chord) Stats() []ThreadStats {
+	var stats []ThreadStats
+	c.stats.Range(func(_, v any) bool {
+		s := v.(*threadStats)
+		s.mu.Lock()
+		ts := ThreadStats{
+			Route:       slices.Clone(s.route),
+			Executions:  s.executions,
+			Errors:      s.errors,
+			LastError:   s.lastError,
+			LastErrorAt: s.lastErrorAt,
+		}
+		if s.executions > 0 {
+			ts.AverageDuration = s.total / time.Duration(s.executions)
+		}
+		s.mu.Unlock()
+		stats = append(stats, ts)
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		return strings.Join(stats[i].Route, "\x00") < strings.Join(stats[j].Route, "\x00")
+	})
+	return stats
+}
+
+// PublishExpvar publishes the statistics of the chord, as returned by Stats,
+// as the expvar variable of the given name, served as JSON under
+// /debug/vars by the expvar package. Like expvar.Publish, it panics if the
+// name is already in use.
+func (c *Chord) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return c.Stats()
+	}))
+}
+
+// recordStats records an execution of the route, which took d and returned err.
+func (c *Chord) recordStats(route []string, d time.Duration, err error) {
+	key := strings.Join(route, "\x00")
+	v, ok := c.stats.Load(key)
+	if !ok {
+		v, _ = c.stats.LoadOrStore(key, &threadStats{route: route})
+	}
+	s := v.(*threadStats)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.executions++
+	s.total += d
+	if err != nil {
+		s.errors++
+		s.lastError = err.Error()
+		s.lastErrorAt = time.Now()
+	}
+}
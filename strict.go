@@ -0,0 +1,69 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNilThread is the error matched by errors.Is when a nil thread is
+// registered on a strict chord.
+var ErrNilThread = errors.New("chord: nil thread")
+
+// ErrNilChord is the error matched by errors.Is when a nil chord is mounted.
+var ErrNilChord = errors.New("chord: nil chord")
+
+// SetStrict enables or disables the strict mode of the chord, in which its
+// registrations also fail on the wiring mistakes which would otherwise only
+// surface at dispatch time: registering a nil thread, failing with
+// ErrNilThread, and registering a thread under the key of a mounted chord, or
+// mounting a chord under the key of a registered thread, failing with a
+// *DuplicateError. Duplicate and invalid keys, and nil chords, fail in any
+// mode. The chords mounted by
+// RegisterPattern inherit the mode of their parent.
+func (c *Chord) SetStrict(enabled bool) {
+	c.strict.Store(enabled)
+}
+
+// MustRegister registers a thread under key as Register does, and panics if
+// the registration fails, such as to wire a tree at startup.
+func (c *Chord) MustRegister(key string, thread Thread, tw ...ThreadWrapper) *Registration {
+	r, err := c.Register(key, thread, tw...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// MustMount mounts a chord under key as Mount does, and panics if the mount
+// fails.
+func (c *Chord) MustMount(key string, chord *Chord, tw ...ThreadWrapper) {
+	if err := c.Mount(key, chord, tw...); err != nil {
+		panic(err)
+	}
+}
+
+// checkThread rejects the registration under key of a missing thread, or
+// thread factory, when the chord is strict.
+func (c *Chord) checkThread(key string, missing bool) error {
+	if missing && c.strict.Load() {
+		return fmt.Errorf("%w registered under %q", ErrNilThread, key)
+	}
+	return nil
+}
+
+// checkShadow rejects, when the chord is strict, the registration of a thread
+// under the key of a mounted chord, or the mount of a chord under the key of
+// a registered thread, which would shadow one another.
+func (c *Chord) checkShadow(key string, mount bool) error {
+	if !c.strict.Load() {
+		return nil
+	}
+	if mount {
+		if _, ok := c.threads.Load(key); ok {
+			return &DuplicateError{Key: key}
+		}
+	} else if _, ok := c.chords.Load(key); ok {
+		return &DuplicateError{Key: key, Mount: true}
+	}
+	return nil
+}
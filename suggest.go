@@ -0,0 +1,143 @@
+package chord
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Suggest returns the registered keys closest to the first key of the path
+// which doesn't match from the chord, such as "status" for "stauts" in
+// "service stauts", for adapters to suggest them. Keys are compared by edit
+// distance, counting transpositions as one edit, and the keys the mistyped
+// key is a prefix of are suggested as well. The keys of the threads and the
+// chords of the level where matching fails are considered, along with their
+// aliases, but not hidden, internal, parameter or wildcard keys. The closest
+// keys come first. Suggest returns nil when the path matches.
+func (c *Chord) Suggest(path []string) []string {
+	node := c
+	for i, key := range path {
+		key = node.unalias(key)
+		if i == len(path)-1 {
+			if _, ok := node.fetchEntry(key); ok {
+				return nil
+			}
+		} else if m, ok := node.fetchMount(key); ok {
+			node = m.chord
+			continue
+		} else if key, ok := dynamicKey(&node.chords, isParam); ok {
+			m, _ := node.fetchMount(key)
+			if m != nil {
+				node = m.chord
+				continue
+			}
+		}
+		return node.suggestions(node.foldKey(path[i]))
+	}
+	return nil
+}
+
+// suggestions returns the visible keys and aliases of the chord close to key,
+// closest first.
+func (c *Chord) suggestions(key string) []string {
+	type candidate struct {
+		key  string
+		dist int
+	}
+	var cands []candidate
+	add := func(k string) {
+		if isParam(k) || isWildcard(k) {
+			return
+		}
+		d := editDistance(key, k)
+		if d <= maxSuggestionDistance(key) || strings.HasPrefix(k, key) {
+			cands = append(cands, candidate{k, d})
+		}
+	}
+	c.ensureLoaded()
+	for _, k := range sortedKeys(&c.threads) {
+		if e, ok := c.fetchEntry(k); ok && !e.meta.Hidden && !e.meta.Internal {
+			add(k)
+		}
+	}
+	for _, k := range sortedKeys(&c.chords) {
+		if m, ok := c.fetchMount(k); ok && !m.meta.Hidden && !m.meta.Internal {
+			add(k)
+		}
+	}
+	c.mu.RLock()
+	for alias := range c.aliases {
+		add(alias)
+	}
+	c.mu.RUnlock()
+	sort.SliceStable(cands, func(i, j int) bool {
+		if cands[i].dist != cands[j].dist {
+			return cands[i].dist < cands[j].dist
+		}
+		return cands[i].key < cands[j].key
+	})
+	var keys []string
+	for _, cand := range cands {
+		keys = append(keys, cand.key)
+	}
+	return keys
+}
+
+// notFoundError returns the *NotFoundError of the path, holding the
+// suggestions of the chord for it.
+func (c *Chord) notFoundError(path []string) *NotFoundError {
+	return &NotFoundError{Path: path, Suggestions: c.Suggest(path)}
+}
+
+// maxSuggestionDistance returns the maximum edit distance of the keys
+// suggested for key, growing with its length.
+func maxSuggestionDistance(key string) int {
+	switch n := len([]rune(key)); {
+	case n <= 4:
+		return 1
+	case n <= 8:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// editDistance returns the optimal string alignment distance between a and b:
+// the number of insertions, deletions, substitutions and transpositions of
+// adjacent runes turning a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// quoteList returns the strings quoted and joined as "a", "b" or "c".
+func quoteList(s []string) string {
+	q := make([]string, len(s))
+	for i, v := range s {
+		q[i] = strconv.Quote(v)
+	}
+	if len(q) == 1 {
+		return q[0]
+	}
+	return strings.Join(q[:len(q)-1], ", ") + " or " + q[len(q)-1]
+}
@@ -0,0 +1,194 @@
+/*
+Package tcpadapter serves a chord tree over a newline-delimited TCP protocol.
+
+Every line received on a connection is a command line, tokenized with
+chord.ParseLine and dispatched through the root chord, the thread being
+selected by the longest prefix of its path. The output of the thread is
+streamed back on the connection, one write per flush of its output, and errors
+are written as a line. This makes a chord reachable with telnet or netcat.
+*/
+package tcpadapter
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphitects/chord"
+)
+
+// ConnWrapper returns the ThreadWrapper to apply to the threads dispatched from
+// the lines of a connection, such as to authorize them by remote address. It
+// may return nil to leave them unwrapped.
+type ConnWrapper func(conn net.Conn) chord.ThreadWrapper
+
+// Server serves the threads of a root chord over TCP connections.
+type Server struct {
+	// Prompt, when not empty, is written before reading every line.
+	Prompt string
+	// IdleTimeout, when positive, closes the connections which send no line
+	// for this duration.
+	IdleTimeout time.Duration
+	// WriteTimeout, when positive, is the maximum duration of every write on a
+	// connection.
+	WriteTimeout time.Duration
+	// ExecTimeout, when positive, is the maximum duration of every thread; the
+	// context of its input is canceled once it elapses.
+	ExecTimeout time.Duration
+	// Authenticator, when set, authenticates every connection before serving
+	// it, with its remote address and, for TLS connections, the state of the
+	// connection. The error of a connection failing is written to it and
+	// returned by ServeConn. The principal is placed in the context of every
+	// input of the connection.
+	Authenticator chord.Authenticator
+
+	root *chord.Chord
+
+	// wrappers are the connection wrappers applied, in FIFO order, to the
+	// threads dispatched from the lines of every connection.
+	wrappers []ConnWrapper
+	mu       sync.RWMutex
+}
+
+// New returns a Server dispatching the lines of its connections through root.
+func New(root *chord.Chord) *Server {
+	return &Server{root: root}
+}
+
+// Use registers connection wrappers, applied in FIFO order to the threads
+// dispatched from the lines of every connection accepted afterwards.
+func (s *Server) Use(cw ...ConnWrapper) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wrappers = append(s.wrappers, cw...)
+}
+
+// Serve accepts connections on the listener and serves them until accepting
+// fails, such as when the listener is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer nc.Close()
+			s.ServeConn(context.Background(), nc)
+		}()
+	}
+}
+
+// ServeConn serves the lines of a connection until it is closed, it times out
+// or the context is done; the context of every input is derived from ctx.
+func (s *Server) ServeConn(ctx context.Context, nc net.Conn) error {
+	ctx, cancel := context.WithCancel(chord.WithExternal(ctx))
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		nc.SetDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+
+	w := &deadlineWriter{conn: nc, timeout: s.WriteTimeout}
+	if s.Authenticator != nil {
+		p, err := s.authenticate(ctx, nc)
+		if err != nil {
+			fmt.Fprintln(w, err)
+			return err
+		}
+		ctx = chord.WithPrincipal(ctx, p)
+	}
+
+	s.mu.RLock()
+	var tw []chord.ThreadWrapper
+	for _, cw := range s.wrappers {
+		if w := cw(nc); w != nil {
+			tw = append(tw, w)
+		}
+	}
+	s.mu.RUnlock()
+
+	sc := bufio.NewScanner(nc)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if s.Prompt != "" {
+			if _, err := io.WriteString(w, s.Prompt); err != nil {
+				return err
+			}
+		}
+		if s.IdleTimeout > 0 {
+			nc.SetReadDeadline(time.Now().Add(s.IdleTimeout))
+		}
+		if !sc.Scan() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return sc.Err()
+		}
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if err := s.exec(ctx, line, tw, w); err != nil {
+			if _, err := fmt.Fprintln(w, err); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// authenticate authenticates the connection with the Authenticator,
+// completing the TLS handshake of TLS connections first.
+func (s *Server) authenticate(ctx context.Context, nc net.Conn) (*chord.Principal, error) {
+	cred := &chord.Credentials{RemoteAddr: nc.RemoteAddr()}
+	if tc, ok := nc.(*tls.Conn); ok {
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return nil, err
+		}
+		state := tc.ConnectionState()
+		cred.TLS = &state
+	}
+	return chord.Authenticate(ctx, s.Authenticator, cred)
+}
+
+// exec executes the thread of the line, wrapped with the connection wrappers,
+// writing its output to w.
+func (s *Server) exec(ctx context.Context, line string, tw []chord.ThreadWrapper, w io.Writer) error {
+	thread, in, err := s.root.MatchLine(line)
+	if err != nil {
+		return err
+	}
+	if s.ExecTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.ExecTimeout)
+		defer cancel()
+	}
+	bw := bufio.NewWriter(w)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bw)}
+	err = chord.Invoke(chord.WrapThreads(thread, tw...), in.WithContext(ctx), out)
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// deadlineWriter writes to a connection, bounding every write by a timeout.
+type deadlineWriter struct {
+	conn    net.Conn
+	timeout time.Duration
+}
+
+// Write implements io.Writer.
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+	if w.timeout > 0 {
+		w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	}
+	return w.conn.Write(p)
+}
@@ -0,0 +1,76 @@
+package tcpadapter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graphitects/chord"
+)
+
+// session serves a connection of s in memory and returns the client side.
+func session(t *testing.T, s *Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	server, client := net.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.ServeConn(ctx, server)
+		server.Close()
+	}()
+	t.Cleanup(func() {
+		cancel()
+		client.Close()
+		<-done
+	})
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	return client, bufio.NewReader(client)
+}
+
+func TestServeConn(t *testing.T) {
+	root := chord.NewChord()
+	root.Register("greet", func(in *chord.Input, out *chord.Output) error {
+		fmt.Fprintln(out, "hello", strings.Join(in.Args, " "))
+		return out.Flush()
+	})
+	var wrapped []string
+	s := New(root)
+	s.Use(func(conn net.Conn) chord.ThreadWrapper {
+		return func(next chord.Thread) chord.Thread {
+			return func(in *chord.Input, out *chord.Output) error {
+				wrapped = append(wrapped, in.Args...)
+				return next(in, out)
+			}
+		}
+	})
+	conn, r := session(t, s)
+
+	fmt.Fprintln(conn, "greet bob")
+	if line, err := r.ReadString('\n'); err != nil || line != "hello bob\n" {
+		t.Fatalf("line = %q, %v, want %q", line, err, "hello bob\n")
+	}
+	if len(wrapped) != 1 || wrapped[0] != "bob" {
+		t.Fatalf("connection wrapper saw %q, want [bob]", wrapped)
+	}
+	fmt.Fprintln(conn, "missing")
+	if line, _ := r.ReadString('\n'); !strings.Contains(line, "no thread found") {
+		t.Fatalf("line = %q, want a not found error", line)
+	}
+}
+
+func TestAuthenticator(t *testing.T) {
+	s := New(chord.NewChord())
+	s.Authenticator = chord.AuthenticatorFunc(func(ctx context.Context, cred *chord.Credentials) (*chord.Principal, error) {
+		return nil, errors.New("unknown address")
+	})
+	_, r := session(t, s)
+	if line, _ := r.ReadString('\n'); !strings.Contains(line, "unauthenticated") {
+		t.Fatalf("line = %q, want an unauthenticated error", line)
+	}
+}
@@ -0,0 +1,98 @@
+package chord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is the error returned by the threads wrapped by Timeout when
+// they exceed their timeout.
+type TimeoutError struct {
+	Timeout time.Duration // Timeout exceeded.
+	Err     error         // Error returned by the thread, if any.
+}
+
+// Error implements the error interface.
+func (e *TimeoutError) Error() string {
+	msg := fmt.Sprintf("chord: thread timed out after %v", e.Timeout)
+	if e.Err != nil && !errors.Is(e.Err, context.DeadlineExceeded) {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the error of the thread, and context.DeadlineExceeded so
+// that errors.Is reports timeouts as for contexts.
+func (e *TimeoutError) Unwrap() []error {
+	return []error{e.Err, context.DeadlineExceeded}
+}
+
+// ExitCode implements ExitCoder, returning ExitTimeout.
+func (e *TimeoutError) ExitCode() int {
+	return ExitTimeout
+}
+
+// Timeout returns a ThreadWrapper running the threads it wraps with a deadline
+// of d after their start, derived from the context of the input. Passed to
+// Register, it bounds the executions of the registered thread:
+//
+//	root.Register("report", report, chord.Timeout(30*time.Second))
+//
+// Threads observe the deadline through the context of their input, which
+// aborts the commands of ExecThread and the remote calls. An execution still
+// running at the deadline returns a *TimeoutError right away, without waiting
+// for the thread, whose further writes to the output are discarded. A duration
+// of zero or less doesn't bound the executions.
+func Timeout(d time.Duration) ThreadWrapper {
+	return func(next Thread) Thread {
+		if d <= 0 {
+			return next
+		}
+		return func(in *Input, out *Output) error {
+			ctx, cancel := context.WithTimeout(in.Context(), d)
+			defer cancel()
+			tout, flush := cancelOutput(ctx, out)
+			done := make(chan timeoutResult, 1)
+			go func() {
+				var res timeoutResult
+				defer func() {
+					if res.panicked = !res.returned; res.panicked {
+						res.value = recover()
+					}
+					done <- res
+				}()
+				res.err = next(in.WithContext(ctx), tout)
+				res.returned = true
+			}()
+			select {
+			case res := <-done:
+				if res.panicked {
+					panic(res.value)
+				}
+				if ferr := flush(); res.err == nil {
+					res.err = ferr
+				}
+				if res.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && in.Context().Err() == nil {
+					return &TimeoutError{Timeout: d, Err: res.err}
+				}
+				return res.err
+			case <-ctx.Done():
+				if in.Context().Err() != nil {
+					return in.Context().Err()
+				}
+				return &TimeoutError{Timeout: d}
+			}
+		}
+	}
+}
+
+// timeoutResult is the outcome of a thread run by Timeout: the error it
+// returned, or the value it panicked with.
+type timeoutResult struct {
+	err      error
+	returned bool
+	panicked bool
+	value    any
+}
@@ -0,0 +1,160 @@
+package chord
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// VersionFlag is the flag of an input selecting the version of the thread
+// registered by RegisterVersion to dispatch, such as --api-version=v2.
+const VersionFlag = "api-version"
+
+// ErrUnknownVersion is the error matched by errors.Is when an input selects a
+// version of a thread which isn't registered.
+var ErrUnknownVersion = errors.New("chord: unknown version")
+
+// VersionError is returned when an input selects a version of a thread which
+// isn't registered.
+type VersionError struct {
+	Key       string   // Key of the thread.
+	Version   string   // Version selected.
+	Available []string // Versions registered, in order of registration.
+}
+
+// Error implements the error interface.
+func (e *VersionError) Error() string {
+	return "chord: unknown version " + strconv.Quote(e.Version) + " of " + strconv.Quote(e.Key) +
+		", available: " + strings.Join(e.Available, ", ")
+}
+
+// Unwrap returns ErrUnknownVersion, so that errors.Is(err, ErrUnknownVersion)
+// reports true.
+func (e *VersionError) Unwrap() error {
+	return ErrUnknownVersion
+}
+
+// ExitCode returns ExitUsage.
+func (e *VersionError) ExitCode() int {
+	return ExitUsage
+}
+
+// threadVersion is a version of a thread registered by RegisterVersion.
+type threadVersion struct {
+	name   string
+	thread Thread
+}
+
+// RegisterVersion registers a version of the thread under key, alongside the
+// versions already registered by RegisterVersion, so that the behavior of a
+// thread can be migrated gradually. The version dispatched is the one selected
+// by the VersionFlag of the input, or else the default version of the chord,
+// as set by SetDefaultVersion, if registered, or else the latest version
+// registered. A *VersionError is returned when the input selects a version
+// which isn't registered. Registering a version again replaces it, while a
+// thread registered otherwise under the key, such as with Register, fails the
+// registration with a *DuplicateError, and Replace replaces all the versions.
+// The thread is wrapped with the provided wrappers, in FIFO order, and the key
+// is validated, and the Registration returned, as by Register.
+func (c *Chord) RegisterVersion(key, version string, thread Thread, tw ...ThreadWrapper) (*Registration, error) {
+	if version == "" {
+		panic("chord: empty version of " + key)
+	}
+	key = c.foldKey(key)
+	if err := c.validateKey(key); err != nil {
+		return nil, err
+	}
+	if err := c.checkThread(key, thread == nil); err != nil {
+		return nil, err
+	}
+	if err := c.checkShadow(key, false); err != nil {
+		return nil, err
+	}
+	v := threadVersion{name: version, thread: WrapThreads(thread, tw...)}
+	e, err := c.storeVersion(key, v)
+	if err != nil {
+		return nil, err
+	}
+	c.notify(EventRegister, key)
+	return &Registration{c: c, key: key, e: e}, nil
+}
+
+// storeVersion adds the version to the entry of the versions registered under
+// key, storing a new entry if there is none, and returns the entry.
+func (c *Chord) storeVersion(key string, v threadVersion) (*entry, error) {
+	e := &entry{}
+	e.versions.Store(&[]threadVersion{v})
+	e.thread = c.versionedThread(key, e)
+	actual, loaded := c.threads.LoadOrStore(key, e)
+	if !loaded {
+		return e, nil
+	}
+	e = actual.(*entry)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	vs := e.versions.Load()
+	if vs == nil {
+		return nil, &DuplicateError{Key: key}
+	}
+	next := slices.DeleteFunc(slices.Clone(*vs), func(o threadVersion) bool { return o.name == v.name })
+	next = append(next, v)
+	e.versions.Store(&next)
+	return e, nil
+}
+
+// SetDefaultVersion sets the version of the threads of the chord registered by
+// RegisterVersion dispatched when the input selects none. The threads without
+// such a version dispatch their latest version. An empty version, the default,
+// dispatches the latest versions.
+func (c *Chord) SetDefaultVersion(version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defaultVersion = version
+}
+
+// FetchVersions returns the versions of the thread registered under key by
+// RegisterVersion, in order of registration, or nil if none.
+func (c *Chord) FetchVersions(key string) []string {
+	e, ok := c.fetchEntry(key)
+	if !ok {
+		return nil
+	}
+	vs := e.versions.Load()
+	if vs == nil {
+		return nil
+	}
+	names := make([]string, len(*vs))
+	for i, v := range *vs {
+		names[i] = v.name
+	}
+	return names
+}
+
+// versionedThread returns the thread of the entry of the versions registered
+// under key, dispatching the version selected as documented by RegisterVersion.
+func (c *Chord) versionedThread(key string, e *entry) Thread {
+	return func(in *Input, out *Output) error {
+		vs := *e.versions.Load()
+		version, explicit := in.Flags[VersionFlag]
+		if !explicit || version == "" {
+			c.mu.RLock()
+			version = c.defaultVersion
+			c.mu.RUnlock()
+			explicit = false
+		}
+		for _, v := range vs {
+			if v.name == version {
+				return v.thread(in, out)
+			}
+		}
+		if explicit {
+			err := &VersionError{Key: key, Version: version}
+			for _, v := range vs {
+				err.Available = append(err.Available, v.name)
+			}
+			return err
+		}
+		return vs[len(vs)-1].thread(in, out)
+	}
+}
@@ -0,0 +1,69 @@
+package chord
+
+import "slices"
+
+// Walk calls fn for every thread of the chord and of its nested chords, with
+// the path under which the thread is matched from the chord, the thread as it
+// was registered (without the middleware of the chords) and its metadata.
+// The threads of a chord are visited in lexical order of their keys, before
+// the threads of its nested chords. Walking stops when fn returns false.
+func (c *Chord) Walk(fn func(path []string, t Thread, meta Meta) bool) {
+	c.walkEntries(nil, func(path []string, e *entry) bool {
+		return fn(path, e.thread, e.meta.clone())
+	})
+}
+
+// walkEntries implements Walk for the subtree mounted under path, visiting the
+// entries of the threads, and reports whether the walk should continue.
+func (c *Chord) walkEntries(path []string, fn func(path []string, e *entry) bool) bool {
+	c.ensureLoaded()
+	for _, key := range sortedKeys(&c.threads) {
+		e, ok := c.fetchEntry(key)
+		if !ok {
+			continue
+		}
+		if !fn(append(slices.Clip(path), key), e) {
+			return false
+		}
+	}
+	for _, key := range sortedKeys(&c.chords) {
+		m, ok := c.fetchMount(key)
+		if !ok {
+			continue
+		}
+		if !m.chord.walkEntries(append(slices.Clip(path), key), fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Routes returns the paths of the threads of the chord and of its nested
+// chords, in the order Walk visits them, for building documentation,
+// completion data or assertions about the shape of the tree. Hidden and
+// internal threads are omitted, as are the subtrees of hidden and internal
+// chords. Parameter and wildcard keys are returned as registered, such as
+// ":id" or "*path".
+func (c *Chord) Routes() [][]string {
+	var routes [][]string
+	c.walkVisible(nil, func(path []string, _ Meta) {
+		routes = append(routes, path)
+	})
+	return routes
+}
+
+// walkVisible calls fn for the visible threads of the subtree mounted under
+// path, in the order of Routes, with their paths and metadata.
+func (c *Chord) walkVisible(path []string, fn func(path []string, meta Meta)) {
+	c.ensureLoaded()
+	for _, key := range sortedKeys(&c.threads) {
+		if e, ok := c.fetchEntry(key); ok && !e.meta.Hidden && !e.meta.Internal {
+			fn(append(slices.Clip(path), key), e.meta)
+		}
+	}
+	for _, key := range sortedKeys(&c.chords) {
+		if m, ok := c.fetchMount(key); ok && !m.meta.Hidden && !m.meta.Internal {
+			m.chord.walkVisible(append(slices.Clip(path), key), fn)
+		}
+	}
+}
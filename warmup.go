@@ -0,0 +1,65 @@
+package chord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SetWarmup sets the function warming up the thread registered under key, such
+// as to fill its caches or open its connections, called by Warm. Passing nil
+// removes it. Registering a thread under the key removes it too. It reports
+// whether a thread is registered under key.
+func (c *Chord) SetWarmup(key string, fn func(ctx context.Context) error) bool {
+	e, ok := c.fetchEntry(key)
+	if !ok {
+		return false
+	}
+	if fn == nil {
+		e.warmup.Store(nil)
+	} else {
+		e.warmup.Store(&fn)
+	}
+	return true
+}
+
+// Warm warms up the threads of the chord and of its nested chords, such as
+// before a service takes traffic: the threads registered by RegisterFactory
+// are built and the warmup functions set by SetWarmup are called, all
+// concurrently. It returns once all of them returned, with their errors
+// joined in the order of Walk and prefixed by the path of their thread.
+func (c *Chord) Warm(ctx context.Context) error {
+	var results []*error // Errors of the warmups, in the order of Walk.
+	var wg sync.WaitGroup
+	c.walkEntries(nil, func(path []string, e *entry) bool {
+		warmup := e.warmup.Load()
+		if e.factory == nil && warmup == nil {
+			return true
+		}
+		res := new(error)
+		results = append(results, res)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if e.factory != nil {
+				_, err = e.factory.get()
+			}
+			if err == nil && warmup != nil {
+				err = (*warmup)(ctx)
+			}
+			if err != nil {
+				*res = fmt.Errorf("%s: %w", strings.Join(path, " "), err)
+			}
+		}()
+		return true
+	})
+	wg.Wait()
+	errs := make([]error, len(results))
+	for i, res := range results {
+		errs[i] = *res
+	}
+	return errors.Join(errs...)
+}
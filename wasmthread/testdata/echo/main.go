@@ -0,0 +1,27 @@
+// Command echo is a module of the tests, writing its arguments, the key of its
+// input and its CHORD_FLAG_NAME variable, and exits with the status of its exit
+// flag.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	var in struct {
+		Key   string            `json:"key"`
+		Flags map[string]string `json:"flags"`
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&in); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s %s %s", strings.Join(os.Args, " "), in.Key, os.Getenv("CHORD_FLAG_NAME"))
+	if code, err := strconv.Atoi(in.Flags["exit"]); err == nil {
+		os.Exit(code)
+	}
+}
@@ -0,0 +1,8 @@
+// Command v2 is a module of the tests, writing "v2".
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Print("v2")
+}
@@ -0,0 +1,177 @@
+/*
+Package wasmthread runs WebAssembly modules as sandboxed threads, with the
+wazero runtime.
+
+A module is a WASI command, such as a Go program built with GOOS=wasip1. Every
+execution of its thread instantiates the module afresh: the input is passed as
+a JSON Input document on the standard input of the module, its arguments also
+as command-line arguments and its flags as CHORD_FLAG_<NAME> environment
+variables, and the standard output and error of the module are streamed to the
+output of the thread. Modules have no access to the filesystem or network of
+the host, and can be swapped at runtime without re-registering their thread.
+*/
+package wasmthread
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+
+	"github.com/graphitects/chord"
+)
+
+// Input is the document passed on the standard input of a module.
+type Input struct {
+	Key    string            `json:"key"`
+	Args   []string          `json:"args"`
+	Flags  map[string]string `json:"flags"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// Runtime compiles and runs WebAssembly modules.
+type Runtime struct {
+	rt wazero.Runtime
+}
+
+// New returns a Runtime providing WASI to its modules. The executions of the
+// modules are interrupted when the context of their input is done.
+func New(ctx context.Context) *Runtime {
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	wasi_snapshot_preview1.MustInstantiate(ctx, rt)
+	return &Runtime{rt: rt}
+}
+
+// Close releases the resources of the runtime and of its modules.
+func (r *Runtime) Close(ctx context.Context) error {
+	return r.rt.Close(ctx)
+}
+
+// Compile compiles the binary of a module named name, the first command-line
+// argument of its executions.
+func (r *Runtime) Compile(ctx context.Context, name string, wasm []byte) (*Module, error) {
+	compiled, err := r.rt.CompileModule(ctx, wasm)
+	if err != nil {
+		return nil, err
+	}
+	return &Module{rt: r, name: name, bin: &binary{compiled: compiled}}, nil
+}
+
+// Module is a compiled module, whose binary can be swapped at runtime.
+type Module struct {
+	rt   *Runtime
+	name string
+	bin  *binary
+	mu   sync.Mutex
+}
+
+// binary is a compiled binary of a module, with the number of its running
+// executions.
+type binary struct {
+	compiled wazero.CompiledModule
+	refs     int
+}
+
+// Swap compiles a new binary for the module. Subsequent executions of its
+// thread run the new binary, while running ones complete with the former,
+// which is closed once the last of them is done.
+func (m *Module) Swap(ctx context.Context, wasm []byte) error {
+	compiled, err := m.rt.rt.CompileModule(ctx, wasm)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	old := m.bin
+	m.bin = &binary{compiled: compiled}
+	idle := old.refs == 0
+	m.mu.Unlock()
+	if idle {
+		return old.compiled.Close(ctx)
+	}
+	return nil
+}
+
+// acquire returns the current binary of the module, which must be released
+// once its execution is done.
+func (m *Module) acquire() *binary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bin.refs++
+	return m.bin
+}
+
+// release ends an execution of b, closing b if it was the last execution of a
+// swapped binary.
+func (m *Module) release(b *binary) {
+	m.mu.Lock()
+	b.refs--
+	closed := b.refs == 0 && b != m.bin
+	m.mu.Unlock()
+	if closed {
+		b.compiled.Close(context.Background())
+	}
+}
+
+// Thread returns a thread executing the module, as documented by the package.
+// A module exiting with a non-zero status fails with an *ExitError.
+func (m *Module) Thread() chord.Thread {
+	return func(in *chord.Input, out *chord.Output) error {
+		stdin, err := json.Marshal(&Input{Key: in.Key, Args: in.Args, Flags: in.Flags, Params: in.Params})
+		if err != nil {
+			return err
+		}
+		cfg := wazero.NewModuleConfig().
+			// Anonymous instances may run concurrently.
+			WithName("").
+			WithArgs(append([]string{m.name}, in.Args...)...).
+			WithStdin(bytes.NewReader(stdin))
+		if out != nil && out.Writer != nil {
+			cfg = cfg.WithStdout(out).WithStderr(out)
+		}
+		for _, kv := range chord.FlagEnv(in.Flags) {
+			k, v, _ := strings.Cut(kv, "=")
+			cfg = cfg.WithEnv(k, v)
+		}
+
+		bin := m.acquire()
+		defer m.release(bin)
+		ctx := in.Context()
+		mod, err := m.rt.rt.InstantiateModule(ctx, bin.compiled, cfg)
+		if mod != nil {
+			mod.Close(ctx)
+		}
+		var exit *sys.ExitError
+		if errors.As(err, &exit) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if exit.ExitCode() == 0 {
+				return nil
+			}
+			return &ExitError{Code: int(exit.ExitCode())}
+		}
+		return err
+	}
+}
+
+// ExitError reports the non-zero exit status of a module.
+type ExitError struct {
+	Code int
+}
+
+// Error implements the error interface.
+func (e *ExitError) Error() string {
+	return "wasmthread: exit status " + strconv.Itoa(e.Code)
+}
+
+// ExitCode implements chord.ExitCoder.
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
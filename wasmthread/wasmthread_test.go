@@ -0,0 +1,102 @@
+package wasmthread
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/graphitects/chord"
+)
+
+// build builds the module of the testdata directory and returns its binary.
+func build(t *testing.T, name string) []byte {
+	t.Helper()
+	wasm := filepath.Join(t.TempDir(), name+".wasm")
+	cmd := exec.Command("go", "build", "-o", wasm, "./testdata/"+name)
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("building %s: %v\n%s", name, err, out)
+	}
+	data, err := os.ReadFile(wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// run runs the thread with the input and returns its output.
+func run(thread chord.Thread, in *chord.Input) (string, error) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bw)}
+	err := thread(in, out)
+	bw.Flush()
+	return buf.String(), err
+}
+
+func TestThread(t *testing.T) {
+	ctx := context.Background()
+	r := New(ctx)
+	defer r.Close(ctx)
+	m, err := r.Compile(ctx, "echo", build(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := &chord.Input{Key: "greet", Args: []string{"bob"}, Flags: map[string]string{"name": "alice"}}
+	if got, err := run(m.Thread(), in); err != nil || got != "echo bob greet alice" {
+		t.Fatalf("output = %q, %v, want %q", got, err, "echo bob greet alice")
+	}
+	var exit *ExitError
+	in = &chord.Input{Flags: map[string]string{"exit": "3"}}
+	if _, err := run(m.Thread(), in); !errors.As(err, &exit) || exit.Code != 3 {
+		t.Fatalf("error = %v, want the exit status 3", err)
+	}
+}
+
+func TestSwap(t *testing.T) {
+	ctx := context.Background()
+	r := New(ctx)
+	defer r.Close(ctx)
+	m, err := r.Compile(ctx, "echo", build(t, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2 := build(t, "v2")
+
+	// The execution running during Swap blocks writing its output to the
+	// pipe until the test reads it.
+	pr, pw := io.Pipe()
+	out := &chord.Output{ReadWriter: *bufio.NewReadWriter(bufio.NewReader(strings.NewReader("")), bufio.NewWriterSize(pw, 1))}
+	done := make(chan error)
+	go func() {
+		err := m.Thread()(&chord.Input{Key: "running"}, out)
+		pw.Close()
+		done <- err
+	}()
+	first := make([]byte, 1)
+	if _, err := io.ReadFull(pr, first); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Swap(ctx, v2); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := run(m.Thread(), &chord.Input{}); err != nil || got != "v2" {
+		t.Fatalf("output after Swap = %q, %v, want %q", got, err, "v2")
+	}
+	rest, _ := io.ReadAll(pr)
+	if got, err := string(first)+string(rest), <-done; err != nil || got != "echo running " {
+		t.Fatalf("output of the execution running during Swap = %q, %v, want %q", got, err, "echo running ")
+	}
+	if err := m.Swap(ctx, []byte("invalid")); err == nil {
+		t.Fatal("Swap of an invalid binary succeeded")
+	}
+}
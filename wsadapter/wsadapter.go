@@ -0,0 +1,103 @@
+/*
+Package wsadapter serves a chord tree over WebSocket connections.
+
+Every text frame received on a connection is a command line, dispatched
+through the root chord as by a chord.REPL, and the output of the thread is
+streamed back as text frames, one per flush of its output. This enables
+browser-based consoles on top of a chord tree.
+*/
+package wsadapter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/coder/websocket"
+
+	"github.com/graphitects/chord"
+	"github.com/graphitects/chord/httpadapter"
+)
+
+// Handler is an http.Handler upgrading requests to WebSocket sessions which
+// dispatch their frames through a root chord.
+type Handler struct {
+	// AcceptOptions configures the upgrade of the requests, such as the origins
+	// allowed to connect. It may be nil.
+	AcceptOptions *websocket.AcceptOptions
+	// Authenticator, when set, authenticates every request before it is
+	// upgraded, with the credentials returned by httpadapter.Credentials.
+	// Requests failing are answered with the status of the error, as returned
+	// by httpadapter.Status, usually 401, and its status text.
+	Authenticator chord.Authenticator
+
+	root *chord.Chord
+}
+
+// New returns a Handler dispatching the frames of its sessions through root.
+func New(root *chord.Chord) *Handler {
+	return &Handler{root: root}
+}
+
+// ServeHTTP implements http.Handler. The session lasts until the connection
+// is closed or the context of the request is done; the context of every input
+// is derived from it, holding the principal authenticated by the
+// Authenticator. Errors of the threads are sent back as text frames.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if h.Authenticator != nil {
+		p, err := chord.Authenticate(chord.WithExternal(ctx), h.Authenticator, httpadapter.Credentials(r))
+		if err != nil {
+			status := httpadapter.Status(err)
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		ctx = chord.WithPrincipal(ctx, p)
+	}
+	conn, err := websocket.Accept(w, r, h.AcceptOptions)
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	h.Serve(ctx, conn)
+}
+
+// Serve runs a session on an established connection until it is closed or
+// the context is done. The connection isn't authenticated by the
+// Authenticator: the principal of the caller, if any, is expected in ctx, as
+// placed by chord.WithPrincipal.
+func (h *Handler) Serve(ctx context.Context, conn *websocket.Conn) error {
+	ctx = chord.WithExternal(ctx)
+	fw := &frameWriter{ctx: ctx, conn: conn}
+	repl := chord.NewREPL(h.root, nil, fw)
+	for {
+		_, line, err := conn.Read(ctx)
+		if err != nil {
+			if websocket.CloseStatus(err) == websocket.StatusNormalClosure {
+				return nil
+			}
+			return err
+		}
+		if err := repl.Exec(ctx, string(line)); err != nil {
+			if _, err := fw.Write([]byte(err.Error())); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// frameWriter is an io.Writer sending every write as a text frame.
+type frameWriter struct {
+	ctx  context.Context
+	conn *websocket.Conn
+}
+
+// Write implements io.Writer.
+func (w *frameWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := w.conn.Write(w.ctx, websocket.MessageText, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
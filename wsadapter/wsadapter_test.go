@@ -0,0 +1,77 @@
+package wsadapter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/graphitects/chord"
+)
+
+func TestServe(t *testing.T) {
+	root := chord.NewChord()
+	root.Register("greet", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString("hello " + in.Flags["name"])
+		return out.Flush()
+	})
+	srv := httptest.NewServer(New(root))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+	if err := conn.Write(ctx, websocket.MessageText, []byte("greet --name=bob")); err != nil {
+		t.Fatal(err)
+	}
+	_, frame, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(frame); got != "hello bob" {
+		t.Fatalf("frame = %q, want %q", got, "hello bob")
+	}
+}
+
+func TestAuthenticator(t *testing.T) {
+	root := chord.NewChord()
+	root.Register("whoami", func(in *chord.Input, out *chord.Output) error {
+		out.WriteString(chord.PrincipalOf(in).Name)
+		return out.Flush()
+	})
+	h := New(root)
+	h.Authenticator = chord.AuthenticatorFunc(func(ctx context.Context, cred *chord.Credentials) (*chord.Principal, error) {
+		if cred.Token != "secret" {
+			return nil, errors.New("invalid token")
+		}
+		return &chord.Principal{Name: "bob"}, nil
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, res, err := websocket.Dial(ctx, srv.URL, nil)
+	if err == nil || res == nil || res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Dial without credentials = %v, want a 401 response", err)
+	}
+	conn, _, err := websocket.Dial(ctx, srv.URL, &websocket.DialOptions{
+		HTTPHeader: http.Header{"Authorization": {"Bearer secret"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+	conn.Write(ctx, websocket.MessageText, []byte("whoami"))
+	if _, frame, err := conn.Read(ctx); err != nil || string(frame) != "bob" {
+		t.Fatalf("frame = %q, %v, want %q", frame, err, "bob")
+	}
+}